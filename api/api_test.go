@@ -0,0 +1,283 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/mock"
+	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/storage"
+
+	"github.com/caio/go-tdigest/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	s, err := storage.NewStorage(filepath.Join(t.TempDir(), "api.db"))
+	require.Nil(t, err)
+
+	srv := NewServer(s)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		srv.Shutdown()
+		ts.Close()
+	})
+
+	return ts
+}
+
+// do sends the request with the JSON body and decodes the JSON response into out, it returns the status code
+func do(t *testing.T, method, u string, body, out any) int {
+	var r *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		require.Nil(t, err)
+		r = bytes.NewReader(b)
+	} else {
+		r = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, u, r)
+	require.Nil(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr apiError
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		t.Logf("%s %s: %d %s", method, u, resp.StatusCode, apiErr.Error)
+	} else if out != nil {
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+
+	return resp.StatusCode
+}
+
+func TestLoaders(t *testing.T) {
+	ts := newTestServer(t)
+	api := ts.URL + Prefix
+
+	conf := &model.Loader{
+		Name: "api loader",
+		URL:  "http://localhost:8080/",
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    10,
+			Connections: 1,
+		},
+	}
+
+	var created model.Loader
+	require.Equal(t, http.StatusCreated, do(t, http.MethodPost, api+"/loaders", conf, &created))
+	require.NotEmpty(t, created.UUID)
+	require.Equal(t, http.MethodGet, created.Method)
+	require.Equal(t, "fast_http", created.HTTPEngine)
+
+	var loaded model.Loader
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/loaders/"+created.UUID, nil, &loaded))
+	require.Equal(t, created, loaded)
+
+	var loaders []*model.Loader
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/loaders?name="+url.QueryEscape("api loader"), nil, &loaders))
+	require.Len(t, loaders, 1)
+
+	tags := []*model.LoaderTag{{Key: "env", Value: "staging"}, {Key: "team"}}
+	require.Equal(t, http.StatusOK, do(t, http.MethodPost, api+"/loaders/"+created.UUID+"/tags", tags, nil))
+	require.Equal(t, http.StatusConflict, do(t, http.MethodPost, api+"/loaders/"+created.UUID+"/tags", tags[:1], nil))
+	require.Equal(t, http.StatusOK, do(t, http.MethodPut, api+"/loaders/"+created.UUID+"/tags/env", tagValue{Value: "prod"}, nil))
+
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/loaders?tag=env=prod", nil, &loaders))
+	require.Len(t, loaders, 1)
+
+	require.Equal(t, http.StatusNoContent, do(t, http.MethodDelete, api+"/loaders/"+created.UUID+"/tags/team", nil, nil))
+
+	var loaderTags []*model.LoaderTag
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/loaders/"+created.UUID+"/tags", nil, &loaderTags))
+	require.Len(t, loaderTags, 1)
+	require.Equal(t, "prod", loaderTags[0].Value)
+
+	require.Equal(t, http.StatusBadRequest, do(t, http.MethodPost, api+"/loaders", &model.Loader{Name: "no url"}, nil))
+	require.Equal(t, http.StatusConflict, do(t, http.MethodPost, api+"/loaders", conf, nil))
+	require.Equal(t, http.StatusNoContent, do(t, http.MethodDelete, api+"/loaders/"+created.UUID, nil, nil))
+	require.Equal(t, http.StatusNotFound, do(t, http.MethodGet, api+"/loaders/"+created.UUID, nil, nil))
+	require.Equal(t, http.StatusNotFound, do(t, http.MethodGet, api+"/summaries/"+created.UUID, nil, nil))
+	require.Equal(t, http.StatusNotFound, do(t, http.MethodGet, api+"/runs/"+created.UUID, nil, nil))
+}
+
+func TestTemplates(t *testing.T) {
+	ts := newTestServer(t)
+	api := ts.URL + Prefix
+
+	var tmpl model.Template
+	require.Equal(t, http.StatusCreated, do(t, http.MethodPost, api+"/templates", templateContent{Name: "short", Content: "{{ .URL }}"}, &tmpl))
+	require.Equal(t, "short", tmpl.Name)
+	require.Equal(t, http.StatusConflict, do(t, http.MethodPost, api+"/templates", templateContent{Name: "short"}, nil))
+
+	require.Equal(t, http.StatusOK, do(t, http.MethodPut, api+"/templates/short", templateContent{Content: "{{ .UUID }}"}, &tmpl))
+	require.Equal(t, "{{ .UUID }}", tmpl.Content)
+
+	var tmpls []*model.Template
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/templates", nil, &tmpls))
+	require.Len(t, tmpls, 1)
+
+	require.Equal(t, http.StatusNoContent, do(t, http.MethodDelete, api+"/templates/short", nil, nil))
+	require.Equal(t, http.StatusNotFound, do(t, http.MethodGet, api+"/templates/short", nil, nil))
+	require.Equal(t, http.StatusNotFound, do(t, http.MethodPut, api+"/templates/short", templateContent{}, nil))
+}
+
+func TestRuns(t *testing.T) {
+	_, target := mock.NewServer(0)
+	defer target.Close()
+
+	u, err := url.JoinPath(target.URL, "ok")
+	require.Nil(t, err)
+
+	ts := newTestServer(t)
+	api := ts.URL + Prefix
+
+	conf := &model.Loader{
+		URL:                          u,
+		AggregateWindow:              time.Second,
+		GatherAggregateRequestsStats: true,
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    200,
+			Connections: 2,
+		},
+		Thresholds: model.Thresholds{
+			{Metric: "requests", Operator: model.ThresholdEqual, Value: "200"},
+			{Metric: "fail", Operator: model.ThresholdGreater, Value: "0"},
+		},
+	}
+
+	var created model.Loader
+	require.Equal(t, http.StatusCreated, do(t, http.MethodPost, api+"/loaders", conf, &created))
+	require.Len(t, created.Thresholds, 2)
+
+	invalid := *conf
+	invalid.Name = "api invalid threshold"
+	invalid.Thresholds = model.Thresholds{{Metric: "p99", Operator: model.ThresholdLess, Value: "200"}}
+	require.Equal(t, http.StatusBadRequest, do(t, http.MethodPost, api+"/loaders", &invalid, nil))
+
+	var run Run
+	require.Equal(t, http.StatusAccepted, do(t, http.MethodPost, api+"/loaders/"+created.UUID+"/runs", startRunRequest{Description: "api run"}, &run))
+	require.Equal(t, RunRunning, run.Status)
+
+	require.Eventually(t, func() bool {
+		require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/runs/"+run.ID, nil, &run))
+		return run.Status != RunRunning
+	}, 10*time.Second, 50*time.Millisecond)
+
+	require.Equal(t, RunFinished, run.Status)
+	require.Equal(t, 200, run.Stats.ReqCount)
+	require.Equal(t, 200, run.Stats.HTTPCodes[200])
+	require.NotEmpty(t, run.SummaryID)
+	require.Len(t, run.Thresholds, 2)
+	require.True(t, run.Thresholds[0].Pass)
+	require.False(t, run.Thresholds[1].Pass)
+	require.Equal(t, "0", run.Thresholds[1].Actual)
+
+	var summary model.Summary
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/summaries/"+run.SummaryID+"?requests=true", nil, &summary))
+	require.Equal(t, 200, summary.ReqCount)
+	require.Equal(t, "api run", summary.Description)
+	require.NotEmpty(t, summary.AggregatedStats)
+	require.NotEmpty(t, summary.TDigest)
+	require.Empty(t, summary.Percentiles)
+
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/summaries/"+run.SummaryID+"?percentiles=p99.9,p10", nil, &summary))
+	require.Len(t, summary.Percentiles, 2)
+	require.Equal(t, 99.9, summary.Percentiles[0].Percentile)
+	require.GreaterOrEqual(t, summary.Percentiles[0].Value, summary.Percentiles[1].Value)
+	require.Equal(t, http.StatusBadRequest, do(t, http.MethodGet, api+"/summaries/"+run.SummaryID+"?percentiles=p101", nil, nil))
+
+	// The stopped run still saves the summary
+	created.ReqCount = 0
+	created.Duration = time.Minute
+	created.RateLimit = 100
+	created.UUID = ""
+	created.Name = "api stopped run"
+	require.Equal(t, http.StatusCreated, do(t, http.MethodPost, api+"/loaders", &created, &created))
+	require.Equal(t, http.StatusAccepted, do(t, http.MethodPost, api+"/loaders/"+created.UUID+"/runs", nil, &run))
+
+	time.Sleep(500 * time.Millisecond)
+	require.Equal(t, http.StatusOK, do(t, http.MethodPost, api+"/runs/"+run.ID+"/stop", nil, &run))
+	require.Equal(t, RunFinished, run.Status)
+	require.True(t, run.Stopped)
+	require.NotZero(t, run.Stats.ReqCount)
+	require.NotEmpty(t, run.SummaryID)
+
+	var runs []Run
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/runs", nil, &runs))
+	require.Len(t, runs, 2)
+	require.Equal(t, run.ID, runs[0].ID)
+
+	var summaries []*model.Summary
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/loaders/"+created.UUID+"/summaries", nil, &summaries))
+	require.Len(t, summaries, 1)
+	require.Equal(t, run.SummaryID, summaries[0].UUID)
+
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, api+"/loaders/"+created.UUID+"/summaries?percentiles=99.99", nil, &summaries))
+	require.Len(t, summaries[0].Percentiles, 1)
+}
+
+func TestRunsRetention(t *testing.T) {
+	rs := newRuns()
+	rs.maxFinished = 2
+
+	for i := 0; i < 3; i++ {
+		l, err := loader.NewLoader(&model.Loader{
+			URL:        "http://localhost",
+			Method:     http.MethodGet,
+			HTTPEngine: "fast_http",
+			LoaderReqDetails: model.LoaderReqDetails{
+				ReqCount:    1,
+				Connections: 1,
+			},
+		})
+		require.Nil(t, err)
+
+		td, err := tdigest.New()
+		require.Nil(t, err)
+
+		rn := &run{
+			l: l,
+			state: Run{
+				ID:     strconv.Itoa(i),
+				Status: RunRunning,
+				Start:  time.Now(),
+			},
+			t:         td,
+			errors:    make(map[string]int),
+			httpCodes: make(map[int]int),
+		}
+		rn.Observe(&model.RequestStat{RetCode: http.StatusOK, Duration: time.Millisecond})
+		rs.runs[rn.state.ID] = rn
+
+		rn.finish("summary", nil, nil)
+		rs.finish(rn.state.ID)
+
+		// The finished run keeps its stats without the loader
+		require.Nil(t, rn.l)
+		require.Nil(t, rn.t)
+		state := rn.snapshot()
+		require.Equal(t, RunFinished, state.Status)
+		require.Equal(t, 1, state.Stats.ReqCount)
+		require.Equal(t, 1, state.Stats.HTTPCodes[http.StatusOK])
+	}
+
+	// Only the last finished runs are kept
+	_, ok := rs.get("0")
+	require.False(t, ok)
+
+	states := rs.list()
+	require.Len(t, states, 2)
+}
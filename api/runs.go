@@ -0,0 +1,383 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/caio/go-tdigest/v4"
+	"github.com/google/uuid"
+)
+
+// The run statuses
+const (
+	RunRunning  = "running"
+	RunFinished = "finished"
+	RunFailed   = "failed"
+)
+
+// maxFinishedRuns is how many finished runs are kept, the older ones are forgotten
+// as their summaries are saved in the storage
+const maxFinishedRuns = 100
+
+// Run is the state of the benchmark started over the API
+type Run struct {
+	ID       string     `json:"id"`
+	LoaderID string     `json:"loader_id"`
+	Status   string     `json:"status"`
+	Start    time.Time  `json:"start"`
+	End      *time.Time `json:"end,omitempty"`
+	Stopped  bool       `json:"stopped,omitempty"`
+	Error    string     `json:"error,omitempty"`
+
+	// SummaryID is the saved summary of the finished run
+	SummaryID string `json:"summary_id,omitempty"`
+
+	// Thresholds are the loader thresholds checked against the summary of the finished run
+	Thresholds []*loader.ThresholdResult `json:"thresholds,omitempty"`
+
+	Stats *LiveStats `json:"stats"`
+}
+
+// LiveStats are the statistics of the requests finished so far
+type LiveStats struct {
+	Elapsed    time.Duration `json:"elapsed"`
+	ReqCount   int           `json:"requests_count"`
+	SuccessReq int           `json:"success_req"`
+	FailReq    int           `json:"fail_req"`
+	ReqPerSec  float64       `json:"req_per_sec"`
+	InFlight   int           `json:"in_flight"`
+	TargetRate float64       `json:"target_rate,omitempty"`
+
+	P50ReqTime time.Duration `json:"p_50_req_time"`
+	P90ReqTime time.Duration `json:"p_90_req_time"`
+	P99ReqTime time.Duration `json:"p_99_req_time"`
+
+	model.ConnectionStats
+
+	Errors    map[string]int `json:"errors"`
+	HTTPCodes map[int]int    `json:"http_codes"`
+}
+
+// startRunRequest is the optional body of the run start
+type startRunRequest struct {
+	Description string `json:"description"`
+}
+
+// run is the benchmark running in the background.
+// When it finishes the final stats are kept and the loader with the live stats are released.
+type run struct {
+	l      *loader.Loader
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	state     Run
+	success   int
+	fail      int
+	t         *tdigest.TDigest
+	errors    map[string]int
+	httpCodes map[int]int
+	stats     *LiveStats
+}
+
+// Observe counts the request stat, the flow stats are skipped as their steps are observed separately
+func (r *run) Observe(stat *model.RequestStat) {
+	if stat.Flow {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stats != nil {
+		return
+	}
+
+	if loader.StatFailed(stat) {
+		r.fail++
+		r.errors[loader.StatError(stat)]++
+	} else {
+		r.success++
+	}
+
+	if loader.StatResponded(stat) {
+		r.httpCodes[stat.RetCode]++
+	}
+
+	_ = r.t.Add(float64(stat.Duration))
+}
+
+// snapshot returns the run state with the current stats
+func (r *run) snapshot() Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.state
+	state.Stats = r.stats
+	if state.Stats == nil {
+		state.Stats = r.liveStats()
+	}
+
+	return state
+}
+
+// liveStats computes the stats of the requests finished so far, the run lock must be held
+func (r *run) liveStats() *LiveStats {
+	state := r.state
+
+	end := time.Now()
+	if state.End != nil {
+		end = *state.End
+	}
+
+	stats := &LiveStats{
+		Elapsed:         end.Sub(state.Start),
+		ReqCount:        r.success + r.fail,
+		SuccessReq:      r.success,
+		FailReq:         r.fail,
+		ConnectionStats: r.l.Connections(),
+		Errors:          make(map[string]int, len(r.errors)),
+		HTTPCodes:       make(map[int]int, len(r.httpCodes)),
+	}
+
+	if state.Status == RunRunning {
+		stats.InFlight = r.l.InFlight()
+		stats.TargetRate = r.l.TargetRate()
+	}
+
+	if stats.Elapsed > 0 {
+		stats.ReqPerSec = float64(r.success) / stats.Elapsed.Seconds()
+	}
+
+	if stats.ReqCount != 0 {
+		stats.P50ReqTime = time.Duration(r.t.Quantile(0.5))
+		stats.P90ReqTime = time.Duration(r.t.Quantile(0.9))
+		stats.P99ReqTime = time.Duration(r.t.Quantile(0.99))
+	}
+
+	for k, v := range r.errors {
+		stats.Errors[k] = v
+	}
+
+	for k, v := range r.httpCodes {
+		stats.HTTPCodes[k] = v
+	}
+
+	return stats
+}
+
+func (r *run) finish(summaryID string, thresholds []*loader.ThresholdResult, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	end := time.Now()
+	r.state.End = &end
+	r.state.SummaryID = summaryID
+	r.state.Thresholds = thresholds
+
+	if err != nil {
+		r.state.Status = RunFailed
+		r.state.Error = err.Error()
+	} else {
+		r.state.Status = RunFinished
+	}
+
+	r.stats = r.liveStats()
+	r.l = nil
+	r.t = nil
+	r.errors = nil
+	r.httpCodes = nil
+}
+
+// runs are the running benchmarks and the last finished ones
+type runs struct {
+	mu   sync.Mutex
+	runs map[string]*run
+	wg   sync.WaitGroup
+
+	// finished are the IDs of the finished runs from the oldest, at most maxFinished are kept
+	finished    []string
+	maxFinished int
+}
+
+func newRuns() *runs {
+	return &runs{
+		runs:        make(map[string]*run),
+		maxFinished: maxFinishedRuns,
+	}
+}
+
+// finish records the finished run and forgets the oldest finished runs over the limit
+func (rs *runs) finish(id string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.finished = append(rs.finished, id)
+	for len(rs.finished) > rs.maxFinished {
+		delete(rs.runs, rs.finished[0])
+		rs.finished = rs.finished[1:]
+	}
+}
+
+func (rs *runs) get(id string) (*run, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r, ok := rs.runs[id]
+	return r, ok
+}
+
+func (rs *runs) list() []Run {
+	rs.mu.Lock()
+	all := make([]*run, 0, len(rs.runs))
+	for _, r := range rs.runs {
+		all = append(all, r)
+	}
+	rs.mu.Unlock()
+
+	states := make([]Run, 0, len(all))
+	for _, r := range all {
+		states = append(states, r.snapshot())
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Start.After(states[j].Start)
+	})
+
+	return states
+}
+
+func (rs *runs) stopAll() {
+	rs.mu.Lock()
+	for _, r := range rs.runs {
+		r.cancel()
+	}
+	rs.mu.Unlock()
+
+	rs.wg.Wait()
+}
+
+// startRun starts the benchmark of the saved loader, the summary is saved when it finishes
+func (s *Server) startRun(w http.ResponseWriter, r *http.Request) {
+	var req startRunRequest
+	if r.ContentLength != 0 {
+		err := decodeJSON(r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	conf, err := s.storage.GetLoaderByID(r.PathValue("id"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	l, err := loader.NewLoader(conf)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("could not create loader: %w", err))
+		return
+	}
+
+	t, err := tdigest.New()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("tdigest error: %w", err))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rn := &run{
+		l:      l,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		state: Run{
+			ID:       uuid.NewString(),
+			LoaderID: conf.UUID,
+			Status:   RunRunning,
+			Start:    time.Now(),
+		},
+		t:         t,
+		errors:    make(map[string]int),
+		httpCodes: make(map[int]int),
+	}
+	l.AddObserver(rn)
+
+	s.runs.mu.Lock()
+	s.runs.runs[rn.state.ID] = rn
+	s.runs.wg.Add(1)
+	s.runs.mu.Unlock()
+
+	go func() {
+		defer s.runs.wg.Done()
+		defer s.runs.finish(rn.state.ID)
+		defer close(rn.done)
+		defer cancel()
+
+		summary, err := l.Do(ctx)
+		if err != nil {
+			log.Printf("Run %s failed: %v", rn.state.ID, err)
+			rn.finish("", nil, err)
+			return
+		}
+
+		summary.Description = req.Description
+		summaryID, err := s.storage.InsertSummary(conf.UUID, summary, conf.GatherFullRequestsStats, conf.GatherAggregateRequestsStats)
+		if err != nil {
+			rn.finish(summaryID, nil, fmt.Errorf("could not save summary: %w", err))
+			return
+		}
+
+		thresholds, err := loader.CheckThresholds(conf.Thresholds, summary)
+		if err != nil {
+			err = fmt.Errorf("could not check thresholds: %w", err)
+		}
+
+		rn.finish(summaryID, thresholds, err)
+	}()
+
+	writeJSON(w, http.StatusAccepted, rn.snapshot())
+}
+
+func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.runs.list())
+}
+
+func (s *Server) getRun(w http.ResponseWriter, r *http.Request) {
+	rn, ok := s.runs.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("run not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rn.snapshot())
+}
+
+// stopRun stops the benchmark gracefully and waits until its summary is saved
+func (s *Server) stopRun(w http.ResponseWriter, r *http.Request) {
+	rn, ok := s.runs.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("run not found"))
+		return
+	}
+
+	rn.mu.Lock()
+	if rn.state.Status == RunRunning {
+		rn.state.Stopped = true
+	}
+	rn.mu.Unlock()
+
+	rn.cancel()
+	<-rn.done
+
+	writeJSON(w, http.StatusOK, rn.snapshot())
+}
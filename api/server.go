@@ -0,0 +1,601 @@
+// Package api is the REST API over the storage and the loader used by hload serve
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/storage"
+	"github.com/tmwalaszek/hload/time_formats"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// Prefix is the path prefix of the API version
+	Prefix = "/api/v1"
+
+	defaultLimit = 10
+)
+
+// Server serves the API, the running and the last finished runs are kept in memory
+type Server struct {
+	storage *storage.Storage
+	runs    *runs
+
+	mux *http.ServeMux
+}
+
+// NewServer creates the API server over the storage
+func NewServer(s *storage.Storage) *Server {
+	srv := &Server{
+		storage: s,
+		runs:    newRuns(),
+		mux:     http.NewServeMux(),
+	}
+
+	srv.mux.HandleFunc("GET "+Prefix+"/loaders", srv.listLoaders)
+	srv.mux.HandleFunc("POST "+Prefix+"/loaders", srv.createLoader)
+	srv.mux.HandleFunc("GET "+Prefix+"/loaders/{id}", srv.getLoader)
+	srv.mux.HandleFunc("DELETE "+Prefix+"/loaders/{id}", srv.deleteLoader)
+	srv.mux.HandleFunc("GET "+Prefix+"/loaders/{id}/summaries", srv.listSummaries)
+	srv.mux.HandleFunc("GET "+Prefix+"/loaders/{id}/tags", srv.listTags)
+	srv.mux.HandleFunc("POST "+Prefix+"/loaders/{id}/tags", srv.createTags)
+	srv.mux.HandleFunc("PUT "+Prefix+"/loaders/{id}/tags/{key}", srv.updateTag)
+	srv.mux.HandleFunc("DELETE "+Prefix+"/loaders/{id}/tags/{key}", srv.deleteTag)
+	srv.mux.HandleFunc("POST "+Prefix+"/loaders/{id}/runs", srv.startRun)
+
+	srv.mux.HandleFunc("GET "+Prefix+"/runs", srv.listRuns)
+	srv.mux.HandleFunc("GET "+Prefix+"/runs/{id}", srv.getRun)
+	srv.mux.HandleFunc("POST "+Prefix+"/runs/{id}/stop", srv.stopRun)
+
+	srv.mux.HandleFunc("GET "+Prefix+"/summaries/{id}", srv.getSummary)
+
+	srv.mux.HandleFunc("GET "+Prefix+"/templates", srv.listTemplates)
+	srv.mux.HandleFunc("POST "+Prefix+"/templates", srv.createTemplate)
+	srv.mux.HandleFunc("GET "+Prefix+"/templates/{name}", srv.getTemplate)
+	srv.mux.HandleFunc("PUT "+Prefix+"/templates/{name}", srv.updateTemplate)
+	srv.mux.HandleFunc("DELETE "+Prefix+"/templates/{name}", srv.deleteTemplate)
+
+	return srv
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Shutdown stops the running benchmarks and waits until their summaries are saved
+func (s *Server) Shutdown() {
+	s.runs.stopAll()
+}
+
+// apiError is the error response body
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, apiError{Error: err.Error()})
+}
+
+// writeStorageError writes the storage error as not found, conflict or the internal error
+func writeStorageError(w http.ResponseWriter, err error) {
+	var sqliteErr sqlite3.Error
+
+	switch {
+	case errors.Is(err, storage.ErrNotFound) || errors.Is(err, sql.ErrNoRows) || strings.Contains(err.Error(), "does not exists"):
+		writeError(w, http.StatusNotFound, err)
+	case errors.As(err, &sqliteErr) && errors.Is(sqliteErr.Code, sqlite3.ErrConstraint) || strings.Contains(err.Error(), "already exists"):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err != nil {
+		return fmt.Errorf("could not decode the request body: %w", err)
+	}
+
+	return nil
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("wrong %s: %w", name, err)
+	}
+
+	return i, nil
+}
+
+func queryBool(r *http.Request, name string) bool {
+	b, _ := strconv.ParseBool(r.URL.Query().Get(name))
+	return b
+}
+
+// queryPercentiles returns the comma separated percentiles query parameter, e.g. p99.9,p99.99
+func queryPercentiles(r *http.Request) ([]float64, error) {
+	value := r.URL.Query().Get("percentiles")
+	if value == "" {
+		return nil, nil
+	}
+
+	return loader.ParsePercentiles(strings.Split(value, ","))
+}
+
+// setPercentiles computes the percentiles of the summaries, the summaries without the t-digest are left out
+func setPercentiles(summaries []*model.Summary, percentiles []float64) error {
+	for _, summary := range summaries {
+		err := loader.SetPercentiles(summary, percentiles)
+		if err != nil && !errors.Is(err, loader.ErrNoTDigest) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryRange returns the from and to query parameters as the epoch, from zero when not set
+func queryRange(r *http.Request) (int64, int64, error) {
+	var from, to int64
+	var err error
+
+	if value := r.URL.Query().Get("from"); value != "" {
+		from, err = time_formats.TimeToEpoch(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("wrong from: %w", err)
+		}
+
+		to = time.Now().UTC().Unix()
+	}
+
+	if value := r.URL.Query().Get("to"); value != "" {
+		if from == 0 {
+			return 0, 0, errors.New("to needs from")
+		}
+
+		to, err = time_formats.TimeToEpoch(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("wrong to: %w", err)
+		}
+	}
+
+	return from, to, nil
+}
+
+// listLoaders lists the loaders filtered by the name, the description, the tags or the creation dates range
+func (s *Server) listLoaders(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := queryRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var loaders []*model.Loader
+	switch {
+	case query.Get("name") != "":
+		loaders, err = s.storage.GetLoaderByName(query.Get("name"))
+	case query.Get("description") != "":
+		loaders, err = s.storage.GetLoaderByDescription(query.Get("description"))
+	case len(query["tag"]) != 0:
+		loaders, err = s.storage.GetLoaderByTags(parseTags(query["tag"]))
+	case from != 0:
+		loaders, err = s.storage.GetLoadersByRange(from, to, limit)
+	default:
+		loaders, err = s.storage.GetLoaders(limit)
+	}
+
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	if loaders == nil {
+		loaders = []*model.Loader{}
+	}
+
+	writeJSON(w, http.StatusOK, loaders)
+}
+
+// parseTags parses the key=value tags, the value is optional
+func parseTags(values []string) []*model.LoaderTag {
+	tags := make([]*model.LoaderTag, 0, len(values))
+	for _, value := range values {
+		key, value, _ := strings.Cut(value, "=")
+		tags = append(tags, &model.LoaderTag{Key: key, Value: value})
+	}
+
+	return tags
+}
+
+// createLoader saves the loader configuration, it is validated by creating the loader
+func (s *Server) createLoader(w http.ResponseWriter, r *http.Request) {
+	conf := &model.Loader{}
+	err := decodeJSON(r, conf)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = validateLoader(conf)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := s.storage.InsertLoaderConfiguration(conf)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	conf, err = s.storage.GetLoaderByID(id)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, conf)
+}
+
+// validateLoader sets the defaults the CLI sets and checks the loader can be created
+func validateLoader(conf *model.Loader) error {
+	if conf.URL == "" {
+		return errors.New("url is required")
+	}
+
+	if conf.Connections <= 0 && len(conf.Stages) == 0 {
+		return errors.New("connections are required")
+	}
+
+	if conf.ReqCount == 0 && conf.Duration == 0 && len(conf.Stages) == 0 && (conf.Feeder == nil || conf.Feeder.Mode != model.FeederModeUnique) {
+		return errors.New("requests count, duration or stages are required")
+	}
+
+	if conf.Method == "" {
+		conf.Method = http.MethodGet
+	}
+
+	if conf.HTTPEngine == "" {
+		conf.HTTPEngine = "fast_http"
+	}
+
+	if conf.Name == "" {
+		conf.Name = fmt.Sprintf("Configuration %v", time.Now().Format("Mon, 02 Jan 2006 15:04:05.000"))
+	}
+
+	if len(conf.Stages) > 0 {
+		conf.Stages.SetDefaults()
+		if conf.StageTarget == "" {
+			conf.StageTarget = model.StageTargetConnections
+		}
+	}
+
+	if conf.Feeder != nil {
+		if len(conf.Feeder.Data) == 0 {
+			return errors.New("feeder data is required, the server does not read the feeder files")
+		}
+
+		conf.Feeder.SetDefaults()
+	}
+
+	conf.Endpoints.SetDefaults()
+	conf.Flow.SetDefaults()
+
+	// Creating the loader validates the stages, the endpoints, the flow, the feeder and the assertions
+	c := *conf
+	_, err := loader.NewLoader(&c)
+
+	return err
+}
+
+func (s *Server) getLoader(w http.ResponseWriter, r *http.Request) {
+	conf, err := s.storage.GetLoaderByID(r.PathValue("id"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conf)
+}
+
+func (s *Server) deleteLoader(w http.ResponseWriter, r *http.Request) {
+	_, err := s.storage.GetLoaderByID(r.PathValue("id"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	err = s.storage.DeleteLoader(r.PathValue("id"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listSummaries lists the loader summaries, with the aggregated and the requests stats when requests is set
+// and the percentiles computed from the t-digest when percentiles is set
+func (s *Server) listSummaries(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	percentiles, err := queryPercentiles(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := queryRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = s.storage.GetLoaderByID(r.PathValue("id"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	opts := []storage.Option{storage.WithLimit(limit), storage.WithFrom(from), storage.WithTo(to)}
+	if queryBool(r, "requests") {
+		opts = append(opts, storage.WithRequests())
+	}
+
+	summaries, err := s.storage.GetSummaries(r.PathValue("id"), opts...)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	if summaries == nil {
+		summaries = []*model.Summary{}
+	}
+
+	err = setPercentiles(summaries, percentiles)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (s *Server) getSummary(w http.ResponseWriter, r *http.Request) {
+	percentiles, err := queryPercentiles(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	summary, err := s.storage.GetSummaryByID(r.PathValue("id"), queryBool(r, "requests"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	err = setPercentiles([]*model.Summary{summary}, percentiles)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.storage.GetLoaderTags(r.PathValue("id"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func (s *Server) createTags(w http.ResponseWriter, r *http.Request) {
+	var tags []*model.LoaderTag
+	err := decodeJSON(r, &tags)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, tag := range tags {
+		if tag.Key == "" {
+			writeError(w, http.StatusBadRequest, errors.New("empty tag key"))
+			return
+		}
+	}
+
+	err = s.storage.InsertLoaderConfigurationTags(r.PathValue("id"), tags)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	s.listTags(w, r)
+}
+
+// tagValue is the body of the tag update
+type tagValue struct {
+	Value string `json:"value"`
+}
+
+func (s *Server) updateTag(w http.ResponseWriter, r *http.Request) {
+	var value tagValue
+	err := decodeJSON(r, &value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.storage.UpdateLoaderTag(r.PathValue("id"), r.PathValue("key"), value.Value)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	s.listTags(w, r)
+}
+
+// deleteTag deletes the tag, the value query parameter deletes only the tag with the value
+func (s *Server) deleteTag(w http.ResponseWriter, r *http.Request) {
+	tag := &model.LoaderTag{
+		Key:   r.PathValue("key"),
+		Value: r.URL.Query().Get("value"),
+	}
+
+	err := s.storage.DeleteLoaderTag(r.PathValue("id"), []*model.LoaderTag{tag})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		writeStorageError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTemplates(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tmpls, err := s.storage.GetTemplates(limit)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	if tmpls == nil {
+		tmpls = []*model.Template{}
+	}
+
+	writeJSON(w, http.StatusOK, tmpls)
+}
+
+// templateContent is the body of the template create and update
+type templateContent struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+func (s *Server) createTemplate(w http.ResponseWriter, r *http.Request) {
+	var tmpl templateContent
+	err := decodeJSON(r, &tmpl)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if tmpl.Name == "" {
+		writeError(w, http.StatusBadRequest, errors.New("template name is required"))
+		return
+	}
+
+	err = s.storage.InsertTemplate(tmpl.Name, tmpl.Content)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	created, err := s.storage.GetTemplateByName(tmpl.Name)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) getTemplate(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := s.storage.GetTemplateByName(r.PathValue("name"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tmpl)
+}
+
+func (s *Server) updateTemplate(w http.ResponseWriter, r *http.Request) {
+	var tmpl templateContent
+	err := decodeJSON(r, &tmpl)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.storage.UpdateTemplate(r.PathValue("name"), tmpl.Content)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows updated") {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		writeStorageError(w, err)
+		return
+	}
+
+	s.getTemplate(w, r)
+}
+
+func (s *Server) deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	_, err := s.storage.GetTemplateByName(r.PathValue("name"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	err = s.storage.DeleteTemplate(r.PathValue("name"))
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/distributed"
+
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	Listen string
+
+	cliio.IO
+}
+
+func (o *Options) Run() {
+	ln, err := net.Listen("tcp", o.Listen)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	server := &http.Server{
+		Handler:           distributed.NewAgent().Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+
+	go func() {
+		<-c
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(ctx)
+	}()
+
+	fmt.Fprintf(o.Out, "Agent listening on %s\n", ln.Addr())
+
+	err = server.Serve(ln)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func NewAgentCmd(cliIO cliio.IO) *cobra.Command {
+	opts := Options{
+		IO: cliIO,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Run the agent which runs the benchmarks of the controller (loader run --agents)",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Listen, "listen", "l", ":"+distributed.DefaultPort, "Address the agent listens on")
+
+	return cmd
+}
@@ -0,0 +1,245 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/report"
+	"github.com/tmwalaszek/hload/storage"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type BatchOptions struct {
+	cliio.IO
+
+	UUIDs []string
+	Tags  []string
+	Save  bool
+
+	ReportJUnit string
+	ReportTAP   string
+
+	storage *storage.Storage
+	loaders []*model.Loader
+}
+
+func (o *BatchOptions) Complete() {
+	if len(o.UUIDs) == 0 && len(o.Tags) == 0 {
+		fmt.Fprintf(o.Err, "Error: --uuid or --tag is required")
+		os.Exit(1)
+	}
+
+	var err error
+	o.storage, err = storage.NewStorage(viper.GetString("db"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Can't create storage handler: %v", err)
+		os.Exit(1)
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range o.UUIDs {
+		conf, err := o.storage.GetLoaderByID(id)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+
+		seen[conf.UUID] = true
+		o.loaders = append(o.loaders, conf)
+	}
+
+	if len(o.Tags) > 0 {
+		var tags []*model.LoaderTag
+		for _, tag := range o.Tags {
+			key, value, _ := strings.Cut(tag, "=")
+			tags = append(tags, &model.LoaderTag{
+				Key:   key,
+				Value: value,
+			})
+		}
+
+		loaders, err := o.storage.GetLoaderByTags(tags)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+
+		for _, conf := range loaders {
+			if !seen[conf.UUID] {
+				seen[conf.UUID] = true
+				o.loaders = append(o.loaders, conf)
+			}
+		}
+	}
+
+	if len(o.loaders) == 0 {
+		fmt.Fprintf(o.Err, "Error: no loaders found")
+		os.Exit(1)
+	}
+}
+
+// runCase runs the loader and checks the summary thresholds
+func (o *BatchOptions) runCase(ctx context.Context, conf *model.Loader) *report.Case {
+	c := &report.Case{
+		Loader: conf,
+	}
+
+	l, err := loader.NewLoader(conf)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+
+	c.Summary, err = l.Do(ctx)
+	if err != nil {
+		c.Summary = nil
+		c.Err = err
+		return c
+	}
+
+	if o.Save {
+		_, err = o.storage.InsertSummary(conf.UUID, c.Summary, conf.GatherFullRequestsStats, conf.GatherAggregateRequestsStats)
+		if err != nil {
+			log.Fatalf("Error saving summary: %v", err)
+		}
+	}
+
+	c.Thresholds, err = loader.CheckThresholds(conf.Thresholds, c.Summary)
+	if err != nil {
+		c.Err = err
+	}
+
+	return c
+}
+
+func (o *BatchOptions) Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+
+	go func() {
+		<-sig
+		log.Print("Received signal and will stop the batch")
+		cancel()
+	}()
+
+	cases := make([]*report.Case, 0, len(o.loaders))
+	for i, conf := range o.loaders {
+		if ctx.Err() != nil {
+			cases = append(cases, &report.Case{Loader: conf, Skipped: true})
+			continue
+		}
+
+		fmt.Fprintf(o.Out, "[%d/%d] Running %s (%s)\n", i+1, len(o.loaders), conf.Name, conf.UUID)
+
+		c := o.runCase(ctx, conf)
+		cases = append(cases, c)
+
+		switch {
+		case c.Err != nil:
+			fmt.Fprintf(o.Out, "  error: %v\n", c.Err)
+		case c.Failed():
+			fmt.Fprintf(o.Out, "  failed: %s\n  %s\n", strings.Join(c.Failures(), "; "), c.Metrics())
+		default:
+			fmt.Fprintf(o.Out, "  passed: %s\n", c.Metrics())
+		}
+	}
+
+	err := writeReports(o.ReportJUnit, o.ReportTAP, cases)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	var failed, errored, skipped int
+	for _, c := range cases {
+		switch {
+		case c.Skipped:
+			skipped++
+		case c.Err != nil:
+			errored++
+		case c.Failed():
+			failed++
+		}
+	}
+
+	fmt.Fprintf(o.Out, "\n%d loaders: %d passed, %d failed, %d errors, %d skipped\n",
+		len(cases), len(cases)-failed-errored-skipped, failed, errored, skipped)
+
+	if errored > 0 {
+		os.Exit(1)
+	}
+
+	if failed > 0 {
+		os.Exit(ThresholdsFailedExitCode)
+	}
+}
+
+// writeReports writes the JUnit XML and the TAP reports, an empty path skips the report
+func writeReports(junitPath, tapPath string, cases []*report.Case) error {
+	reports := []struct {
+		path  string
+		write func(f *os.File) error
+	}{
+		{junitPath, func(f *os.File) error { return report.JUnit(f, cases) }},
+		{tapPath, func(f *os.File) error { return report.TAP(f, cases) }},
+	}
+
+	for _, r := range reports {
+		if r.path == "" {
+			continue
+		}
+
+		f, err := os.Create(r.path)
+		if err != nil {
+			return fmt.Errorf("could not create report: %w", err)
+		}
+
+		err = r.write(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not write report %s: %w", r.path, err)
+		}
+	}
+
+	return nil
+}
+
+func NewLoaderBatchCmd(cliIO cliio.IO) *cobra.Command {
+	opts := BatchOptions{
+		IO: cliIO,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Run the loaders saved in the database one after another",
+		Long: "Run the loaders selected by the UUIDs and the tags one after another and check their thresholds. " +
+			"Failed thresholds, aborted benchmarks and benchmark timeouts fail the batch with the exit code 2.",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Complete()
+			opts.Run()
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&opts.UUIDs, "uuid", "u", nil, "Loader configuration UUID from database, can be used multiple times")
+	cmd.Flags().StringArrayVarP(&opts.Tags, "tag", "t", nil, "Run the loaders with the tags key=value, can be used multiple times")
+	cmd.Flags().BoolVarP(&opts.Save, "save", "s", true, "Save the summaries")
+	cmd.Flags().StringVar(&opts.ReportJUnit, "report-junit", "", "Write the JUnit XML report to the file")
+	cmd.Flags().StringVar(&opts.ReportTAP, "report-tap", "", "Write the TAP report to the file")
+
+	return cmd
+}
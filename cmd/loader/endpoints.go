@@ -0,0 +1,120 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// unmarshalConfigKey reads the request mix endpoints or the flow from the loader configuration file.
+// The body is given as a string in the file.
+func unmarshalConfigKey(key string, v any) error {
+	stringToBytes := func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() == reflect.String && t == reflect.TypeOf([]byte(nil)) {
+			return []byte(data.(string)), nil
+		}
+
+		return data, nil
+	}
+
+	return viper.UnmarshalKey(key, v, viper.DecodeHook(mapstructure.DecodeHookFuncType(stringToBytes)))
+}
+
+// completeAssertions returns the assertions from the flags or the loader configuration file
+func completeAssertions() (model.Assertions, error) {
+	var assertions model.Assertions
+	for _, value := range viper.GetStringSlice("assert") {
+		err := assertions.Set(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(assertions) == 0 && viper.IsSet("assertions") {
+		err := unmarshalConfigKey("assertions", &assertions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return assertions, nil
+}
+
+// completeThresholds returns the validated thresholds from the flags or the loader configuration file.
+// In the file the threshold is the metric, operator and value object or the "p99 < 200ms" string.
+func completeThresholds() (model.Thresholds, error) {
+	var thresholds model.Thresholds
+	for _, value := range viper.GetStringSlice("threshold") {
+		err := thresholds.Set(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(thresholds) == 0 && viper.IsSet("thresholds") {
+		stringToThreshold := func(f reflect.Type, t reflect.Type, data any) (any, error) {
+			if f.Kind() != reflect.String || t != reflect.TypeOf(model.Threshold{}) {
+				return data, nil
+			}
+
+			var parsed model.Thresholds
+			err := parsed.Set(data.(string))
+			if err != nil {
+				return nil, err
+			}
+
+			return parsed[0], nil
+		}
+
+		err := viper.UnmarshalKey("thresholds", &thresholds, viper.DecodeHook(mapstructure.DecodeHookFuncType(stringToThreshold)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := loader.ValidateThresholds(thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	return thresholds, nil
+}
+
+// completeFeeder returns the feeder from the flags or the loader configuration file with the file content read
+func completeFeeder() (*model.Feeder, error) {
+	var feeder *model.Feeder
+
+	if path := viper.GetString("feeder-file"); path != "" {
+		feeder = &model.Feeder{
+			Path:   path,
+			Format: viper.GetString("feeder-format"),
+			Mode:   viper.GetString("feeder-mode"),
+		}
+	} else if viper.IsSet("feeder") {
+		feeder = &model.Feeder{}
+		err := unmarshalConfigKey("feeder", feeder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if feeder == nil {
+		return nil, nil
+	}
+
+	feeder.SetDefaults()
+
+	var err error
+	feeder.Data, err = os.ReadFile(feeder.Path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read feeder file: %w", err)
+	}
+
+	return feeder, nil
+}
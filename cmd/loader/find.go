@@ -2,6 +2,7 @@ package loader
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -9,6 +10,8 @@ import (
 	"time"
 
 	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/importer"
+	"github.com/tmwalaszek/hload/loader"
 	"github.com/tmwalaszek/hload/model"
 	"github.com/tmwalaszek/hload/storage"
 	"github.com/tmwalaszek/hload/templates"
@@ -42,6 +45,10 @@ type FindOptions struct {
 
 	Tags []*model.LoaderTag
 
+	Percentiles []string
+
+	percentiles []float64
+
 	db     *storage.Storage
 	render *templates.RenderTemplate
 }
@@ -64,6 +71,12 @@ func (o *FindOptions) Complete() {
 
 	o.render = r
 
+	o.percentiles, err = loader.ParsePercentiles(o.Percentiles)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
 	// We don't support empty From and provided To atm
 	if o.From != "" {
 		o.FromEpoch, err = time_formats.TimeToEpoch(o.From)
@@ -119,6 +132,15 @@ func (o *FindOptions) getSummaries(loaderUUID string) []*model.Summary {
 		}
 	}
 
+	// The summaries saved before the t-digest was kept have no percentiles to compute
+	for _, summary := range summaries {
+		err = loader.SetPercentiles(summary, o.percentiles)
+		if err != nil && !errors.Is(err, loader.ErrNoTDigest) {
+			fmt.Fprintf(o.Err, "Error while computing percentiles: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	return summaries
 }
 
@@ -190,6 +212,10 @@ func (o *FindOptions) Run() {
 		}
 
 		fmt.Fprintf(o.Out, "%s\n", string(output))
+	case "curl":
+		for _, ls := range loaderSummary {
+			fmt.Fprintf(o.Out, "# %s (%s)\n%s\n", ls.Loader.Name, ls.Loader.UUID, importer.CurlCommand(ls.Loader))
+		}
 	default:
 		b, err := o.render.RenderOutput(&loaderConfigurations)
 		if err != nil {
@@ -223,7 +249,7 @@ func NewLoaderFindCmd(cliIO cliio.IO) *cobra.Command {
 	cmd.Flags().StringVarP(&opts.URL, "url", "U", "", "Loader target URL")
 	cmd.Flags().StringVarP(&opts.LoaderDescription, "description", "d", "", "Loader description")
 	cmd.Flags().StringVarP(&opts.LoaderName, "name", "n", "", "Loader name")
-	cmd.Flags().StringVarP(&opts.Output, "output", "o", "list", "Output")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "list", "Output: list, json or curl")
 	cmd.Flags().StringVarP(&opts.From, "from", "f", "", "From date")
 	cmd.Flags().StringVarP(&opts.To, "to", "t", "", "To date")
 	cmd.Flags().BoolVarP(&opts.Summary, "show-summary", "s", false, "Show the summaries of the loadedr configurations")
@@ -231,6 +257,7 @@ func NewLoaderFindCmd(cliIO cliio.IO) *cobra.Command {
 	cmd.Flags().IntVarP(&opts.SummaryLimit, "summary-limit", "L", 5, "Limit the number of returned summaries")
 	cmd.Flags().BoolVar(&opts.ShowRequestsStats, "show-request-stats", false, "Show requests stats - both full or aggregated")
 	cmd.Flags().StringSlice("tag", []string{}, "Tag names pairs - key=valye")
+	cmd.Flags().StringSliceVar(&opts.Percentiles, "percentiles", nil, "Additional request duration percentiles of the summaries computed from the saved t-digest, e.g. p99.9,p99.99,p10")
 
 	return cmd
 }
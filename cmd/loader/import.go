@@ -0,0 +1,313 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/importer"
+	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/storage"
+	"github.com/tmwalaszek/hload/templates"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type ImportOptions struct {
+	cliio.IO
+
+	HAR     string
+	Curl    string
+	OpenAPI string
+
+	// Server replaces the OpenAPI specification server
+	Server string
+
+	// RunLoader runs the imported curl command instead of saving it, Save keeps it with the summary
+	RunLoader bool
+	Save      bool
+
+	// Replace updates the existing loader configurations with the same name instead of failing
+	Replace bool
+
+	FilterHosts []string
+	FilterURL   string
+	DropHeaders []string
+
+	Engine      Engine
+	Connections int
+	ReqCount    int
+	Duration    time.Duration
+
+	db     *storage.Storage
+	render *templates.RenderTemplate
+}
+
+func (o *ImportOptions) Complete() {
+	var err error
+
+	o.db, err = storage.NewStorage(viper.GetString("db"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	if o.RunLoader {
+		o.render, err = templates.NewRenderTemplate(viper.GetString("template"), viper.GetString("db"))
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	if o.Duration != 0 {
+		o.ReqCount = 0
+	}
+}
+
+func (o *ImportOptions) Run() {
+	var sources int
+	for _, source := range []string{o.HAR, o.Curl, o.OpenAPI} {
+		if source != "" {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		fmt.Fprint(o.Err, "Error: one of the HAR file, the curl command or the OpenAPI specification has to be set")
+		os.Exit(1)
+	}
+
+	if o.RunLoader && o.Curl == "" {
+		fmt.Fprint(o.Err, "Error: only the curl command can be run")
+		os.Exit(1)
+	}
+
+	var loaders []*model.Loader
+	var err error
+	switch {
+	case o.HAR != "":
+		loaders, err = o.importHAR()
+	case o.OpenAPI != "":
+		loaders, err = o.importOpenAPI()
+	default:
+		var l *model.Loader
+		l, err = importer.Curl(o.Curl)
+		loaders = []*model.Loader{l}
+	}
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	if len(loaders) == 0 {
+		fmt.Fprint(o.Out, "No requests matched the filters\n")
+		return
+	}
+
+	for _, l := range loaders {
+		l.HTTPEngine = o.Engine.String()
+		l.LoaderReqDetails = model.LoaderReqDetails{
+			Connections: o.Connections,
+			ReqCount:    o.ReqCount,
+			Duration:    o.Duration,
+		}
+	}
+
+	var replaceUUIDs []string
+	if !o.RunLoader || o.Save {
+		replaceUUIDs, err = o.existingLoaders(loaders)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	// Only the single curl command can be run, the replaced loader is updated before the run
+	if o.RunLoader {
+		runOpts := RunOptions{
+			Conf:    loaders[0],
+			Storage: o.db,
+			Save:    o.Save,
+			render:  o.render,
+			IO:      o.IO,
+		}
+
+		if len(replaceUUIDs) > 0 && replaceUUIDs[0] != "" {
+			_, err = o.db.ImportLoaderConfigurations(loaders, replaceUUIDs)
+			if err != nil {
+				fmt.Fprintf(o.Err, "Error: %v", err)
+				os.Exit(1)
+			}
+
+			runOpts.UUID = replaceUUIDs[0]
+		}
+
+		runOpts.Run()
+		return
+	}
+
+	// All loaders are saved in one transaction so the failed import leaves nothing behind
+	ids, err := o.db.ImportLoaderConfigurations(loaders, replaceUUIDs)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	for i, l := range loaders {
+		if replaceUUIDs[i] != "" {
+			fmt.Fprintf(o.Out, "Loader configuration %s updated with id: %s\n", l.Name, ids[i])
+			continue
+		}
+
+		fmt.Fprintf(o.Out, "Loader configuration %s imported with id: %s\n", l.Name, ids[i])
+	}
+}
+
+// existingLoaders returns the UUID of the saved loader configuration for every imported loader.
+// OpenAPI operations are matched on the openapi and operationId tags, the rest of the loaders on the name.
+// Without Replace the existing loader configuration is an error.
+func (o *ImportOptions) existingLoaders(loaders []*model.Loader) ([]string, error) {
+	var specTags, operationTags map[string]*model.LoaderTag
+	if o.OpenAPI != "" {
+		var err error
+		specTags, err = o.db.GetLoaderTagsByKey("openapi")
+		if err != nil {
+			return nil, err
+		}
+
+		operationTags, err = o.db.GetLoaderTagsByKey("operationId")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	replaceUUIDs := make([]string, len(loaders))
+	for i, l := range loaders {
+		spec, operation := loaderTag(l, "openapi"), loaderTag(l, "operationId")
+		if spec != "" && operation != "" {
+			for id, tag := range operationTags {
+				if tag.Value == operation && specTags[id] != nil && specTags[id].Value == spec {
+					replaceUUIDs[i] = id
+				}
+			}
+		}
+
+		if replaceUUIDs[i] == "" {
+			// The name is matched with LIKE so the result is checked for the exact name
+			existing, err := o.db.GetLoaderByName(l.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, e := range existing {
+				if e.Name == l.Name {
+					replaceUUIDs[i] = e.UUID
+				}
+			}
+		}
+
+		if replaceUUIDs[i] != "" && !o.Replace {
+			return nil, fmt.Errorf("loader configuration %s already exists, use --replace to update it", l.Name)
+		}
+	}
+
+	return replaceUUIDs, nil
+}
+
+// loaderTag returns the value of the loader tag, empty when the loader has no such tag
+func loaderTag(l *model.Loader, key string) string {
+	for _, tag := range l.Tags {
+		if tag.Key == key {
+			return tag.Value
+		}
+	}
+
+	return ""
+}
+
+func (o *ImportOptions) importHAR() ([]*model.Loader, error) {
+	f, err := os.Open(o.HAR)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	opts := importer.HAROptions{
+		Hosts:       o.FilterHosts,
+		DropHeaders: o.DropHeaders,
+	}
+
+	if o.FilterURL != "" {
+		opts.URLPattern, err = regexp.Compile(o.FilterURL)
+		if err != nil {
+			return nil, fmt.Errorf("wrong url pattern: %w", err)
+		}
+	}
+
+	return importer.HAR(f, opts)
+}
+
+// importOpenAPI returns the loaders of the specification operations with their tags
+func (o *ImportOptions) importOpenAPI() ([]*model.Loader, error) {
+	f, err := os.Open(o.OpenAPI)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	operations, err := importer.OpenAPI(f, importer.OpenAPIOptions{Server: o.Server})
+	if err != nil {
+		return nil, err
+	}
+
+	loaders := make([]*model.Loader, len(operations))
+	for i, operation := range operations {
+		loaders[i] = operation.Loader
+		loaders[i].Tags = operation.Tags
+	}
+
+	return loaders, nil
+}
+
+func NewLoaderImportCmd(cliIO cliio.IO) *cobra.Command {
+	opts := ImportOptions{
+		IO: cliIO,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import loader configurations from captured traffic, curl commands or OpenAPI specifications",
+		Long: `Import every request of the HAR capture as a saved loader configuration.
+The curl command is imported the same way or run straight away with --run.
+The saved loader configurations with the same name, or the same specification title and operationId,
+are updated with --replace.
+Every operation of the OpenAPI 3 specification is imported as the loader configuration tagged with
+the specification title (openapi), the operationId and the API tag (tag).`,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Complete()
+			opts.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.HAR, "har", "", "HAR file exported from the browser or the proxy")
+	cmd.Flags().StringVar(&opts.Curl, "curl", "", "curl command to import, supports -X, -H, -d, --data-binary, -b, -k, --cacert, --cert, --key and -u")
+	cmd.Flags().BoolVar(&opts.RunLoader, "run", false, "Run the imported curl command instead of saving it")
+	cmd.Flags().BoolVar(&opts.Save, "save", false, "Save the run curl command with its summary")
+	cmd.Flags().BoolVar(&opts.Replace, "replace", false, "Update the saved loader configurations with the same name or OpenAPI operation, their summaries are kept")
+	cmd.Flags().StringVar(&opts.OpenAPI, "openapi", "", "OpenAPI 3 specification file in YAML or JSON")
+	cmd.Flags().StringVar(&opts.Server, "server", "", "Base URL of the OpenAPI operations, the first specification server is used by default")
+	cmd.Flags().StringSliceVar(&opts.FilterHosts, "filter-host", nil, "Import only the requests sent to the host, can be used multiple times")
+	cmd.Flags().StringVar(&opts.FilterURL, "filter-url", "", "Import only the requests with the URL matching the regular expression")
+	cmd.Flags().StringSliceVar(&opts.DropHeaders, "drop-header", importer.DefaultDropHeaders, "Header not imported, the name ending with * matches the prefix, can be used multiple times")
+
+	cmd.Flags().Var(&opts.Engine, "engine", "HTTP library used: fast_http or http")
+	cmd.Flags().IntVarP(&opts.Connections, "connections", "c", DefaultConnections, "Concurrent connections of the imported loaders")
+	cmd.Flags().IntVarP(&opts.ReqCount, "requests", "r", DefaultRequestCount, "Requests count of the imported loaders")
+	cmd.Flags().DurationVarP(&opts.Duration, "duration", "d", 0, "Duration of the imported loaders, replaces the requests count")
+
+	return cmd
+}
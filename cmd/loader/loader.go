@@ -18,8 +18,11 @@ func NewLoaderCmd(cliIO cliio.IO) *cobra.Command {
 	cmd.AddCommand(NewLoaderRunCmd(cliIO))
 	cmd.AddCommand(NewLoaderSaveCmd(cliIO))
 	cmd.AddCommand(NewLoaderStartCmd(cliIO))
+	cmd.AddCommand(NewLoaderBatchCmd(cliIO))
 	cmd.AddCommand(NewLoaderDeleteCmd(cliIO))
 	cmd.AddCommand(NewLoaderFindCmd(cliIO))
+	cmd.AddCommand(NewLoaderProbeCmd(cliIO))
+	cmd.AddCommand(NewLoaderImportCmd(cliIO))
 
 	return cmd
 }
@@ -0,0 +1,223 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const DefaultProbeStepDuration = 10 * time.Second
+
+type ProbeOptions struct {
+	RunOptions
+
+	Mode      string
+	StartRate int
+	MaxRate   int
+	RateStep  int
+	OpenModel bool
+
+	Percentile    int
+	MaxLatency    time.Duration
+	MaxErrorRate  float64
+	MinThroughput float64
+}
+
+func (o *ProbeOptions) Run() {
+	// The probed rate is the arrival rate or the rate limit, checked after a stored loader was loaded
+	if o.OpenModel && (o.Conf.RateLimit != 0 || o.Conf.RequestDelay != 0) {
+		fmt.Fprintf(o.Err, "Error: --open-model can't be used with rate limit or request delay\n")
+		os.Exit(1)
+	}
+
+	if !o.OpenModel && o.Conf.ArrivalRate != 0 {
+		fmt.Fprintf(o.Err, "Error: arrival rate can't be set, use --open-model to probe the arrival rate\n")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+
+	go func() {
+		<-c
+		log.Print("Received signal and will stop the probe")
+		cancel()
+	}()
+
+	// Every probe step runs for the loader duration
+	if o.Conf.Duration == 0 {
+		o.Conf.Duration = DefaultProbeStepDuration
+	}
+	o.Conf.ReqCount = 0
+
+	var loaderUUID string
+	run := func(ctx context.Context, rate int) (*model.Summary, error) {
+		conf := *o.Conf
+		if o.OpenModel {
+			conf.ArrivalRate = rate
+		} else {
+			conf.RateLimit = rate
+		}
+
+		l, err := loader.NewLoader(&conf)
+		if err != nil {
+			return nil, fmt.Errorf("could not create loader: %w", err)
+		}
+
+		summary, err := l.Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not run loader: %w", err)
+		}
+
+		if o.Save && ctx.Err() == nil {
+			// The loader configuration is saved with the first step so a failing probe leaves nothing behind
+			if loaderUUID == "" {
+				loaderUUID, err = o.Storage.InsertLoaderConfiguration(o.Conf)
+				if err != nil {
+					return nil, fmt.Errorf("could not save loader configuration: %w", err)
+				}
+			}
+
+			summary.Description = fmt.Sprintf("Probe step: %d req/s", rate)
+			_, err = o.Storage.InsertSummary(loaderUUID, summary, o.SaveRequests, o.SaveAggregatedRequests)
+			if err != nil {
+				return nil, fmt.Errorf("error saving summary: %w", err)
+			}
+		}
+
+		return summary, nil
+	}
+
+	prober, err := loader.NewProber(loader.ProbeOptions{
+		Mode:      o.Mode,
+		StartRate: o.StartRate,
+		MaxRate:   o.MaxRate,
+		RateStep:  o.RateStep,
+		SLO: loader.ProbeSLO{
+			Percentile:    o.Percentile,
+			MaxLatency:    o.MaxLatency,
+			MaxErrorRate:  o.MaxErrorRate,
+			MinThroughput: o.MinThroughput,
+		},
+	}, run)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	prober.OnStep = func(step *loader.ProbeStep) {
+		status := "pass"
+		if !step.Pass {
+			status = "fail: " + step.Reason
+		}
+
+		fmt.Fprintf(o.Out, "Probe step %d req/s: %.2f req/s, p%d %v, error rate %.4f (%s)\n",
+			step.Rate, step.Summary.ReqPerSec, o.Percentile, step.Latency, step.ErrorRate, status)
+	}
+
+	fmt.Fprintf(o.Out, "Probing %s from %d to %d req/s (%s search, %v per step)\n", o.Conf.URL, o.StartRate, o.MaxRate, o.Mode, o.Conf.Duration)
+
+	result, err := prober.Probe(ctx)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(o.Out, "\n%s\n\n", probeTable(result))
+
+	if result.Best == nil {
+		fmt.Fprintf(o.Out, "No probed rate met the SLO\n")
+	} else {
+		fmt.Fprintf(o.Out, "Highest sustainable throughput: %.2f req/s (probed rate %d req/s)\n", result.Best.Summary.ReqPerSec, result.Best.Rate)
+	}
+
+	if loaderUUID != "" {
+		fmt.Fprintf(o.Out, "Probe steps saved for %s loader\n", loaderUUID)
+	}
+}
+
+// probeTable renders the throughput vs latency table sorted by the probed rate
+func probeTable(result *loader.ProbeResult) string {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Rate (req/s)", "Achieved (req/s)", "P50", "P90", "P99", "Error rate", "SLO"})
+
+	for _, step := range result.Steps {
+		status := "pass"
+		if !step.Pass {
+			status = "fail"
+		}
+
+		t.AppendRow(table.Row{
+			step.Rate,
+			fmt.Sprintf("%.2f", step.Summary.ReqPerSec),
+			step.Summary.P50ReqTime,
+			step.Summary.P90ReqTime,
+			step.Summary.P99ReqTime,
+			fmt.Sprintf("%.4f", step.ErrorRate),
+			status,
+		})
+	}
+
+	t.SortBy([]table.SortBy{{Name: "Rate (req/s)", Mode: table.AscNumeric}})
+
+	return t.Render()
+}
+
+func NewLoaderProbeCmd(cliIO cliio.IO) *cobra.Command {
+	opts := ProbeOptions{
+		RunOptions: RunOptions{
+			IO: cliIO,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "probe",
+		Short: "Find the highest throughput that meets the latency and error rate SLO",
+		Long: "Run the loader repeatedly at increasing rates, using binary or step search, until the SLO is broken. " +
+			"Every step runs for the loader duration.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := viper.BindPFlags(cmd.Flags())
+			if err != nil {
+				fmt.Fprintf(cliIO.Err, "Could not bind flags: %v", err)
+				os.Exit(1)
+			}
+
+			opts.Complete()
+			opts.CompleteDB()
+			opts.Run()
+		},
+	}
+
+	addRunFlags(cmd, &opts.RunOptions)
+
+	cmd.Flags().StringVar(&opts.Mode, "mode", loader.ProbeModeBinary, "Search mode: binary or step")
+	cmd.Flags().IntVar(&opts.StartRate, "start-rate", 10, "First probed rate (req/s)")
+	cmd.Flags().IntVar(&opts.MaxRate, "max-rate", 1000, "Highest probed rate (req/s)")
+	cmd.Flags().IntVar(&opts.RateStep, "rate-step", 10, "Rate increase in step mode and search precision in binary mode (req/s)")
+	cmd.Flags().BoolVar(&opts.OpenModel, "open-model", false, "Probe with the open model arrival rate instead of the rate limit")
+	cmd.Flags().IntVar(&opts.Percentile, "slo-percentile", 99, "SLO latency percentile: 50, 75, 90 or 99")
+	cmd.Flags().DurationVar(&opts.MaxLatency, "slo-latency", 0, "SLO maximum latency at the percentile, 0 disables the check")
+	cmd.Flags().Float64Var(&opts.MaxErrorRate, "slo-error-rate", 0.01, "SLO maximum failed requests ratio (0-1)")
+	cmd.Flags().Float64Var(&opts.MinThroughput, "slo-min-throughput", loader.DefaultMinThroughput, "Minimal achieved to probed rate ratio (0-1)")
+
+	err := cmd.MarkFlagRequired("host")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return cmd
+}
@@ -8,16 +8,24 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/dashboard"
+	"github.com/tmwalaszek/hload/distributed"
 	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/metrics"
 	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/report"
 	"github.com/tmwalaszek/hload/storage"
 	"github.com/tmwalaszek/hload/templates"
 
 	"github.com/jedib0t/go-pretty/v6/list"
 	"github.com/jedib0t/go-pretty/v6/progress"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -25,6 +33,9 @@ import (
 const (
 	DefaultConnections  = 10
 	DefaultRequestCount = 1000
+
+	// ThresholdsFailedExitCode is the exit code when the benchmark finished but did not meet the thresholds
+	ThresholdsFailedExitCode = 2
 )
 
 type RunOptions struct {
@@ -49,6 +60,22 @@ type RunOptions struct {
 
 	UUID string
 
+	// TUI shows the live dashboard instead of the progress bar
+	TUI bool
+
+	// Agents are the addresses of the agents the benchmark is split between, empty runs it locally
+	Agents []string
+
+	// MetricsListen is the address the live Prometheus metrics are served on, empty disables them
+	MetricsListen string
+
+	// ReportJUnit and ReportTAP are the JUnit XML and TAP report files, empty skips the report
+	ReportJUnit string
+	ReportTAP   string
+
+	// Percentiles are the additional request duration percentiles shown with the summary
+	Percentiles []float64
+
 	render *templates.RenderTemplate
 
 	cliio.IO
@@ -105,9 +132,15 @@ func (o *RunOptions) Run() {
 
 	progressChan := make(chan struct{})
 	var l *loader.Loader
+	var controller *distributed.Controller
 	var err error
 
-	if o.Conf.ReqCount != 0 {
+	if len(o.Agents) > 0 {
+		controller, err = distributed.NewController(o.Agents)
+		if err != nil {
+			log.Fatalf("Could not create controller: %v", err)
+		}
+	} else if o.countsRequests() && !o.TUI {
 		l, err = loader.NewLoaderProgress(o.Conf, progressChan)
 		if err != nil {
 			log.Fatalf("Could not create loader: %v", err)
@@ -119,6 +152,19 @@ func (o *RunOptions) Run() {
 		}
 	}
 
+	if o.MetricsListen != "" {
+		exporter := metrics.NewExporter(l)
+		l.AddObserver(exporter)
+
+		addr, err := exporter.Listen(o.MetricsListen)
+		if err != nil {
+			log.Fatalf("Could not serve metrics: %v", err)
+		}
+		defer exporter.Close()
+
+		fmt.Fprintf(o.Out, "Serving metrics on http://%s/metrics\n", addr)
+	}
+
 	var newLoaderUUID string
 	if o.Save && o.UUID == "" {
 		newLoaderUUID, err = o.Storage.InsertLoaderConfiguration(o.Conf)
@@ -131,6 +177,103 @@ func (o *RunOptions) Run() {
 	fmt.Printf("\n\n")
 	var summary *model.Summary
 
+	switch {
+	case controller != nil:
+		summary, err = o.runAgents(ctx, controller)
+	case o.TUI:
+		summary, err = o.runDashboard(ctx, cancel, l)
+	default:
+		summary, err = o.runProgress(ctx, l, progressChan)
+	}
+
+	if err != nil {
+		reportErr := writeReports(o.ReportJUnit, o.ReportTAP, []*report.Case{{Loader: o.Conf, Err: err}})
+		if reportErr != nil {
+			log.Printf("Could not write report: %v", reportErr)
+		}
+
+		log.Fatalf("Could not run loader: %v", err)
+	}
+
+	var loaderUUID string
+	if o.Save {
+		if o.UUID == "" {
+			loaderUUID = newLoaderUUID
+		} else {
+			loaderUUID = o.UUID
+		}
+
+		_, err = o.Storage.InsertSummary(loaderUUID, summary, o.SaveRequests, o.SaveAggregatedRequests)
+		if err != nil {
+			log.Fatalf("Error saving summary: %v", err)
+		}
+
+	}
+
+	err = loader.SetPercentiles(summary, o.Percentiles)
+	if err != nil {
+		log.Fatalf("Could not compute percentiles: %v", err)
+	}
+
+	fmt.Fprintf(o.Out, "\n")
+	o.printSummary(summary)
+
+	results, err := loader.CheckThresholds(o.Conf.Thresholds, summary)
+	if err != nil {
+		log.Fatalf("Could not check thresholds: %v", err)
+	}
+
+	if len(results) > 0 {
+		fmt.Fprintf(o.Out, "\n%s\n", thresholdsTable(results))
+	}
+
+	err = writeReports(o.ReportJUnit, o.ReportTAP, []*report.Case{{Loader: o.Conf, Summary: summary, Thresholds: results}})
+	if err != nil {
+		log.Fatalf("Could not write report: %v", err)
+	}
+
+	if o.Save && !o.Start {
+		fmt.Fprintf(o.Out, "\n")
+		fmt.Fprintf(o.Out, "New loader configuration saved: %s\n", loaderUUID)
+	}
+
+	if o.Save && o.Start {
+		fmt.Fprintf(o.Out, "\n")
+		fmt.Fprintf(o.Out, "New summary saved for %s loader\n", loaderUUID)
+	}
+
+	if !loader.ThresholdsPassed(results) {
+		var failed int
+		for _, result := range results {
+			if !result.Pass {
+				failed++
+			}
+		}
+
+		fmt.Fprintf(o.Err, "%d of %d thresholds failed\n", failed, len(results))
+		os.Exit(ThresholdsFailedExitCode)
+	}
+}
+
+// thresholdsTable renders the thresholds check results
+func thresholdsTable(results []*loader.ThresholdResult) string {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Threshold", "Actual", "Result"})
+
+	for _, result := range results {
+		status := text.FgGreen.Sprint("pass")
+		if !result.Pass {
+			status = text.FgRed.Sprint("fail")
+		}
+
+		t.AppendRow(table.Row{result.Threshold.String(), result.Actual, status})
+	}
+
+	return t.Render()
+}
+
+// runProgress runs the loader showing the progress bar
+func (o *RunOptions) runProgress(ctx context.Context, l *loader.Loader, progressChan chan struct{}) (*model.Summary, error) {
 	pw := progress.NewWriter()
 	pw.SetAutoStop(true)
 
@@ -140,8 +283,10 @@ func (o *RunOptions) Run() {
 		Units:   progress.UnitsDefault,
 	}
 
-	if o.Conf.ReqCount != 0 {
-		trackerReqCount := &progress.Tracker{
+	var trackerReqCount *progress.Tracker
+	if o.countsRequests() {
+		// Without the requests count the tracker is indeterminate and shows the requests sent
+		trackerReqCount = &progress.Tracker{
 			Message: "Requests progress",
 			Total:   int64(o.Conf.ReqCount),
 			Units:   progress.UnitsDefault,
@@ -160,41 +305,97 @@ func (o *RunOptions) Run() {
 		pwFinish <- struct{}{}
 	}()
 
-	summary, err = l.Do(ctx)
-	if err != nil {
-		log.Fatalf("Could not run loader: %v", err)
+	summary, err := l.Do(ctx)
+
+	if trackerReqCount != nil && trackerReqCount.IsIndeterminate() {
+		trackerReqCount.MarkAsDone()
 	}
 
 	pw.Stop()
 	<-pwFinish
 
-	var loaderUUID string
-	if o.Save {
-		if o.UUID == "" {
-			loaderUUID = newLoaderUUID
-		} else {
-			loaderUUID = o.UUID
+	return summary, err
+}
+
+// countsRequests tells whether the progress is the requests count instead of the duration,
+// the benchmark running until the unique feeder runs out has neither of them
+func (o *RunOptions) countsRequests() bool {
+	return o.Conf.ReqCount != 0 || (o.Conf.Duration == 0 && len(o.Conf.Stages) == 0)
+}
+
+// runAgents runs the loader split between the agents showing the progress of all of them
+func (o *RunOptions) runAgents(ctx context.Context, controller *distributed.Controller) (*model.Summary, error) {
+	pw := progress.NewWriter()
+	pw.SetAutoStop(true)
+
+	var tracker *progress.Tracker
+	if o.countsRequests() {
+		tracker = &progress.Tracker{
+			Message: "Requests progress",
+			Total:   int64(o.Conf.ReqCount),
+			Units:   progress.UnitsDefault,
 		}
+		pw.AppendTracker(tracker)
 
-		_, err = o.Storage.InsertSummary(loaderUUID, summary, o.SaveRequests, o.SaveAggregatedRequests)
-		if err != nil {
-			log.Fatalf("Error saving summary: %v", err)
+		var mu sync.Mutex
+		requests := make([]int, len(o.Agents))
+		controller.OnProgress = func(agent int, p *distributed.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			requests[agent] = p.Requests
+
+			var total int
+			for _, r := range requests {
+				total += r
+			}
+			tracker.SetValue(int64(total))
+		}
+	} else {
+		tracker = &progress.Tracker{
+			Message: "Duration",
+			Total:   100,
+			Units:   progress.UnitsDefault,
 		}
+		pw.AppendTracker(tracker)
+		go tickDuration(o.Conf.Duration, tracker)
+	}
 
+	pwFinish := make(chan struct{})
+	go func() {
+		pw.Render()
+		pwFinish <- struct{}{}
+	}()
+
+	summary, err := controller.Run(ctx, o.Conf)
+
+	if tracker.IsIndeterminate() {
+		tracker.MarkAsDone()
 	}
 
-	fmt.Fprintf(o.Out, "\n")
-	o.printSummary(summary)
+	pw.Stop()
+	<-pwFinish
 
-	if o.Save && !o.Start {
-		fmt.Fprintf(o.Out, "\n")
-		fmt.Fprintf(o.Out, "New loader configuration saved: %s\n", loaderUUID)
+	return summary, err
+}
+
+// runDashboard runs the loader showing the live dashboard, stopping it from the dashboard cancels the benchmark
+func (o *RunOptions) runDashboard(ctx context.Context, cancel context.CancelFunc, l *loader.Loader) (*model.Summary, error) {
+	d, err := dashboard.New(o.Conf, l)
+	if err != nil {
+		return nil, err
 	}
+	l.AddObserver(d)
 
-	if o.Save && o.Start {
-		fmt.Fprintf(o.Out, "\n")
-		fmt.Fprintf(o.Out, "New summary saved for %s loader\n", loaderUUID)
+	err = d.Start(os.Stdin, os.Stdout, cancel)
+	if err != nil {
+		return nil, err
 	}
+
+	summary, err := l.Do(ctx)
+	d.Close()
+
+	return summary, err
 }
 
 func tickDuration(duration time.Duration, tracker *progress.Tracker) {
@@ -243,6 +444,22 @@ func (o *RunOptions) CompleteDB() {
 	}
 
 	o.Save = viper.GetBool("save")
+	o.MetricsListen = viper.GetString("metrics-listen")
+	o.TUI = viper.GetBool("tui")
+	o.Agents = viper.GetStringSlice("agents")
+	o.ReportJUnit = viper.GetString("report-junit")
+	o.ReportTAP = viper.GetString("report-tap")
+
+	o.Percentiles, err = loader.ParsePercentiles(viper.GetStringSlice("percentiles"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	if len(o.Agents) > 0 && (o.TUI || o.MetricsListen != "") {
+		fmt.Fprintf(o.Err, "Error: --tui and --metrics-listen can't be used with --agents")
+		os.Exit(1)
+	}
 	o.SaveRequests = o.Conf.GatherFullRequestsStats
 	o.SaveAggregatedRequests = o.Conf.GatherAggregateRequestsStats
 }
@@ -307,6 +524,81 @@ func (o *RunOptions) Complete() {
 		}
 	}
 
+	var stages model.Stages
+	for _, value := range viper.GetStringSlice("stage") {
+		err := stages.Set(value)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	// Stages from the loader configuration file
+	if len(stages) == 0 && viper.IsSet("stages") {
+		err = viper.UnmarshalKey("stages", &stages)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error (stages): %v", err)
+			os.Exit(1)
+		}
+		stages.SetDefaults()
+	}
+
+	var endpoints model.Endpoints
+	for _, value := range viper.GetStringSlice("endpoint") {
+		err := endpoints.Set(value)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	// Endpoints from the loader configuration file
+	if len(endpoints) == 0 && viper.IsSet("endpoints") {
+		err = unmarshalConfigKey("endpoints", &endpoints)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error (endpoints): %v", err)
+			os.Exit(1)
+		}
+	}
+	endpoints.SetDefaults()
+
+	var flow model.Flow
+	if viper.IsSet("flow") {
+		err = unmarshalConfigKey("flow", &flow)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error (flow): %v", err)
+			os.Exit(1)
+		}
+	}
+	flow.SetDefaults()
+
+	feeder, err := completeFeeder()
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error (feeder): %v", err)
+		os.Exit(1)
+	}
+
+	assertions, err := completeAssertions()
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error (assertions): %v", err)
+		os.Exit(1)
+	}
+
+	thresholds, err := completeThresholds()
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error (thresholds): %v", err)
+		os.Exit(1)
+	}
+
+	stageTarget := viper.GetString("stage-target")
+	if stageTarget == "" {
+		stageTarget = viper.GetString("stage_target")
+	}
+
+	if stageTarget == "" && len(stages) > 0 {
+		stageTarget = model.StageTargetConnections
+	}
+
 	var body, caBody, certBody, keyBody []byte
 	if viper.GetString("ca") != "" {
 		caBody, err = os.ReadFile(viper.GetString("ca"))
@@ -343,12 +635,13 @@ func (o *RunOptions) Complete() {
 	requestCount := viper.GetInt("requests")
 	duration := viper.GetDuration("duration")
 
-	if requestCount == 0 && duration == 0 {
+	// The unique feeder with no requests count runs until the rows run out
+	if requestCount == 0 && duration == 0 && (feeder == nil || feeder.Mode != model.FeederModeUnique) {
 		requestCount = DefaultRequestCount
 	}
 
-	// If we have duration set to something, set the requestsCount to 0
-	if duration != 0 {
+	// If we have duration or stages set to something, set the requestsCount to 0
+	if duration != 0 || len(stages) > 0 {
 		requestCount = 0
 	}
 
@@ -357,8 +650,11 @@ func (o *RunOptions) Complete() {
 		method = http.MethodGet
 	}
 
+	// The connections stages set the connections unless they are given explicitly
 	connections := viper.GetInt("connections")
-	if connections == 0 {
+	if stageTarget == model.StageTargetConnections && !viper.IsSet("connections") {
+		connections = 0
+	} else if connections == 0 {
 		connections = DefaultConnections
 	}
 
@@ -377,6 +673,7 @@ func (o *RunOptions) Complete() {
 		AggregateWindow:              viper.GetDuration("aggregate-window"),
 		GatherFullRequestsStats:      o.SaveRequests || o.ShowFullStats,
 		GatherAggregateRequestsStats: o.SaveAggregatedRequests || o.ShowAggregatedStats,
+		CorrectCoordinatedOmission:   viper.GetBool("correct-coordinated-omission"),
 		LoaderReqDetails: model.LoaderReqDetails{
 			ReqCount:     requestCount,
 			AbortAfter:   viper.GetInt("abort"),
@@ -388,9 +685,17 @@ func (o *RunOptions) Complete() {
 			WriteTimeout: viper.GetDuration("write_timeout"),
 			Timeout:      viper.GetDuration("timeout"),
 			RateLimit:    viper.GetInt("rate-limit"),
+			ArrivalRate:  viper.GetInt("arrival-rate"),
+			StageTarget:  stageTarget,
 		},
 		Headers:    headers,
 		Parameters: params,
+		Stages:     stages,
+		Endpoints:  endpoints,
+		Flow:       flow,
+		Feeder:     feeder,
+		Assertions: assertions,
+		Thresholds: thresholds,
 	}
 
 	if viper.GetString("save-loader") != "" {
@@ -427,6 +732,25 @@ func NewLoaderRunCmd(cliIO cliio.IO) *cobra.Command {
 		},
 	}
 
+	addRunFlags(cmd, &opts)
+	cmd.Flags().String("metrics-listen", "", "Serve the live Prometheus metrics on the address, e.g. :9100")
+	cmd.Flags().Bool("tui", false, "Show the live dashboard, press q to stop the benchmark and save the summary")
+	cmd.Flags().StringSlice("agents", nil, "Split the benchmark between the agents host[:port] (hload agent), comma separated")
+	cmd.Flags().String("report-junit", "", "Write the JUnit XML report with the loader as the testcase to the file")
+	cmd.Flags().String("report-tap", "", "Write the TAP report with the loader as the test point to the file")
+	cmd.Flags().StringSlice("percentiles", nil, "Additional request duration percentiles to show, e.g. p99.9,p99.99,p10")
+	cmd.Flags().StringArray("threshold", nil, "Threshold the summary has to meet, e.g. \"p99 < 200ms\", \"fail_rate < 0.5%\", \"req_per_sec > 1000\", \"http_code[5xx] == 0\", can be used multiple times")
+
+	err := cmd.MarkFlagRequired("host")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return cmd
+}
+
+// addRunFlags adds the flags describing the loader configuration
+func addRunFlags(cmd *cobra.Command, opts *RunOptions) {
 	cmd.Flags().StringVarP(&opts.Config, "loader_config", "f", "", "Loader configuration file")
 	cmd.Flags().String("name", "", "Loader configuration name")
 	cmd.Flags().String("description", "Default loader description", "Loader description will be saved in the database")
@@ -447,30 +771,32 @@ func NewLoaderRunCmd(cliIO cliio.IO) *cobra.Command {
 	cmd.Flags().Bool("save-aggregate-requests-stats", false, "Save aggregated requests stats")
 	cmd.Flags().Bool("show-requests-stats", false, "Show all the gather requests stats")
 	cmd.Flags().Bool("show-aggregate-requests-stats", false, "Show all the aggregated requests stats")
+	cmd.Flags().Bool("correct-coordinated-omission", false, "Report latency percentiles corrected for the coordinated omission (needs rate limit or request delay)")
 
 	cmd.Flags().DurationP("duration", "d", 0, "Loader duration")
 	cmd.Flags().Duration("keep-alive", 0, "HTTP Keep Alive")
 	cmd.Flags().DurationP("request-delay", "D", 0, "Request delay")
 	cmd.Flags().Duration("read-timeout", 0, "Read Timeout")
 	cmd.Flags().Duration("write-timeout", 0, "Write Timeout")
-	cmd.Flags().Duration("timeout", 0, "Timeout used for net/http error")
+	cmd.Flags().Duration("timeout", 0, "Timeout used for net/http error, with fasthttp the dial and TLS handshake timeout")
 	cmd.Flags().Duration("benchmark-timeout", 0, "Benchmark timeout when Requests count option is used")
 	cmd.Flags().DurationP("aggregate-window", "A", 10*time.Second, "Aggregate results into window buckets")
 
 	cmd.Flags().IntP("rate-limit", "L", 0, "Rate limit requests per second")
+	cmd.Flags().IntP("arrival-rate", "R", 0, "Open model: schedule requests per second whether the previous ones finished or not")
 	cmd.Flags().IntP("requests", "r", DefaultRequestCount, "Requests count")
 	cmd.Flags().IntP("connections", "c", DefaultConnections, "Concurrent connections")
 	cmd.Flags().IntP("abort", "a", 0, "Number of connections after which benchmark will be aborted")
 
 	cmd.Flags().StringSliceP("header", "H", nil, "Header, can be used multiple times")
 	cmd.Flags().StringSliceP("parameter", "P", nil, "HTTP parameters, can be used multiple times")
-
-	err := cmd.MarkFlagRequired("host")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return cmd
+	cmd.Flags().StringSlice("stage", nil, "Load profile stage duration:target[:linear|step], can be used multiple times")
+	cmd.Flags().String("stage-target", "", "What the stages target is: connections (default, the highest target sets --connections) or rate_limit")
+	cmd.Flags().StringSlice("endpoint", nil, "Request mix endpoint weight:method:url, relative url is joined with the host, can be used multiple times")
+	cmd.Flags().StringSlice("assert", nil, "Response assertion type:value (status:200,201 body:ok body_regexp:re max_body_size:1024) or type:path=value (json:$.status=ok header:Content-Type), can be used multiple times")
+	cmd.Flags().String("feeder-file", "", "CSV (with the header row) or JSONL data file, columns are used as {{feed column}}")
+	cmd.Flags().String("feeder-format", "", "Feeder file format: csv or jsonl (default from the file extension)")
+	cmd.Flags().String("feeder-mode", model.FeederModeSequential, "How the feeder rows are used: sequential, random or unique (stops when the rows run out, use --requests 0 to use all rows)")
 }
 
 func printLoaderDescription(opts *RunOptions) {
@@ -481,12 +807,61 @@ func printLoaderDescription(opts *RunOptions) {
 	l.AppendItem(fmt.Sprintf("Created at: %s", time.Now()))
 	l.AppendItem(fmt.Sprintf("Target host: %s", opts.Conf.URL))
 	l.AppendItem(fmt.Sprintf("Concurrent connections: %d", opts.Conf.Connections))
+	if len(opts.Agents) != 0 {
+		l.AppendItem(fmt.Sprintf("Agents: %s", strings.Join(opts.Agents, ", ")))
+	}
 	if opts.Conf.ReqCount != 0 {
 		l.AppendItem(fmt.Sprintf("Requests count: %d", opts.Conf.ReqCount))
 	}
 	if opts.Conf.Duration != 0 {
 		l.AppendItem(fmt.Sprintf("Duration: %v", opts.Conf.Duration))
 	}
+	if opts.Conf.ArrivalRate != 0 {
+		l.AppendItem(fmt.Sprintf("Arrival rate (req/s): %d", opts.Conf.ArrivalRate))
+	}
+	if len(opts.Conf.Stages) != 0 {
+		l.AppendItem(fmt.Sprintf("Stages (%s):", opts.Conf.StageTarget))
+		l.Indent()
+		for _, stage := range opts.Conf.Stages {
+			l.AppendItem(fmt.Sprintf("%v to %d (%s)", stage.Duration, stage.Target, stage.Transition))
+		}
+		l.UnIndent()
+	}
+	if len(opts.Conf.Endpoints) != 0 {
+		l.AppendItem("Endpoints:")
+		l.Indent()
+		for _, endpoint := range opts.Conf.Endpoints {
+			l.AppendItem(fmt.Sprintf("%s: %s %s (weight %d)", endpoint.Name, endpoint.Method, endpoint.URL, endpoint.Weight))
+		}
+		l.UnIndent()
+	}
+	if opts.Conf.Feeder != nil {
+		l.AppendItem(fmt.Sprintf("Feeder: %s (%s, %s)", opts.Conf.Feeder.Path, opts.Conf.Feeder.Format, opts.Conf.Feeder.Mode))
+	}
+	if len(opts.Conf.Flow) != 0 {
+		l.AppendItem("Flow:")
+		l.Indent()
+		for i, step := range opts.Conf.Flow {
+			l.AppendItem(fmt.Sprintf("%d. %s: %s %s", i+1, step.Name, step.Method, step.URL))
+		}
+		l.UnIndent()
+	}
+	if len(opts.Conf.Assertions) != 0 {
+		l.AppendItem("Assertions:")
+		l.Indent()
+		for _, assertion := range opts.Conf.Assertions {
+			l.AppendItem(assertion.String())
+		}
+		l.UnIndent()
+	}
+	if len(opts.Conf.Thresholds) != 0 {
+		l.AppendItem("Thresholds:")
+		l.Indent()
+		for _, threshold := range opts.Conf.Thresholds {
+			l.AppendItem(threshold.String())
+		}
+		l.UnIndent()
+	}
 
 	fmt.Fprintf(opts.Out, "%s\n", l.Render())
 }
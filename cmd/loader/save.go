@@ -55,6 +55,48 @@ func (o *SaveOptions) Run() {
 		}
 	}
 
+	var stages model.Stages
+	err = viper.UnmarshalKey("stages", &stages)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+	stages.SetDefaults()
+
+	var endpoints model.Endpoints
+	err = unmarshalConfigKey("endpoints", &endpoints)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+	endpoints.SetDefaults()
+
+	var flow model.Flow
+	err = unmarshalConfigKey("flow", &flow)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+	flow.SetDefaults()
+
+	feeder, err := completeFeeder()
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	assertions, err := completeAssertions()
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	thresholds, err := completeThresholds()
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
 	opts := &model.Loader{
 		URL:              host,
 		Name:             viper.GetString("name"),
@@ -67,6 +109,8 @@ func (o *SaveOptions) Run() {
 		Key:              []byte(viper.GetString("key")),
 		Body:             []byte(viper.GetString("body")),
 		BenchmarkTimeout: viper.GetDuration("benchmark_timeout"),
+
+		CorrectCoordinatedOmission: viper.GetBool("correct_coordinated_omission"),
 		LoaderReqDetails: model.LoaderReqDetails{
 			ReqCount:     viper.GetInt("requests"),
 			AbortAfter:   viper.GetInt("abort"),
@@ -78,9 +122,17 @@ func (o *SaveOptions) Run() {
 			WriteTimeout: viper.GetDuration("write_timeout"),
 			Timeout:      viper.GetDuration("timeout"),
 			RateLimit:    viper.GetInt("rate_limit"),
+			ArrivalRate:  viper.GetInt("arrival_rate"),
+			StageTarget:  viper.GetString("stage_target"),
 		},
 		Headers:    headers,
 		Parameters: params,
+		Stages:     stages,
+		Endpoints:  endpoints,
+		Flow:       flow,
+		Feeder:     feeder,
+		Assertions: assertions,
+		Thresholds: thresholds,
 	}
 
 	id, err := s.InsertLoaderConfiguration(opts)
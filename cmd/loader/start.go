@@ -33,6 +33,12 @@ func NewLoaderStartCmd(cliIO cliio.IO) *cobra.Command {
 
 	cmd.Flags().BoolP("save", "s", true, "Save the summary")
 	cmd.Flags().StringP("uuid", "u", "", "Loader configuration UUID from database")
+	cmd.Flags().String("metrics-listen", "", "Serve the live Prometheus metrics on the address, e.g. :9100")
+	cmd.Flags().Bool("tui", false, "Show the live dashboard, press q to stop the benchmark and save the summary")
+	cmd.Flags().StringSlice("agents", nil, "Split the benchmark between the agents host[:port] (hload agent), comma separated")
+	cmd.Flags().String("report-junit", "", "Write the JUnit XML report with the loader as the testcase to the file")
+	cmd.Flags().String("report-tap", "", "Write the TAP report with the loader as the test point to the file")
+	cmd.Flags().StringSlice("percentiles", nil, "Additional request duration percentiles to show, e.g. p99.9,p99.99,p10")
 
 	_ = cmd.MarkFlagRequired("uuid")
 
@@ -9,9 +9,12 @@ import (
 	"runtime"
 	"runtime/pprof"
 
+	"github.com/tmwalaszek/hload/cmd/agent"
 	"github.com/tmwalaszek/hload/cmd/cliio"
 	"github.com/tmwalaszek/hload/cmd/common"
 	"github.com/tmwalaszek/hload/cmd/loader"
+	"github.com/tmwalaszek/hload/cmd/serve"
+	"github.com/tmwalaszek/hload/cmd/summary"
 	"github.com/tmwalaszek/hload/cmd/tags"
 	"github.com/tmwalaszek/hload/cmd/template"
 	"github.com/tmwalaszek/hload/cmd/version"
@@ -163,6 +166,9 @@ func init() {
 	rootCmd.AddCommand(tags.NewTagsCmd(cliIO))
 	rootCmd.AddCommand(version.NewVersionCmd(cliIO))
 	rootCmd.AddCommand(template.NewTemplateCmd(cliIO))
+	rootCmd.AddCommand(agent.NewAgentCmd(cliIO))
+	rootCmd.AddCommand(serve.NewServeCmd(cliIO))
+	rootCmd.AddCommand(summary.NewSummaryCmd(cliIO))
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,93 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/tmwalaszek/hload/api"
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/storage"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type Options struct {
+	Listen string
+
+	storage *storage.Storage
+
+	cliio.IO
+}
+
+func (o *Options) Complete() {
+	s, err := storage.NewStorage(viper.GetString("db"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	o.storage = s
+}
+
+func (o *Options) Run() {
+	ln, err := net.Listen("tcp", o.Listen)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	srv := api.NewServer(o.storage)
+	server := &http.Server{
+		Handler:           srv,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+
+	go func() {
+		<-c
+		log.Print("Received signal, stopping the running benchmarks")
+		srv.Shutdown()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(ctx)
+	}()
+
+	fmt.Fprintf(o.Out, "Serving the API on http://%s%s\n", ln.Addr(), api.Prefix)
+
+	err = server.Serve(ln)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func NewServeCmd(cliIO cliio.IO) *cobra.Command {
+	opts := Options{
+		IO: cliIO,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the REST API to manage the loaders, run the benchmarks and get the summaries",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Complete()
+			opts.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Listen, "listen", "l", ":8080", "Address the API listens on")
+
+	return cmd
+}
@@ -0,0 +1,238 @@
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/compare"
+	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/storage"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+type CompareOptions struct {
+	cliio.IO
+
+	UUIDs           []string
+	Format          string
+	Tolerance       float64
+	MetricTolerance []string
+
+	summaries  []*model.Summary
+	tolerances compare.Tolerances
+}
+
+func (o *CompareOptions) Complete() {
+	switch o.Format {
+	case FormatTable, FormatJSON, FormatMarkdown:
+	default:
+		fmt.Fprintf(o.Err, "Error: unsupported output format %s, supported formats: %s, %s, %s", o.Format, FormatTable, FormatJSON, FormatMarkdown)
+		os.Exit(1)
+	}
+
+	var err error
+	o.tolerances, err = compare.ParseTolerances(o.Tolerance, o.MetricTolerance)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	s, err := storage.NewStorage(viper.GetString("db"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Can't create storage handler: %v", err)
+		os.Exit(1)
+	}
+
+	for _, id := range o.UUIDs {
+		summary, err := s.GetSummaryByID(id, false)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+
+		o.summaries = append(o.summaries, summary)
+	}
+}
+
+func (o *CompareOptions) Run() {
+	c, err := compare.Compare(o.summaries, o.tolerances)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	switch o.Format {
+	case FormatJSON:
+		b, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(o.Out, "%s\n", b)
+	case FormatMarkdown:
+		fmt.Fprintf(o.Out, "%s\n", compareMarkdown(c))
+	default:
+		fmt.Fprintf(o.Out, "%s\n", compareTable(c))
+	}
+}
+
+func shortUUID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+
+	return id
+}
+
+func formatMetric(value float64, unit string) string {
+	switch unit {
+	case "":
+		return fmt.Sprintf("%.0f", value)
+	default:
+		return fmt.Sprintf("%.2f", value)
+	}
+}
+
+func formatDelta(d *compare.Delta, unit string) string {
+	var abs string
+	if unit == "" {
+		abs = fmt.Sprintf("%+.0f", d.Abs)
+	} else {
+		abs = fmt.Sprintf("%+.2f", d.Abs)
+	}
+
+	if d.Rel == nil {
+		return abs + " (n/a)"
+	}
+
+	return fmt.Sprintf("%s (%+.2f%%)", abs, *d.Rel)
+}
+
+func metricName(row *compare.Row) string {
+	if row.Unit == "" {
+		return row.Metric
+	}
+
+	return fmt.Sprintf("%s (%s)", row.Metric, row.Unit)
+}
+
+func compareHeader(c *compare.Comparison) table.Row {
+	header := table.Row{"Metric", shortUUID(c.Baseline.UUID) + " (baseline)"}
+	for _, s := range c.Compared {
+		header = append(header, shortUUID(s.UUID), "Delta")
+	}
+
+	return append(header, "Tolerance")
+}
+
+// compareRows returns the table rows with the delta cells decorated by the verdict
+func compareRows(c *compare.Comparison, decorate func(cell, verdict string) string) []table.Row {
+	rows := make([]table.Row, 0, len(c.Rows))
+	for _, row := range c.Rows {
+		r := table.Row{metricName(row), formatMetric(row.Baseline, row.Unit)}
+		for _, d := range row.Deltas {
+			r = append(r, formatMetric(d.Value, row.Unit), decorate(formatDelta(d, row.Unit), d.Verdict))
+		}
+
+		rows = append(rows, append(r, fmt.Sprintf("%.2f%%", row.Tolerance)))
+	}
+
+	return rows
+}
+
+func compareVerdict(c *compare.Comparison) string {
+	return fmt.Sprintf("%d regression(s), %d improvement(s)", c.Regressions, c.Improvements)
+}
+
+func compareLegend(c *compare.Comparison, prefix string) string {
+	var b strings.Builder
+	for i, s := range append([]compare.SummaryRef{c.Baseline}, c.Compared...) {
+		name := shortUUID(s.UUID)
+		if i == 0 {
+			name += " (baseline)"
+		}
+
+		line := fmt.Sprintf("%s%s: %s %s %s", prefix, name, s.UUID, s.Start.Format("2006-01-02 15:04:05"), s.Description)
+		b.WriteString(strings.TrimSpace(line) + "\n")
+	}
+
+	return b.String()
+}
+
+// compareTable renders the comparison with the regressions in red and the improvements in green
+func compareTable(c *compare.Comparison) string {
+	t := table.NewWriter()
+	// Keep the summaries UUIDs in the header as they are
+	t.Style().Format.Header = text.FormatDefault
+	t.AppendHeader(compareHeader(c))
+	t.AppendRows(compareRows(c, func(cell, verdict string) string {
+		switch verdict {
+		case compare.VerdictRegression:
+			return text.FgRed.Sprint(cell)
+		case compare.VerdictImprovement:
+			return text.FgGreen.Sprint(cell)
+		}
+
+		return cell
+	}))
+
+	return compareLegend(c, "") + "\n" + t.Render() + "\n" + compareVerdict(c)
+}
+
+// compareMarkdown renders the comparison with the regressions in bold and the improvements in italics
+func compareMarkdown(c *compare.Comparison) string {
+	t := table.NewWriter()
+	t.AppendHeader(compareHeader(c))
+	t.AppendRows(compareRows(c, func(cell, verdict string) string {
+		switch verdict {
+		case compare.VerdictRegression:
+			return fmt.Sprintf("**%s regression**", cell)
+		case compare.VerdictImprovement:
+			return fmt.Sprintf("_%s improvement_", cell)
+		}
+
+		return cell
+	}))
+
+	return compareLegend(c, "- ") + "\n" + t.RenderMarkdown() + "\n\n" + compareVerdict(c)
+}
+
+func NewSummaryCompareCmd(cliIO cliio.IO) *cobra.Command {
+	opts := CompareOptions{
+		IO: cliIO,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "compare <baseline uuid> <uuid> [uuid...]",
+		Short: "Compare the summaries against the baseline",
+		Long: "Compare the requests per second, the requests counts and the latency of the summaries against the first one. " +
+			"Changes beyond the tolerance are reported as regressions or improvements.",
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.UUIDs = args
+
+			opts.Complete()
+			opts.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Format, "format", "F", FormatTable, "Output format: table, json or markdown")
+	cmd.Flags().Float64Var(&opts.Tolerance, "tolerance", compare.DefaultTolerance, "Relative change in percent allowed for every metric")
+	cmd.Flags().StringSliceVar(&opts.MetricTolerance, "metric-tolerance", nil, "Metric tolerance metric=percent overriding --tolerance, e.g. p99=10 (metrics: rps, requests, success, fail, avg, min, max, p50, p75, p90, p99)")
+
+	return cmd
+}
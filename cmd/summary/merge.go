@@ -0,0 +1,167 @@
+package summary
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/storage"
+	"github.com/tmwalaszek/hload/templates"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Summaries merge modes
+const (
+	MergeModeAuto       = "auto"
+	MergeModeParallel   = "parallel"
+	MergeModeSequential = "sequential"
+)
+
+type MergeOptions struct {
+	cliio.IO
+
+	UUIDs       []string
+	Mode        string
+	Description string
+	LoaderUUID  string
+
+	storage   *storage.Storage
+	render    *templates.RenderTemplate
+	summaries []*model.Summary
+}
+
+func (o *MergeOptions) Complete() {
+	switch o.Mode {
+	case MergeModeAuto, MergeModeParallel, MergeModeSequential:
+	default:
+		fmt.Fprintf(o.Err, "Error: unsupported merge mode %s, supported modes: %s, %s, %s", o.Mode, MergeModeAuto, MergeModeParallel, MergeModeSequential)
+		os.Exit(1)
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range o.UUIDs {
+		if seen[id] {
+			fmt.Fprintf(o.Err, "Error: summary %s is given more than once", id)
+			os.Exit(1)
+		}
+
+		seen[id] = true
+	}
+
+	var err error
+	o.storage, err = storage.NewStorage(viper.GetString("db"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Can't create storage handler: %v", err)
+		os.Exit(1)
+	}
+
+	o.render, err = templates.NewRenderTemplate(viper.GetString("template"), viper.GetString("db"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	for _, id := range o.UUIDs {
+		summary, err := o.storage.GetSummaryByID(id, true)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+
+		o.summaries = append(o.summaries, summary)
+	}
+
+	if o.LoaderUUID == "" {
+		o.LoaderUUID = o.summaries[0].LoaderConf
+	}
+
+	_, err = o.storage.GetLoaderByID(o.LoaderUUID)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func (o *MergeOptions) Run() {
+	sequential := o.Mode == MergeModeSequential || (o.Mode == MergeModeAuto && !loader.SummariesOverlap(o.summaries))
+
+	merge := loader.MergeSummaries
+	if sequential {
+		merge = loader.MergeSequentialSummaries
+	}
+
+	// Only the stats every source has are complete in the merged summary
+	saveRequests, saveAggRequests := true, true
+	for _, s := range o.summaries {
+		saveRequests = saveRequests && len(s.RequestStats) > 0
+		saveAggRequests = saveAggRequests && len(s.AggregatedStats) > 0
+	}
+
+	merged, err := merge(o.summaries)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	merged.Sources = o.UUIDs
+	merged.Description = o.Description
+	if merged.Description == "" {
+		merged.Description = fmt.Sprintf("Merged %d summaries", len(o.summaries))
+	}
+
+	if !saveRequests {
+		merged.RequestStats = nil
+	}
+
+	if !saveAggRequests {
+		merged.AggregatedStats = nil
+	}
+
+	_, err = o.storage.InsertSummary(o.LoaderUUID, merged, saveRequests, saveAggRequests)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error saving summary: %v", err)
+		os.Exit(1)
+	}
+
+	b, err := o.render.RenderSummary(merged, false, false)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Failed to render summary template: %v", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(o.Out, "%s\n", string(b))
+}
+
+func NewSummaryMergeCmd(cliIO cliio.IO) *cobra.Command {
+	opts := MergeOptions{
+		IO: cliIO,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "merge <uuid> <uuid> [uuid...]",
+		Short: "Merge the summaries into a new saved summary",
+		Long: "Merge the summaries into a new summary saved under the loader of the first summary. " +
+			"The requests counts, the HTTP codes and the errors are summed, the average, the min and the max request times recomputed " +
+			"and the percentiles computed from the merged t-digests or the saved requests stats. " +
+			"The parallel mode merges the runs done side by side and the duration is the longest run, " +
+			"the sequential mode merges the runs done one after another and the duration is the runs durations sum. " +
+			"The auto mode is sequential when none of the runs overlap.",
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.UUIDs = args
+
+			opts.Complete()
+			opts.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", MergeModeAuto, "Merge mode: auto, parallel or sequential")
+	cmd.Flags().StringVarP(&opts.Description, "description", "d", "", "Merged summary description")
+	cmd.Flags().StringVarP(&opts.LoaderUUID, "loader", "l", "", "Loader UUID to save the merged summary under, the first summary loader by default")
+
+	return cmd
+}
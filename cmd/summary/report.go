@@ -0,0 +1,107 @@
+package summary
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/report"
+	"github.com/tmwalaszek/hload/storage"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const FormatHTML = "html"
+
+type ReportOptions struct {
+	cliio.IO
+
+	UUID   string
+	Format string
+	Output string
+
+	summary    *model.Summary
+	loaderConf *model.Loader
+}
+
+func (o *ReportOptions) Complete() {
+	if o.UUID == "" {
+		fmt.Fprintf(o.Err, "Error: summary UUID is required")
+		os.Exit(1)
+	}
+
+	if o.Format != FormatHTML {
+		fmt.Fprintf(o.Err, "Error: unsupported report format %s, supported formats: %s", o.Format, FormatHTML)
+		os.Exit(1)
+	}
+
+	s, err := storage.NewStorage(viper.GetString("db"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Can't create storage handler: %v", err)
+		os.Exit(1)
+	}
+
+	o.summary, err = s.GetSummaryByID(o.UUID, true)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	o.loaderConf, err = s.GetLoaderByID(o.summary.LoaderConf)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func (o *ReportOptions) Run() {
+	var out io.Writer = o.Out
+
+	if o.Output != "" {
+		f, err := os.Create(o.Output)
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	err := report.HTML(w, o.loaderConf, o.summary)
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error while writing the report: %v", err)
+		os.Exit(1)
+	}
+}
+
+func NewSummaryReportCmd(cliIO cliio.IO) *cobra.Command {
+	opts := ReportOptions{
+		IO: cliIO,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Create the summary report with the charts",
+		Long:  "Create the single static HTML file with the latency and throughput over time, the status codes and errors, the latency histogram and the loader configuration",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Complete()
+			opts.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.UUID, "uuid", "u", "", "Summary UUID from database")
+	cmd.Flags().StringVarP(&opts.Format, "format", "F", FormatHTML, "Report format: html")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Write the report to the file instead of the standard output")
+
+	return cmd
+}
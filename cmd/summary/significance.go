@@ -0,0 +1,279 @@
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tmwalaszek/hload/cmd/cliio"
+	"github.com/tmwalaszek/hload/compare"
+	"github.com/tmwalaszek/hload/model"
+	"github.com/tmwalaszek/hload/storage"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// RegressionExitCode is the exit code when --fail-on-regression is set and a significant regression was found
+const RegressionExitCode = 2
+
+type SignificanceOptions struct {
+	cliio.IO
+
+	BaselineUUIDs  []string
+	CandidateUUIDs []string
+	Format         string
+	Test           compare.SignificanceOptions
+
+	FailOnRegression bool
+
+	baseline  []*model.Summary
+	candidate []*model.Summary
+}
+
+// splitUUIDs returns the comma separated summaries UUIDs of the group
+func splitUUIDs(group string) []string {
+	var ids []string
+	for _, id := range strings.Split(group, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func (o *SignificanceOptions) Complete() {
+	switch o.Format {
+	case FormatTable, FormatJSON, FormatMarkdown:
+	default:
+		fmt.Fprintf(o.Err, "Error: unsupported output format %s, supported formats: %s, %s, %s", o.Format, FormatTable, FormatJSON, FormatMarkdown)
+		os.Exit(1)
+	}
+
+	err := o.Test.Validate()
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	if len(o.BaselineUUIDs) == 0 || len(o.CandidateUUIDs) == 0 {
+		fmt.Fprintf(o.Err, "Error: the baseline and the candidate need at least one summary UUID")
+		os.Exit(1)
+	}
+
+	s, err := storage.NewStorage(viper.GetString("db"))
+	if err != nil {
+		fmt.Fprintf(o.Err, "Can't create storage handler: %v", err)
+		os.Exit(1)
+	}
+
+	load := func(ids []string) []*model.Summary {
+		var summaries []*model.Summary
+		for _, id := range ids {
+			summary, err := s.GetSummaryByID(id, true)
+			if err != nil {
+				fmt.Fprintf(o.Err, "Error: %v", err)
+				os.Exit(1)
+			}
+
+			summaries = append(summaries, summary)
+		}
+
+		return summaries
+	}
+
+	o.baseline = load(o.BaselineUUIDs)
+	o.candidate = load(o.CandidateUUIDs)
+}
+
+func (o *SignificanceOptions) Run() {
+	r, err := compare.Significance(o.baseline, o.candidate, o.Test)
+	if err != nil {
+		fmt.Fprintf(o.Err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	switch o.Format {
+	case FormatJSON:
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			fmt.Fprintf(o.Err, "Error: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(o.Out, "%s\n", b)
+	case FormatMarkdown:
+		fmt.Fprintf(o.Out, "%s\n", significanceMarkdown(r))
+	default:
+		fmt.Fprintf(o.Out, "%s\n", significanceTable(r))
+	}
+
+	if o.FailOnRegression && r.Regressions > 0 {
+		os.Exit(RegressionExitCode)
+	}
+}
+
+func significanceHeader(r *compare.SignificanceResult) table.Row {
+	header := table.Row{"Metric", "Source", "Samples", "Baseline " + r.Statistic, "Candidate " + r.Statistic, "Diff"}
+	if r.Method == compare.MethodBootstrap {
+		header = append(header, fmt.Sprintf("%.0f%% CI", (1-r.Alpha)*100))
+	} else {
+		header = append(header, "U")
+	}
+
+	return append(header, "p-value", "Cliff's delta", "Verdict")
+}
+
+// significanceRows returns the table rows with the verdict cell decorated
+func significanceRows(r *compare.SignificanceResult, decorate func(cell, verdict string) string) []table.Row {
+	rows := make([]table.Row, 0, len(r.Tests))
+	for _, t := range r.Tests {
+		diff := fmt.Sprintf("%+.2f", t.Diff)
+		if t.RelDiff != nil {
+			diff += fmt.Sprintf(" (%+.2f%%)", *t.RelDiff)
+		}
+
+		var detail string
+		if t.CILow != nil && t.CIHigh != nil {
+			detail = fmt.Sprintf("[%+.2f, %+.2f]", *t.CILow, *t.CIHigh)
+		} else if t.U != nil {
+			detail = fmt.Sprintf("%.1f", *t.U)
+		}
+
+		verdict := "not significant"
+		if t.Significant {
+			verdict = t.Verdict
+		}
+
+		rows = append(rows, table.Row{
+			fmt.Sprintf("%s (%s)", t.Metric, t.Unit),
+			t.Source,
+			fmt.Sprintf("%d / %d", t.BaselineN, t.CandidateN),
+			fmt.Sprintf("%.2f", t.Baseline),
+			fmt.Sprintf("%.2f", t.Candidate),
+			diff,
+			detail,
+			formatPValue(t.PValue),
+			fmt.Sprintf("%+.3f (%s)", t.CliffsDelta, t.Effect),
+			decorate(verdict, t.Verdict),
+		})
+	}
+
+	return rows
+}
+
+func formatPValue(p float64) string {
+	if p < 0.0001 {
+		return "<0.0001"
+	}
+
+	return fmt.Sprintf("%.4f", p)
+}
+
+func significanceLegend(r *compare.SignificanceResult, prefix string) string {
+	var b strings.Builder
+
+	method := fmt.Sprintf("Mann-Whitney U test of the medians, alpha %v", r.Alpha)
+	if r.Method == compare.MethodBootstrap {
+		method = fmt.Sprintf("Bootstrap of the %s difference, alpha %v", r.Statistic, r.Alpha)
+	}
+	b.WriteString(prefix + method + "\n")
+
+	groups := []struct {
+		name string
+		refs []compare.SummaryRef
+	}{
+		{"Baseline", r.Baseline},
+		{"Candidate", r.Candidate},
+	}
+
+	for _, g := range groups {
+		ids := make([]string, 0, len(g.refs))
+		for _, ref := range g.refs {
+			ids = append(ids, ref.UUID)
+		}
+
+		fmt.Fprintf(&b, "%s%s: %s\n", prefix, g.name, strings.Join(ids, ", "))
+	}
+
+	return b.String()
+}
+
+func significanceVerdict(r *compare.SignificanceResult) string {
+	return fmt.Sprintf("%d significant regression(s), %d significant improvement(s)", r.Regressions, r.Improvements)
+}
+
+// significanceTable renders the tests with the significant regressions in red and the improvements in green
+func significanceTable(r *compare.SignificanceResult) string {
+	t := table.NewWriter()
+	t.Style().Format.Header = text.FormatDefault
+	t.AppendHeader(significanceHeader(r))
+	t.AppendRows(significanceRows(r, func(cell, verdict string) string {
+		switch verdict {
+		case compare.VerdictRegression:
+			return text.FgRed.Sprint(cell)
+		case compare.VerdictImprovement:
+			return text.FgGreen.Sprint(cell)
+		}
+
+		return cell
+	}))
+
+	return significanceLegend(r, "") + "\n" + t.Render() + "\n" + significanceVerdict(r)
+}
+
+// significanceMarkdown renders the tests with the significant regressions in bold and the improvements in italics
+func significanceMarkdown(r *compare.SignificanceResult) string {
+	t := table.NewWriter()
+	t.AppendHeader(significanceHeader(r))
+	t.AppendRows(significanceRows(r, func(cell, verdict string) string {
+		switch verdict {
+		case compare.VerdictRegression:
+			return fmt.Sprintf("**%s**", cell)
+		case compare.VerdictImprovement:
+			return fmt.Sprintf("_%s_", cell)
+		}
+
+		return cell
+	}))
+
+	return significanceLegend(r, "- ") + "\n" + t.RenderMarkdown() + "\n\n" + significanceVerdict(r)
+}
+
+func NewSummarySignificanceCmd(cliIO cliio.IO) *cobra.Command {
+	opts := SignificanceOptions{
+		IO: cliIO,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "significance <baseline uuid[,uuid...]> <candidate uuid[,uuid...]>",
+		Short: "Test whether the latency and the throughput differences between the summaries are significant",
+		Long: "Test the latency and the throughput of the candidate summaries against the baseline summaries with the Mann-Whitney U test " +
+			"or the bootstrap confidence interval and report the p-value and the Cliff's delta effect size. " +
+			"The summaries of a group are comma separated and pooled. The latency samples are the saved requests stats, " +
+			"or the aggregated windows average request times when a summary has no requests stats. " +
+			"The throughput samples are the aggregated windows requests per second, or the requests per second from the requests stats.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.BaselineUUIDs = splitUUIDs(args[0])
+			opts.CandidateUUIDs = splitUUIDs(args[1])
+
+			opts.Complete()
+			opts.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Format, "format", "F", FormatTable, "Output format: table, json or markdown")
+	cmd.Flags().StringVarP(&opts.Test.Method, "method", "m", compare.MethodMannWhitney, "Test method: mann-whitney or bootstrap")
+	cmd.Flags().Float64Var(&opts.Test.Alpha, "alpha", compare.DefaultAlpha, "Significance level, the bootstrap confidence interval is 1 - alpha")
+	cmd.Flags().IntVar(&opts.Test.Iterations, "iterations", compare.DefaultIterations, "Bootstrap resamples")
+	cmd.Flags().StringVar(&opts.Test.Statistic, "statistic", compare.DefaultStatistic, "Bootstrap statistic: mean or percentile p1-p99")
+	cmd.Flags().Int64Var(&opts.Test.Seed, "seed", 1, "Bootstrap random seed")
+	cmd.Flags().BoolVar(&opts.FailOnRegression, "fail-on-regression", false, fmt.Sprintf("Exit with code %d when a significant regression was found", RegressionExitCode))
+
+	return cmd
+}
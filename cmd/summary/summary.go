@@ -0,0 +1,32 @@
+package summary
+
+import (
+	"log"
+
+	"github.com/spf13/viper"
+	"github.com/tmwalaszek/hload/cmd/cliio"
+
+	"github.com/spf13/cobra"
+)
+
+func NewSummaryCmd(cliIO cliio.IO) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Work with the saved summaries",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Usage()
+		},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			err := viper.BindPFlags(cmd.Flags())
+			if err != nil {
+				log.Fatalf("Can't bind flags: %v", err)
+			}
+		},
+	}
+
+	cmd.AddCommand(NewSummaryReportCmd(cliIO))
+	cmd.AddCommand(NewSummaryCompareCmd(cliIO))
+	cmd.AddCommand(NewSummarySignificanceCmd(cliIO))
+	cmd.AddCommand(NewSummaryMergeCmd(cliIO))
+	return cmd
+}
@@ -0,0 +1,244 @@
+// Package compare compares summaries against the baseline summary and flags the metrics
+// that moved beyond the tolerance in the worse or the better direction.
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// Verdicts of the metric change
+const (
+	VerdictUnchanged   = "unchanged"
+	VerdictRegression  = "regression"
+	VerdictImprovement = "improvement"
+)
+
+// DefaultTolerance is the relative change in percent the metric can move without a verdict
+const DefaultTolerance = 5.0
+
+var ErrNotEnoughSummaries = errors.New("at least two summaries are needed for the comparison")
+
+// Metric is one of the compared summary values
+type Metric struct {
+	Name           string
+	Unit           string
+	HigherIsBetter bool
+
+	value func(s *model.Summary) float64
+}
+
+func durationMetric(name string, value func(s *model.Summary) time.Duration) Metric {
+	return Metric{
+		Name: name,
+		Unit: "ms",
+		value: func(s *model.Summary) float64 {
+			return float64(value(s)) / float64(time.Millisecond)
+		},
+	}
+}
+
+// Metrics are the compared metrics in the output order
+var Metrics = []Metric{
+	{Name: "rps", Unit: "req/s", HigherIsBetter: true, value: func(s *model.Summary) float64 { return s.ReqPerSec }},
+	{Name: "requests", HigherIsBetter: true, value: func(s *model.Summary) float64 { return float64(s.ReqCount) }},
+	{Name: "success", HigherIsBetter: true, value: func(s *model.Summary) float64 { return float64(s.SuccessReq) }},
+	{Name: "fail", value: func(s *model.Summary) float64 { return float64(s.FailReq) }},
+	durationMetric("avg", func(s *model.Summary) time.Duration { return s.AvgReqTime }),
+	durationMetric("min", func(s *model.Summary) time.Duration { return s.MinReqTime }),
+	durationMetric("max", func(s *model.Summary) time.Duration { return s.MaxReqTime }),
+	durationMetric("p50", func(s *model.Summary) time.Duration { return s.P50ReqTime }),
+	durationMetric("p75", func(s *model.Summary) time.Duration { return s.P75ReqTime }),
+	durationMetric("p90", func(s *model.Summary) time.Duration { return s.P90ReqTime }),
+	durationMetric("p99", func(s *model.Summary) time.Duration { return s.P99ReqTime }),
+}
+
+func isMetric(name string) bool {
+	for _, m := range Metrics {
+		if m.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tolerances are the relative changes in percent allowed per metric
+type Tolerances struct {
+	Default float64            `json:"default"`
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// For returns the metric tolerance
+func (t Tolerances) For(metric string) float64 {
+	if tolerance, ok := t.Metrics[metric]; ok {
+		return tolerance
+	}
+
+	return t.Default
+}
+
+// ParseTolerances returns the tolerances from the default and the metric=percent overrides
+func ParseTolerances(defaultTolerance float64, overrides []string) (Tolerances, error) {
+	if defaultTolerance < 0 {
+		return Tolerances{}, fmt.Errorf("tolerance %v can't be negative", defaultTolerance)
+	}
+
+	t := Tolerances{
+		Default: defaultTolerance,
+		Metrics: make(map[string]float64),
+	}
+
+	for _, override := range overrides {
+		name, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return Tolerances{}, fmt.Errorf("wrong metric tolerance %q, expected metric=percent", override)
+		}
+
+		name = strings.TrimSpace(name)
+		if !isMetric(name) {
+			return Tolerances{}, fmt.Errorf("unknown metric %q in tolerance %q", name, override)
+		}
+
+		tolerance, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+		if err != nil || tolerance < 0 {
+			return Tolerances{}, fmt.Errorf("wrong metric tolerance %q, expected metric=percent", override)
+		}
+
+		t.Metrics[name] = tolerance
+	}
+
+	return t, nil
+}
+
+// Delta is the metric change of the compared summary against the baseline
+type Delta struct {
+	Value float64 `json:"value"`
+	Abs   float64 `json:"abs_delta"`
+	// Rel is the relative change in percent, nil when the baseline value is zero
+	Rel     *float64 `json:"rel_delta_percent,omitempty"`
+	Verdict string   `json:"verdict"`
+}
+
+// Row is one metric of all the compared summaries
+type Row struct {
+	Metric    string   `json:"metric"`
+	Unit      string   `json:"unit,omitempty"`
+	Tolerance float64  `json:"tolerance_percent"`
+	Baseline  float64  `json:"baseline"`
+	Deltas    []*Delta `json:"compared"`
+}
+
+// SummaryRef identifies the compared summary
+type SummaryRef struct {
+	UUID        string    `json:"id"`
+	Description string    `json:"description"`
+	Start       time.Time `json:"start"`
+}
+
+// Comparison is the result of comparing the summaries with the first one as the baseline
+type Comparison struct {
+	Baseline  SummaryRef   `json:"baseline"`
+	Compared  []SummaryRef `json:"compared"`
+	Rows      []*Row       `json:"metrics"`
+	Tolerance Tolerances   `json:"tolerance"`
+
+	Regressions  int `json:"regressions"`
+	Improvements int `json:"improvements"`
+}
+
+func summaryRef(s *model.Summary) SummaryRef {
+	return SummaryRef{
+		UUID:        s.UUID,
+		Description: s.Description,
+		Start:       s.Start,
+	}
+}
+
+// verdict decides whether the move from base to value is beyond the tolerance
+func verdict(m Metric, base, value, tolerance float64) (*float64, string) {
+	if base == value {
+		rel := 0.0
+		return &rel, VerdictUnchanged
+	}
+
+	worse := value > base
+	if m.HigherIsBetter {
+		worse = !worse
+	}
+
+	// Every change from zero is beyond the tolerance
+	if base == 0 {
+		if worse {
+			return nil, VerdictRegression
+		}
+
+		return nil, VerdictImprovement
+	}
+
+	rel := (value - base) / math.Abs(base) * 100
+	if math.Abs(rel) <= tolerance {
+		return &rel, VerdictUnchanged
+	}
+
+	if worse {
+		return &rel, VerdictRegression
+	}
+
+	return &rel, VerdictImprovement
+}
+
+// Compare compares the summaries against the first one
+func Compare(summaries []*model.Summary, tolerances Tolerances) (*Comparison, error) {
+	if len(summaries) < 2 {
+		return nil, ErrNotEnoughSummaries
+	}
+
+	baseline := summaries[0]
+	c := &Comparison{
+		Baseline:  summaryRef(baseline),
+		Tolerance: tolerances,
+	}
+
+	for _, s := range summaries[1:] {
+		c.Compared = append(c.Compared, summaryRef(s))
+	}
+
+	for _, m := range Metrics {
+		row := &Row{
+			Metric:    m.Name,
+			Unit:      m.Unit,
+			Tolerance: tolerances.For(m.Name),
+			Baseline:  m.value(baseline),
+		}
+
+		for _, s := range summaries[1:] {
+			value := m.value(s)
+			rel, v := verdict(m, row.Baseline, value, row.Tolerance)
+
+			switch v {
+			case VerdictRegression:
+				c.Regressions++
+			case VerdictImprovement:
+				c.Improvements++
+			}
+
+			row.Deltas = append(row.Deltas, &Delta{
+				Value:   value,
+				Abs:     value - row.Baseline,
+				Rel:     rel,
+				Verdict: v,
+			})
+		}
+
+		c.Rows = append(c.Rows, row)
+	}
+
+	return c, nil
+}
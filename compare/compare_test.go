@@ -0,0 +1,111 @@
+package compare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmwalaszek/hload/model"
+)
+
+func findRow(t *testing.T, c *Comparison, metric string) *Row {
+	for _, row := range c.Rows {
+		if row.Metric == metric {
+			return row
+		}
+	}
+
+	t.Fatalf("metric %s not found", metric)
+	return nil
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &model.Summary{
+		UUID:       "a",
+		ReqPerSec:  1000,
+		ReqCount:   10000,
+		SuccessReq: 10000,
+		P50ReqTime: 10 * time.Millisecond,
+		P99ReqTime: 100 * time.Millisecond,
+		AvgReqTime: 20 * time.Millisecond,
+	}
+
+	after := &model.Summary{
+		UUID:       "b",
+		ReqPerSec:  980,
+		ReqCount:   9800,
+		SuccessReq: 9790,
+		FailReq:    10,
+		P50ReqTime: 8 * time.Millisecond,
+		P99ReqTime: 130 * time.Millisecond,
+		AvgReqTime: 20 * time.Millisecond,
+	}
+
+	tolerances, err := ParseTolerances(DefaultTolerance, []string{"p99=50"})
+	require.NoError(t, err)
+
+	c, err := Compare([]*model.Summary{baseline, after}, tolerances)
+	require.NoError(t, err)
+	require.Equal(t, "a", c.Baseline.UUID)
+	require.Len(t, c.Compared, 1)
+	require.Len(t, c.Rows, len(Metrics))
+
+	rps := findRow(t, c, "rps")
+	require.Equal(t, 1000.0, rps.Baseline)
+	require.Equal(t, -20.0, rps.Deltas[0].Abs)
+	require.InDelta(t, -2.0, *rps.Deltas[0].Rel, 1e-9)
+	require.Equal(t, VerdictUnchanged, rps.Deltas[0].Verdict)
+
+	// Failures from zero have no relative change and are always beyond the tolerance
+	fail := findRow(t, c, "fail")
+	require.Nil(t, fail.Deltas[0].Rel)
+	require.Equal(t, VerdictRegression, fail.Deltas[0].Verdict)
+
+	p50 := findRow(t, c, "p50")
+	require.Equal(t, "ms", p50.Unit)
+	require.Equal(t, 10.0, p50.Baseline)
+	require.InDelta(t, -20.0, *p50.Deltas[0].Rel, 1e-9)
+	require.Equal(t, VerdictImprovement, p50.Deltas[0].Verdict)
+
+	// p99 moved 30% which is within its own tolerance
+	p99 := findRow(t, c, "p99")
+	require.Equal(t, 50.0, p99.Tolerance)
+	require.Equal(t, VerdictUnchanged, p99.Deltas[0].Verdict)
+
+	avg := findRow(t, c, "avg")
+	require.Equal(t, 0.0, *avg.Deltas[0].Rel)
+	require.Equal(t, VerdictUnchanged, avg.Deltas[0].Verdict)
+
+	require.Equal(t, 1, c.Regressions)
+	require.Equal(t, 1, c.Improvements)
+
+	// Lower throughput beyond the tolerance is the regression
+	tolerances, err = ParseTolerances(1, nil)
+	require.NoError(t, err)
+
+	c, err = Compare([]*model.Summary{baseline, after, baseline}, tolerances)
+	require.NoError(t, err)
+	rps = findRow(t, c, "rps")
+	require.Len(t, rps.Deltas, 2)
+	require.Equal(t, VerdictRegression, rps.Deltas[0].Verdict)
+	require.Equal(t, VerdictUnchanged, rps.Deltas[1].Verdict)
+
+	_, err = Compare([]*model.Summary{baseline}, tolerances)
+	require.ErrorIs(t, err, ErrNotEnoughSummaries)
+}
+
+func TestParseTolerances(t *testing.T) {
+	tolerances, err := ParseTolerances(5, []string{"p99=10", "rps = 2.5%"})
+	require.NoError(t, err)
+	require.Equal(t, 10.0, tolerances.For("p99"))
+	require.Equal(t, 2.5, tolerances.For("rps"))
+	require.Equal(t, 5.0, tolerances.For("p50"))
+
+	for _, wrong := range []string{"p99", "p98=1", "p99=x", "p99=-1"} {
+		_, err = ParseTolerances(5, []string{wrong})
+		require.Error(t, err, wrong)
+	}
+
+	_, err = ParseTolerances(-1, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,536 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// Significance test methods
+const (
+	MethodMannWhitney = "mann-whitney"
+	MethodBootstrap   = "bootstrap"
+)
+
+// Sources of the samples, the requests stats or the aggregated windows stats
+const (
+	SourceRequests = "requests"
+	SourceWindows  = "windows"
+)
+
+// Significance test defaults
+const (
+	DefaultAlpha      = 0.05
+	DefaultIterations = 2000
+	DefaultStatistic  = "p50"
+
+	// MaxBootstrapSamples is the number of samples the bootstrap resamples at most,
+	// larger samples are randomly reduced to keep the resampling fast
+	MaxBootstrapSamples = 10000
+)
+
+var ErrNoSamples = errors.New("no samples")
+
+// SignificanceOptions are the significance test parameters
+type SignificanceOptions struct {
+	Method string
+	// Alpha is the significance level, the bootstrap confidence interval is 1 - Alpha
+	Alpha float64
+	// Iterations is the number of the bootstrap resamples
+	Iterations int
+	// Statistic is the bootstrap statistic: mean or the percentile p1-p99
+	Statistic string
+	// Seed of the bootstrap random resampling
+	Seed int64
+}
+
+// Validate checks the options and sets the defaults
+func (o *SignificanceOptions) Validate() error {
+	if o.Method == "" {
+		o.Method = MethodMannWhitney
+	}
+
+	if o.Method != MethodMannWhitney && o.Method != MethodBootstrap {
+		return fmt.Errorf("unknown method %q, supported methods: %s, %s", o.Method, MethodMannWhitney, MethodBootstrap)
+	}
+
+	if o.Alpha == 0 {
+		o.Alpha = DefaultAlpha
+	}
+
+	if o.Alpha < 0 || o.Alpha >= 1 {
+		return fmt.Errorf("alpha %v has to be between 0 and 1", o.Alpha)
+	}
+
+	if o.Iterations == 0 {
+		o.Iterations = DefaultIterations
+	}
+
+	if o.Iterations < 0 {
+		return fmt.Errorf("iterations %d can't be negative", o.Iterations)
+	}
+
+	if o.Statistic == "" {
+		o.Statistic = DefaultStatistic
+	}
+
+	_, err := statistic(o.Statistic)
+	return err
+}
+
+// Test is the significance test of one metric between the baseline and the candidate samples
+type Test struct {
+	Metric     string `json:"metric"`
+	Unit       string `json:"unit"`
+	Source     string `json:"source"`
+	BaselineN  int    `json:"baseline_samples"`
+	CandidateN int    `json:"candidate_samples"`
+
+	// Baseline and Candidate are the statistic of the samples, the median for the Mann-Whitney U test
+	Baseline  float64 `json:"baseline"`
+	Candidate float64 `json:"candidate"`
+	Diff      float64 `json:"diff"`
+	// RelDiff is the relative difference in percent, nil when the baseline is zero
+	RelDiff *float64 `json:"rel_diff_percent,omitempty"`
+
+	// U is the Mann-Whitney U statistic of the candidate samples
+	U *float64 `json:"u,omitempty"`
+	// CILow and CIHigh are the bootstrap confidence interval of the difference
+	CILow  *float64 `json:"ci_low,omitempty"`
+	CIHigh *float64 `json:"ci_high,omitempty"`
+
+	PValue float64 `json:"p_value"`
+	// CliffsDelta is the effect size from -1 to 1, positive when the candidate values are higher
+	CliffsDelta float64 `json:"cliffs_delta"`
+	Effect      string  `json:"effect"`
+
+	Significant bool   `json:"significant"`
+	Verdict     string `json:"verdict"`
+}
+
+// SignificanceResult is the result of the significance tests between the baseline and the candidate summaries
+type SignificanceResult struct {
+	Method    string       `json:"method"`
+	Statistic string       `json:"statistic"`
+	Alpha     float64      `json:"alpha"`
+	Baseline  []SummaryRef `json:"baseline"`
+	Candidate []SummaryRef `json:"candidate"`
+	Tests     []*Test      `json:"tests"`
+
+	Regressions  int `json:"regressions"`
+	Improvements int `json:"improvements"`
+}
+
+// statistic returns the statistic function of the sorted samples
+func statistic(name string) (func(sorted []float64) float64, error) {
+	if name == "mean" {
+		return func(sorted []float64) float64 {
+			var sum float64
+			for _, v := range sorted {
+				sum += v
+			}
+
+			return sum / float64(len(sorted))
+		}, nil
+	}
+
+	p, err := strconv.Atoi(strings.TrimPrefix(name, "p"))
+	if err != nil || !strings.HasPrefix(name, "p") || p < 1 || p > 99 {
+		return nil, fmt.Errorf("unknown statistic %q, supported statistics: mean, p1-p99", name)
+	}
+
+	return func(sorted []float64) float64 {
+		return quantile(sorted, float64(p)/100)
+	}, nil
+}
+
+// quantile returns the linearly interpolated quantile of the sorted samples
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	return sorted[i] + (sorted[i+1]-sorted[i])*(pos-float64(i))
+}
+
+// MannWhitneyU returns the U statistic of the b samples and the two-sided p-value from the normal
+// approximation with the ties and the continuity corrections
+func MannWhitneyU(a, b []float64) (u, pValue float64) {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	type sample struct {
+		value float64
+		b     bool
+	}
+
+	all := make([]sample, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, sample{value: v})
+	}
+	for _, v := range b {
+		all = append(all, sample{value: v, b: true})
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].value < all[j].value
+	})
+
+	// Ranks sum of the b samples with the tied values getting the average rank
+	var rankSum, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].b {
+				rankSum += rank
+			}
+		}
+
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	u = rankSum - n2*(n2+1)/2
+
+	n := n1 + n2
+	mean := n1 * n2 / 2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return u, 1
+	}
+
+	z := math.Max(math.Abs(u-mean)-0.5, 0) / sigma
+
+	return u, math.Min(math.Erfc(z/math.Sqrt2), 1)
+}
+
+// CliffsDelta returns the effect size from the U statistic of the b samples,
+// the share of the pairs where b is higher minus the share where it is lower
+func CliffsDelta(u float64, n1, n2 int) float64 {
+	if n1 == 0 || n2 == 0 {
+		return 0
+	}
+
+	return 2*u/(float64(n1)*float64(n2)) - 1
+}
+
+// EffectMagnitude returns the Cliff's delta magnitude: negligible, small, medium or large
+func EffectMagnitude(delta float64) string {
+	switch d := math.Abs(delta); {
+	case d < 0.147:
+		return "negligible"
+	case d < 0.33:
+		return "small"
+	case d < 0.474:
+		return "medium"
+	default:
+		return "large"
+	}
+}
+
+// Bootstrap returns the 1 - alpha confidence interval of the statistic difference between the b and a samples
+// and the two-sided p-value from the share of the resampled differences on the other side of zero
+func Bootstrap(a, b []float64, stat func(sorted []float64) float64, iterations int, alpha float64, rng *rand.Rand) (low, high, pValue float64) {
+	a = reduceSamples(a, rng)
+	b = reduceSamples(b, rng)
+
+	diffs := make([]float64, iterations)
+	ra := make([]float64, len(a))
+	rb := make([]float64, len(b))
+
+	var below, above int
+	for i := range diffs {
+		resample(ra, a, rng)
+		resample(rb, b, rng)
+
+		diffs[i] = stat(rb) - stat(ra)
+		if diffs[i] <= 0 {
+			below++
+		}
+		if diffs[i] >= 0 {
+			above++
+		}
+	}
+
+	sort.Float64s(diffs)
+	low = quantile(diffs, alpha/2)
+	high = quantile(diffs, 1-alpha/2)
+	pValue = math.Min(1, 2*float64(min(below, above))/float64(iterations))
+
+	return low, high, pValue
+}
+
+func sortedCopy(samples []float64) []float64 {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+
+	return sorted
+}
+
+// resample fills dst with the sorted random draws with replacement from the samples
+func resample(dst, samples []float64, rng *rand.Rand) {
+	for i := range dst {
+		dst[i] = samples[rng.Intn(len(samples))]
+	}
+
+	sort.Float64s(dst)
+}
+
+// reduceSamples returns at most MaxBootstrapSamples random samples
+func reduceSamples(samples []float64, rng *rand.Rand) []float64 {
+	if len(samples) <= MaxBootstrapSamples {
+		return samples
+	}
+
+	reduced := make([]float64, MaxBootstrapSamples)
+	for i, j := range rng.Perm(len(samples))[:MaxBootstrapSamples] {
+		reduced[i] = samples[j]
+	}
+
+	return reduced
+}
+
+// latencySource returns the requests source when all the summaries have the requests stats, otherwise the windows
+func latencySource(summaries []*model.Summary) string {
+	for _, s := range summaries {
+		if len(s.RequestStats) == 0 {
+			return SourceWindows
+		}
+	}
+
+	return SourceRequests
+}
+
+// latencySamples returns the requests durations or the windows average request times in milliseconds,
+// the failed requests are left out
+func latencySamples(summaries []*model.Summary, source string) ([]float64, error) {
+	var samples []float64
+	for _, s := range summaries {
+		if source == SourceRequests {
+			for _, r := range s.RequestStats {
+				if r.Error == "" {
+					samples = append(samples, toMs(r.Duration))
+				}
+			}
+
+			continue
+		}
+
+		if len(s.AggregatedStats) == 0 {
+			return nil, fmt.Errorf("%w: summary %s has neither requests nor aggregated stats", ErrNoSamples, s.UUID)
+		}
+
+		for _, w := range s.AggregatedStats {
+			if w.RequestCount > 0 {
+				samples = append(samples, toMs(w.AvgRequestTime))
+			}
+		}
+	}
+
+	return samples, nil
+}
+
+// throughputSource returns the windows source when all the summaries have the aggregated stats, otherwise the requests
+func throughputSource(summaries []*model.Summary) string {
+	for _, s := range summaries {
+		if len(s.AggregatedStats) == 0 {
+			return SourceRequests
+		}
+	}
+
+	return SourceWindows
+}
+
+// throughputSamples returns the windows requests per second or the requests of every full second of the benchmark
+func throughputSamples(summaries []*model.Summary, source string) ([]float64, error) {
+	var samples []float64
+	for _, s := range summaries {
+		if source == SourceWindows {
+			for _, w := range s.AggregatedStats {
+				d := w.Duration
+				if d <= 0 {
+					d = w.End.Sub(w.Start)
+				}
+
+				if d > 0 {
+					samples = append(samples, float64(w.RequestCount)/d.Seconds())
+				}
+			}
+
+			continue
+		}
+
+		if len(s.RequestStats) == 0 {
+			return nil, fmt.Errorf("%w: summary %s has neither requests nor aggregated stats", ErrNoSamples, s.UUID)
+		}
+
+		seconds := make([]float64, int(s.TotalTime/time.Second))
+		for _, r := range s.RequestStats {
+			i := int(r.End.Sub(s.Start) / time.Second)
+			if i >= 0 && i < len(seconds) {
+				seconds[i]++
+			}
+		}
+
+		samples = append(samples, seconds...)
+	}
+
+	return samples, nil
+}
+
+func toMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func summaryRefs(summaries []*model.Summary) []SummaryRef {
+	refs := make([]SummaryRef, 0, len(summaries))
+	for _, s := range summaries {
+		refs = append(refs, summaryRef(s))
+	}
+
+	return refs
+}
+
+// Significance tests whether the latency and the throughput of the candidate summaries differ from the baseline
+// summaries, the samples of every group are pooled
+func Significance(baseline, candidate []*model.Summary, opts SignificanceOptions) (*SignificanceResult, error) {
+	if len(baseline) == 0 || len(candidate) == 0 {
+		return nil, ErrNotEnoughSummaries
+	}
+
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := statistic(opts.Statistic)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SignificanceResult{
+		Method:    opts.Method,
+		Statistic: opts.Statistic,
+		Alpha:     opts.Alpha,
+		Baseline:  summaryRefs(baseline),
+		Candidate: summaryRefs(candidate),
+	}
+
+	if opts.Method == MethodMannWhitney {
+		result.Statistic = "p50"
+	}
+
+	metrics := []struct {
+		name           string
+		unit           string
+		higherIsBetter bool
+		source         func([]*model.Summary) string
+		samples        func([]*model.Summary, string) ([]float64, error)
+	}{
+		{"latency", "ms", false, latencySource, latencySamples},
+		{"throughput", "req/s", true, throughputSource, throughputSamples},
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	both := append(append([]*model.Summary(nil), baseline...), candidate...)
+
+	for _, m := range metrics {
+		// The source is decided for both groups so the samples are comparable
+		source := m.source(both)
+
+		a, err := m.samples(baseline, source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.name, err)
+		}
+
+		b, err := m.samples(candidate, source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.name, err)
+		}
+
+		if len(a) == 0 || len(b) == 0 {
+			return nil, fmt.Errorf("%s: %w", m.name, ErrNoSamples)
+		}
+
+		u, pValue := MannWhitneyU(a, b)
+		test := &Test{
+			Metric:      m.name,
+			Unit:        m.unit,
+			Source:      source,
+			BaselineN:   len(a),
+			CandidateN:  len(b),
+			PValue:      pValue,
+			CliffsDelta: CliffsDelta(u, len(a), len(b)),
+		}
+
+		if opts.Method == MethodBootstrap {
+			low, high, p := Bootstrap(a, b, stat, opts.Iterations, opts.Alpha, rng)
+			test.Baseline = stat(sortedCopy(a))
+			test.Candidate = stat(sortedCopy(b))
+			test.CILow = &low
+			test.CIHigh = &high
+			test.PValue = p
+		} else {
+			test.Baseline = quantile(sortedCopy(a), 0.5)
+			test.Candidate = quantile(sortedCopy(b), 0.5)
+			test.U = &u
+		}
+
+		test.Diff = test.Candidate - test.Baseline
+
+		if test.Baseline != 0 {
+			rel := test.Diff / math.Abs(test.Baseline) * 100
+			test.RelDiff = &rel
+		}
+
+		test.Effect = EffectMagnitude(test.CliffsDelta)
+		test.Significant = test.PValue < opts.Alpha
+		test.Verdict = VerdictUnchanged
+
+		// The direction is the effect size sign for the ranks test and the difference sign for the bootstrap
+		direction := test.CliffsDelta
+		if opts.Method == MethodBootstrap {
+			direction = test.Diff
+		}
+
+		if test.Significant && direction != 0 {
+			worse := direction > 0
+			if m.higherIsBetter {
+				worse = !worse
+			}
+
+			if worse {
+				test.Verdict = VerdictRegression
+				result.Regressions++
+			} else {
+				test.Verdict = VerdictImprovement
+				result.Improvements++
+			}
+		}
+
+		result.Tests = append(result.Tests, test)
+	}
+
+	return result, nil
+}
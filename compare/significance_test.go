@@ -0,0 +1,170 @@
+package compare
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmwalaszek/hload/model"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	u, p := MannWhitneyU([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10})
+	require.Equal(t, 25.0, u)
+	require.InDelta(t, 0.01219, p, 1e-4)
+	require.Equal(t, 1.0, CliffsDelta(u, 5, 5))
+	require.Equal(t, "large", EffectMagnitude(1))
+
+	// Ties get the average rank
+	u, p = MannWhitneyU([]float64{1, 2, 2, 3}, []float64{2, 3, 3, 4})
+	require.Equal(t, 13.0, u)
+	require.Greater(t, p, 0.05)
+
+	u, p = MannWhitneyU([]float64{1, 1, 1}, []float64{1, 1})
+	require.Equal(t, 3.0, u)
+	require.Equal(t, 1.0, p)
+	require.Equal(t, 0.0, CliffsDelta(u, 3, 2))
+	require.Equal(t, "negligible", EffectMagnitude(0))
+}
+
+func TestBootstrap(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	var a, b []float64
+	for i := 0; i < 200; i++ {
+		a = append(a, 10+rng.NormFloat64())
+		b = append(b, 12+rng.NormFloat64())
+	}
+
+	stat, err := statistic("p50")
+	require.NoError(t, err)
+
+	low, high, p := Bootstrap(a, b, stat, 1000, 0.05, rng)
+	require.Greater(t, low, 1.5)
+	require.Less(t, high, 2.5)
+	require.Equal(t, 0.0, p)
+
+	low, high, p = Bootstrap(a, a, stat, 1000, 0.05, rng)
+	require.Less(t, low, 0.0)
+	require.Greater(t, high, 0.0)
+	require.Greater(t, p, 0.05)
+}
+
+func TestStatistic(t *testing.T) {
+	for _, name := range []string{"mean", "p1", "p50", "p99"} {
+		_, err := statistic(name)
+		require.NoError(t, err, name)
+	}
+
+	for _, name := range []string{"", "p", "p0", "p100", "median", "99"} {
+		_, err := statistic(name)
+		require.Error(t, err, name)
+	}
+
+	require.Equal(t, 2.5, quantile([]float64{1, 2, 3, 4}, 0.5))
+}
+
+// testSummary returns the summary with the requests stats of the latencies and the windows of the throughputs
+func testSummary(id string, latency, rps float64, requests bool, rng *rand.Rand) *model.Summary {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &model.Summary{
+		UUID:      id,
+		Start:     start,
+		TotalTime: 10 * time.Second,
+	}
+
+	for i := 0; i < 10; i++ {
+		count := int(rps + rng.NormFloat64()*rps/20)
+		avg := time.Duration((latency + rng.NormFloat64()) * float64(time.Millisecond))
+		s.AggregatedStats = append(s.AggregatedStats, &model.AggregatedStat{
+			Start:          start.Add(time.Duration(i) * time.Second),
+			End:            start.Add(time.Duration(i+1) * time.Second),
+			Duration:       time.Second,
+			AvgRequestTime: avg,
+			RequestCount:   count,
+		})
+
+		if !requests {
+			continue
+		}
+
+		for j := 0; j < count; j++ {
+			d := time.Duration(math.Abs(latency+rng.NormFloat64()*latency/10) * float64(time.Millisecond))
+			s.RequestStats = append(s.RequestStats, &model.RequestStat{
+				End:      start.Add(time.Duration(i)*time.Second + time.Duration(j)*time.Millisecond),
+				Duration: d,
+			})
+		}
+	}
+
+	return s
+}
+
+func TestSignificance(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	baseline := []*model.Summary{testSummary("a", 10, 100, true, rng), testSummary("b", 10, 100, true, rng)}
+	slower := []*model.Summary{testSummary("c", 12, 100, true, rng)}
+
+	r, err := Significance(baseline, slower, SignificanceOptions{})
+	require.NoError(t, err)
+	require.Equal(t, MethodMannWhitney, r.Method)
+	require.Len(t, r.Baseline, 2)
+	require.Len(t, r.Tests, 2)
+
+	latency := r.Tests[0]
+	require.Equal(t, "latency", latency.Metric)
+	require.Equal(t, SourceRequests, latency.Source)
+	require.Greater(t, latency.BaselineN, 1500)
+	require.NotNil(t, latency.U)
+	require.Less(t, latency.PValue, 0.001)
+	require.Greater(t, latency.CliffsDelta, 0.474)
+	require.Equal(t, "large", latency.Effect)
+	require.Equal(t, VerdictRegression, latency.Verdict)
+	require.InDelta(t, 20, *latency.RelDiff, 2)
+
+	throughput := r.Tests[1]
+	require.Equal(t, SourceWindows, throughput.Source)
+	require.Equal(t, 20, throughput.BaselineN)
+	require.False(t, throughput.Significant)
+	require.Equal(t, VerdictUnchanged, throughput.Verdict)
+
+	require.Equal(t, 1, r.Regressions)
+	require.Equal(t, 0, r.Improvements)
+
+	// The summary without the requests stats makes both groups use the windows
+	faster := []*model.Summary{testSummary("d", 5, 200, false, rng)}
+	r, err = Significance(baseline, faster, SignificanceOptions{Method: MethodBootstrap, Iterations: 500, Statistic: "mean"})
+	require.NoError(t, err)
+	require.Equal(t, "mean", r.Statistic)
+	require.Equal(t, SourceWindows, r.Tests[0].Source)
+	require.Equal(t, 20, r.Tests[0].BaselineN)
+	require.NotNil(t, r.Tests[0].CILow)
+	require.Less(t, *r.Tests[0].CIHigh, 0.0)
+	require.Equal(t, VerdictImprovement, r.Tests[0].Verdict)
+	require.Equal(t, VerdictImprovement, r.Tests[1].Verdict)
+	require.Equal(t, 2, r.Improvements)
+
+	// Throughput from the requests per second when the windows are missing
+	noWindows := testSummary("e", 10, 100, true, rng)
+	noWindows.AggregatedStats = nil
+	r, err = Significance(baseline, []*model.Summary{noWindows}, SignificanceOptions{})
+	require.NoError(t, err)
+	require.Equal(t, SourceRequests, r.Tests[1].Source)
+	require.Equal(t, 10, r.Tests[1].CandidateN)
+
+	empty := &model.Summary{UUID: "f"}
+	_, err = Significance(baseline, []*model.Summary{empty}, SignificanceOptions{})
+	require.ErrorIs(t, err, ErrNoSamples)
+
+	_, err = Significance(baseline, nil, SignificanceOptions{})
+	require.ErrorIs(t, err, ErrNotEnoughSummaries)
+
+	_, err = Significance(baseline, slower, SignificanceOptions{Method: "t-test"})
+	require.Error(t, err)
+
+	_, err = Significance(baseline, slower, SignificanceOptions{Alpha: 1.5})
+	require.Error(t, err)
+}
@@ -0,0 +1,382 @@
+// Package dashboard shows the live statistics of the running benchmark in the terminal
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/caio/go-tdigest/v4"
+	"golang.org/x/term"
+)
+
+const (
+	// RefreshInterval is how often the dashboard is redrawn
+	RefreshInterval = time.Second
+
+	// maxErrors is the number of the most frequent errors shown
+	maxErrors = 5
+	// defaultWidth is used when the terminal size is unknown
+	defaultWidth = 80
+)
+
+// sparkBars are the sparkline levels from the lowest to the highest
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Source is the running benchmark state which is not in the requests stats
+type Source interface {
+	InFlight() int
+	TargetRate() float64
+	Connections() model.ConnectionStats
+}
+
+// window collects the latency of the requests finished within the aggregation window
+type window struct {
+	count int
+	total time.Duration
+}
+
+// Dashboard collects the request stats it observes and draws them every second
+type Dashboard struct {
+	conf   *model.Loader
+	source Source
+	window time.Duration
+
+	mu        sync.Mutex
+	start     time.Time
+	requests  int
+	failed    int
+	bytes     int
+	t         *tdigest.TDigest
+	windows   []window
+	httpCodes map[int]int
+	errors    map[string]int
+	stopping  bool
+
+	// lastRequests and lastRender give the current requests per second
+	lastRequests int
+	lastRender   time.Time
+	rps          float64
+
+	out      *os.File
+	inFd     int
+	oldState *term.State
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates the dashboard of the benchmark, the latency sparkline has a bar per aggregation window
+func New(conf *model.Loader, source Source) (*Dashboard, error) {
+	t, err := tdigest.New()
+	if err != nil {
+		return nil, fmt.Errorf("tdigest error: %w", err)
+	}
+
+	w := conf.AggregateWindow
+	if w <= 0 {
+		w = RefreshInterval
+	}
+
+	now := time.Now()
+
+	return &Dashboard{
+		conf:       conf,
+		source:     source,
+		window:     w,
+		start:      now,
+		lastRender: now,
+		t:          t,
+		httpCodes:  make(map[int]int),
+		errors:     make(map[string]int),
+	}, nil
+}
+
+// Observe counts the request stat, the flow stats are skipped as their steps are observed separately
+func (d *Dashboard) Observe(stat *model.RequestStat) {
+	if stat.Flow {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.requests++
+	d.bytes += stat.BodySize
+
+	if loader.StatResponded(stat) {
+		d.httpCodes[stat.RetCode]++
+	}
+
+	if loader.StatFailed(stat) {
+		d.failed++
+		d.errors[loader.StatError(stat)]++
+	}
+
+	_ = d.t.Add(float64(stat.Duration))
+
+	win := 0
+	if stat.End.After(d.start) {
+		win = int(stat.End.Sub(d.start) / d.window)
+	}
+
+	for len(d.windows) <= win {
+		d.windows = append(d.windows, window{})
+	}
+
+	d.windows[win].count++
+	d.windows[win].total += stat.Duration
+}
+
+// Stopping tells the dashboard the benchmark is being stopped
+func (d *Dashboard) Stopping() {
+	d.mu.Lock()
+	d.stopping = true
+	d.mu.Unlock()
+}
+
+// Start switches the terminal to the dashboard screen and redraws it every second.
+// The q key or Ctrl+C call stop, the keys are read only when the input is a terminal.
+func (d *Dashboard) Start(in, out *os.File, stop func()) error {
+	d.out = out
+	d.done = make(chan struct{})
+
+	if term.IsTerminal(int(in.Fd())) {
+		oldState, err := term.MakeRaw(int(in.Fd()))
+		if err != nil {
+			return fmt.Errorf("could not set the terminal raw mode: %w", err)
+		}
+
+		d.inFd = int(in.Fd())
+		d.oldState = oldState
+		go d.readKeys(in, stop)
+	}
+
+	// Switch to the alternate screen and hide the cursor
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(RefreshInterval)
+		defer ticker.Stop()
+
+		d.draw()
+		for {
+			select {
+			case <-d.done:
+				return
+			case <-ticker.C:
+				d.draw()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops redrawing the dashboard and restores the terminal
+func (d *Dashboard) Close() {
+	if d.done == nil {
+		return
+	}
+
+	close(d.done)
+	d.wg.Wait()
+
+	fmt.Fprint(d.out, "\x1b[?25h\x1b[?1049l")
+
+	if d.oldState != nil {
+		_ = term.Restore(d.inFd, d.oldState)
+	}
+}
+
+func (d *Dashboard) readKeys(in io.Reader, stop func()) {
+	buf := make([]byte, 1)
+	for {
+		_, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+
+		switch buf[0] {
+		case 'q', 'Q', 3:
+			d.Stopping()
+			stop()
+			return
+		}
+	}
+}
+
+func (d *Dashboard) draw() {
+	width := defaultWidth
+	if w, _, err := term.GetSize(int(d.out.Fd())); err == nil && w > 0 {
+		width = w
+	}
+
+	screen := d.Render(time.Now(), width)
+
+	// The raw mode terminal does not return the carriage on the new line
+	fmt.Fprint(d.out, "\x1b[H\x1b[2J"+strings.ReplaceAll(screen, "\n", "\r\n"))
+}
+
+// Render returns the dashboard screen at the time now fitted to the width
+func (d *Dashboard) Render(now time.Time, width int) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if elapsed := now.Sub(d.lastRender); elapsed > 0 {
+		d.rps = float64(d.requests-d.lastRequests) / elapsed.Seconds()
+		d.lastRequests = d.requests
+		d.lastRender = now
+	}
+
+	var b strings.Builder
+	elapsed := now.Sub(d.start).Truncate(time.Second)
+
+	fmt.Fprintf(&b, "HLoad %s %s (%s)\n\n", d.conf.Method, d.conf.URL, d.conf.HTTPEngine)
+
+	switch {
+	case d.conf.Duration != 0:
+		fmt.Fprintf(&b, "Elapsed:             %v / %v\n", elapsed, d.conf.Duration)
+	default:
+		fmt.Fprintf(&b, "Elapsed:             %v\n", elapsed)
+	}
+
+	switch {
+	case d.conf.ReqCount != 0:
+		fmt.Fprintf(&b, "Requests:            %d / %d (failed %d)\n", d.requests, d.conf.ReqCount, d.failed)
+	default:
+		fmt.Fprintf(&b, "Requests:            %d (failed %d)\n", d.requests, d.failed)
+	}
+
+	fmt.Fprintf(&b, "Requests/second:     %.1f", d.rps)
+	if target := d.source.TargetRate(); target != 0 {
+		fmt.Fprintf(&b, " (target %.1f)", target)
+	}
+	b.WriteString("\n")
+
+	connections := d.source.Connections()
+	fmt.Fprintf(&b, "In-flight requests:  %d\n", d.source.InFlight())
+	fmt.Fprintf(&b, "Active connections:  %d (new %d, reused %d, closed %d, reset %d)\n",
+		connections.NewConnections-connections.ClosedConnections, connections.NewConnections,
+		connections.ReusedConnections, connections.ClosedConnections, connections.ResetConnections)
+	fmt.Fprintf(&b, "Data transferred:    %d bytes\n\n", d.bytes)
+
+	if d.requests != 0 {
+		fmt.Fprintf(&b, "Latency:             p50 %v  p90 %v  p99 %v\n",
+			time.Duration(d.t.Quantile(0.5)), time.Duration(d.t.Quantile(0.9)), time.Duration(d.t.Quantile(0.99)))
+	} else {
+		b.WriteString("Latency:             -\n")
+	}
+
+	prefix := fmt.Sprintf("Latency per %v:", d.window)
+	fmt.Fprintf(&b, "%-21s%s\n\n", prefix, sparkline(d.windows, width-21))
+
+	b.WriteString("Status codes:\n")
+	codes := make([]int, 0, len(d.httpCodes))
+	for code := range d.httpCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		fmt.Fprintf(&b, "  %d %-30s %d\n", code, http.StatusText(code), d.httpCodes[code])
+	}
+	b.WriteString("\n")
+
+	b.WriteString("Top errors:\n")
+	errs := make([]string, 0, len(d.errors))
+	for err := range d.errors {
+		errs = append(errs, err)
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		if d.errors[errs[i]] != d.errors[errs[j]] {
+			return d.errors[errs[i]] > d.errors[errs[j]]
+		}
+
+		return errs[i] < errs[j]
+	})
+
+	if len(errs) > maxErrors {
+		errs = errs[:maxErrors]
+	}
+
+	for _, err := range errs {
+		fmt.Fprintf(&b, "  %-40s %d\n", truncate(err, 40), d.errors[err])
+	}
+	b.WriteString("\n")
+
+	if d.stopping {
+		b.WriteString("Stopping the benchmark...\n")
+	} else {
+		b.WriteString("Press q to stop the benchmark, the summary is still saved\n")
+	}
+
+	return b.String()
+}
+
+// sparkline draws the average latency of the last windows which fit the width
+func sparkline(windows []window, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	if len(windows) > width {
+		windows = windows[len(windows)-width:]
+	}
+
+	avgs := make([]time.Duration, len(windows))
+	var minAvg, maxAvg time.Duration
+	var found bool
+	for i, w := range windows {
+		if w.count == 0 {
+			continue
+		}
+
+		avgs[i] = w.total / time.Duration(w.count)
+		if !found || avgs[i] < minAvg {
+			minAvg = avgs[i]
+		}
+
+		if !found || avgs[i] > maxAvg {
+			maxAvg = avgs[i]
+		}
+		found = true
+	}
+
+	var b strings.Builder
+	for i, avg := range avgs {
+		// The windows without the finished requests are left blank
+		if windows[i].count == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+
+		level := 0
+		if maxAvg > minAvg {
+			level = int(int64(avg-minAvg) * int64(len(sparkBars)-1) / int64(maxAvg-minAvg))
+		}
+
+		b.WriteRune(sparkBars[level])
+	}
+
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n-3]) + "..."
+}
@@ -0,0 +1,90 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testSource struct{}
+
+func (testSource) InFlight() int {
+	return 2
+}
+
+func (testSource) TargetRate() float64 {
+	return 50
+}
+
+func (testSource) Connections() model.ConnectionStats {
+	return model.ConnectionStats{NewConnections: 5, ReusedConnections: 20, ClosedConnections: 1}
+}
+
+func TestRender(t *testing.T) {
+	conf := &model.Loader{
+		URL:             "http://localhost:8080/",
+		Method:          "GET",
+		HTTPEngine:      "fast_http",
+		AggregateWindow: time.Second,
+		LoaderReqDetails: model.LoaderReqDetails{
+			Duration: time.Minute,
+		},
+	}
+
+	d, err := New(conf, testSource{})
+	require.Nil(t, err)
+
+	start := d.start
+	stats := []*model.RequestStat{
+		{End: start.Add(100 * time.Millisecond), Duration: 10 * time.Millisecond, RetCode: 200, BodySize: 10},
+		{End: start.Add(200 * time.Millisecond), Duration: 10 * time.Millisecond, RetCode: 200, BodySize: 10},
+		{End: start.Add(1100 * time.Millisecond), Duration: 30 * time.Millisecond, RetCode: 503},
+		{End: start.Add(1200 * time.Millisecond), Duration: time.Second, Error: "timeout"},
+		{End: start.Add(1300 * time.Millisecond), Duration: 40 * time.Millisecond, Flow: true},
+	}
+
+	for _, stat := range stats {
+		d.Observe(stat)
+	}
+
+	screen := d.Render(start.Add(2*time.Second), 80)
+
+	expected := []string{
+		"HLoad GET http://localhost:8080/ (fast_http)",
+		"Elapsed:             2s / 1m0s",
+		"Requests:            4 (failed 2)",
+		"Requests/second:     2.0 (target 50.0)",
+		"In-flight requests:  2",
+		"Active connections:  4 (new 5, reused 20, closed 1, reset 0)",
+		"Data transferred:    20 bytes",
+		"Latency per 1s:      ▁█",
+		"  200 OK",
+		"  503 Service Unavailable",
+		"  timeout",
+		"  Service Unavailable",
+		"Press q to stop the benchmark",
+	}
+
+	for _, line := range expected {
+		require.Contains(t, screen, line)
+	}
+
+	d.Stopping()
+	require.Contains(t, d.Render(start.Add(3*time.Second), 80), "Stopping the benchmark...")
+}
+
+func TestSparkline(t *testing.T) {
+	windows := []window{
+		{count: 1, total: time.Millisecond},
+		{},
+		{count: 2, total: 10 * time.Millisecond},
+		{count: 1, total: 8 * time.Millisecond},
+	}
+
+	require.Equal(t, "▁ ▅█", sparkline(windows, 10))
+	require.Equal(t, "▁█", sparkline(windows, 2))
+	require.Equal(t, "", sparkline(windows, 0))
+}
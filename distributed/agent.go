@@ -0,0 +1,189 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+)
+
+// progressInterval is how often the agent sends the progress
+var progressInterval = time.Second
+
+// Agent runs the benchmarks sent by the controller, one at a time
+type Agent struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// NewAgent creates the agent
+func NewAgent() *Agent {
+	return &Agent{}
+}
+
+// Handler returns the agent HTTP API handler
+func (a *Agent) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(RunPath, a.handleRun)
+	mux.HandleFunc(StopPath, a.handleStop)
+
+	return mux
+}
+
+// handleRun runs the loader sent in the request body and streams the progress and the summary back.
+// The benchmark stops when the controller goes away or asks the agent to stop.
+func (a *Agent) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf := &model.Loader{}
+	err := json.NewDecoder(r.Body).Decode(conf)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not decode the loader: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	a.mu.Lock()
+	if a.cancel != nil {
+		a.mu.Unlock()
+		http.Error(w, "the agent is already running a benchmark", http.StatusConflict)
+		return
+	}
+	a.cancel = cancel
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.cancel = nil
+		a.mu.Unlock()
+	}()
+
+	l, err := loader.NewLoader(conf)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not create the loader: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	counter := &progressCounter{flow: len(conf.Flow) > 0}
+	l.AddObserver(counter)
+
+	log.Printf("Running the benchmark of %s with %d connections", conf.URL, conf.Connections)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	s := &stream{w: w, enc: json.NewEncoder(w)}
+	s.flusher, _ = w.(http.Flusher)
+
+	type result struct {
+		summary *model.Summary
+		err     error
+	}
+
+	start := time.Now()
+	resultChan := make(chan result, 1)
+	go func() {
+		summary, err := l.Do(ctx)
+		resultChan <- result{summary, err}
+	}()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.send(&Message{Type: MessageProgress, Progress: counter.progress(time.Since(start))})
+		case res := <-resultChan:
+			if res.err != nil {
+				log.Printf("Benchmark error: %v", res.err)
+				s.send(&Message{Type: MessageError, Error: res.err.Error()})
+				return
+			}
+
+			log.Printf("Benchmark finished: %d requests", res.summary.ReqCount)
+			s.send(&Message{Type: MessageSummary, Summary: res.summary})
+			return
+		}
+	}
+}
+
+// handleStop stops the running benchmark, the summary is still sent
+func (a *Agent) handleStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.mu.Lock()
+	if a.cancel != nil {
+		log.Print("Received stop and will stop benchmark")
+		a.cancel()
+	}
+	a.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stream writes the messages as JSON lines flushing every one of them
+type stream struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	flusher http.Flusher
+}
+
+func (s *stream) send(m *Message) {
+	err := s.enc.Encode(m)
+	if err != nil {
+		return
+	}
+
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+// progressCounter counts the finished requests, in the flow every flow run counts as one request
+type progressCounter struct {
+	flow bool
+
+	mu       sync.Mutex
+	requests int
+	failed   int
+}
+
+func (p *progressCounter) Observe(stat *model.RequestStat) {
+	if p.flow != stat.Flow {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests++
+	if loader.StatFailed(stat) {
+		p.failed++
+	}
+}
+
+func (p *progressCounter) progress(elapsed time.Duration) *Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return &Progress{
+		Elapsed:  elapsed,
+		Requests: p.requests,
+		Failed:   p.failed,
+	}
+}
@@ -0,0 +1,240 @@
+package distributed
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+)
+
+// Controller runs the benchmark on the agents and merges their summaries
+type Controller struct {
+	agents []string
+	client *http.Client
+
+	// OnProgress is called with the agent index when the agent sends its progress
+	OnProgress func(agent int, progress *Progress)
+}
+
+// NewController creates the controller of the agents, the agent address is host[:port] or the URL
+func NewController(agents []string) (*Controller, error) {
+	if len(agents) == 0 {
+		return nil, errors.New("no agents")
+	}
+
+	c := &Controller{
+		client: &http.Client{},
+	}
+
+	for _, agent := range agents {
+		u, err := agentURL(agent)
+		if err != nil {
+			return nil, err
+		}
+
+		c.agents = append(c.agents, u)
+	}
+
+	return c, nil
+}
+
+func agentURL(agent string) (string, error) {
+	agent = strings.TrimSpace(agent)
+	if !strings.Contains(agent, "://") {
+		agent = "http://" + agent
+	}
+
+	u, err := url.Parse(agent)
+	if err != nil {
+		return "", fmt.Errorf("wrong agent address %s: %w", agent, err)
+	}
+
+	if u.Host == "" {
+		return "", fmt.Errorf("wrong agent address %s: no host", agent)
+	}
+
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), DefaultPort)
+	}
+
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// Split splits the connections, the rate limits, the requests count and the stages targets between the agents.
+// The remainders go to the first agents, every agent needs at least one connection.
+func Split(conf *model.Loader, agents int) ([]*model.Loader, error) {
+	// The connections stages set the connections when they are not given
+	connectionsStages := len(conf.Stages) > 0 && conf.StageTarget != model.StageTargetRateLimit
+	connections := conf.Connections
+	if connectionsStages && connections == 0 {
+		connections = conf.Stages.MaxTarget()
+	}
+
+	if connections < agents {
+		return nil, fmt.Errorf("%d connections can't be split between %d agents", connections, agents)
+	}
+
+	if conf.ReqCount != 0 && conf.ReqCount < agents {
+		return nil, fmt.Errorf("%d requests can't be split between %d agents", conf.ReqCount, agents)
+	}
+
+	if connectionsStages {
+		for _, stage := range conf.Stages {
+			if stage.Target != 0 && stage.Target < agents {
+				return nil, fmt.Errorf("stage target %d can't be split between %d agents", stage.Target, agents)
+			}
+		}
+	}
+
+	confs := make([]*model.Loader, agents)
+	for i := range confs {
+		c := *conf
+
+		c.Connections = share(connections, agents, i)
+		c.RateLimit = share(conf.RateLimit, agents, i)
+		c.ArrivalRate = share(conf.ArrivalRate, agents, i)
+		c.ReqCount = share(conf.ReqCount, agents, i)
+
+		if len(conf.Stages) > 0 {
+			c.Stages = make(model.Stages, len(conf.Stages))
+			for j, stage := range conf.Stages {
+				s := *stage
+				s.Target = share(stage.Target, agents, i)
+				c.Stages[j] = &s
+			}
+		}
+
+		confs[i] = &c
+	}
+
+	return confs, nil
+}
+
+// share returns the part of the value of the agent i
+func share(value, agents, i int) int {
+	s := value / agents
+	if i < value%agents {
+		s++
+	}
+
+	return s
+}
+
+// Run runs the benchmark split between the agents and returns the merged summary.
+// When the context is cancelled the agents are asked to stop and their summaries are still merged.
+func (c *Controller) Run(ctx context.Context, conf *model.Loader) (*model.Summary, error) {
+	confs, err := Split(conf, len(c.agents))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*model.Summary, len(c.agents))
+	errs := make([]error, len(c.agents))
+
+	var wg sync.WaitGroup
+	for i := range c.agents {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			summaries[i], errs[i] = c.runAgent(ctx, i, confs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: %w", c.agents[i], err)
+		}
+	}
+
+	summary, err := loader.MergeSummaries(summaries)
+	if err != nil {
+		return nil, err
+	}
+	summary.URL = conf.URL
+
+	return summary, nil
+}
+
+func (c *Controller) runAgent(ctx context.Context, i int, conf *model.Loader) (*model.Summary, error) {
+	body, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	// The run request is not cancelled with the context, the agent is asked to stop and still sends the summary
+	resp, err := c.client.Post(c.agents[i]+RunPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	finished := make(chan struct{})
+	defer close(finished)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.stopAgent(i)
+		case <-finished:
+		}
+	}()
+
+	scanner := bufio.NewScanner(resp.Body)
+	// The summary line holds the t-digests and can hold all the requests stats
+	scanner.Buffer(make([]byte, 64*1024), 1<<30)
+
+	for scanner.Scan() {
+		var m Message
+		err := json.Unmarshal(scanner.Bytes(), &m)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode the agent message: %w", err)
+		}
+
+		switch m.Type {
+		case MessageProgress:
+			if c.OnProgress != nil && m.Progress != nil {
+				c.OnProgress(i, m.Progress)
+			}
+		case MessageSummary:
+			if m.Summary == nil {
+				return nil, errors.New("no summary in the agent message")
+			}
+
+			return m.Summary, nil
+		case MessageError:
+			return nil, errors.New(m.Error)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, errors.New("the agent finished without the summary")
+}
+
+func (c *Controller) stopAgent(i int) {
+	resp, err := c.client.Post(c.agents[i]+StopPath, "application/json", nil)
+	if err != nil {
+		return
+	}
+
+	resp.Body.Close()
+}
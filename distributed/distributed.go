@@ -0,0 +1,42 @@
+// Package distributed runs the benchmark on many hload agents at once.
+// The controller splits the loader between the agents, every agent streams its progress
+// and finally its summary back and the controller merges the summaries into one.
+package distributed
+
+import (
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// DefaultPort is the agent port used when the agent address has no port
+const DefaultPort = "9180"
+
+// The agent API paths
+const (
+	RunPath  = "/run"
+	StopPath = "/stop"
+)
+
+// The types of the messages streamed by the agent
+const (
+	MessageProgress = "progress"
+	MessageSummary  = "summary"
+	MessageError    = "error"
+)
+
+// Message is one line of the JSON lines stream the agent sends while it runs the benchmark
+type Message struct {
+	Type string `json:"type"`
+
+	Progress *Progress      `json:"progress,omitempty"`
+	Summary  *model.Summary `json:"summary,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// Progress are the agent benchmark counters sent every second
+type Progress struct {
+	Elapsed  time.Duration `json:"elapsed"`
+	Requests int           `json:"requests"`
+	Failed   int           `json:"failed"`
+}
@@ -0,0 +1,168 @@
+package distributed
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmwalaszek/hload/mock"
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	conf := &model.Loader{
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    1001,
+			Connections: 10,
+			RateLimit:   100,
+		},
+	}
+
+	confs, err := Split(conf, 3)
+	require.Nil(t, err)
+	require.Len(t, confs, 3)
+
+	var connections, rateLimit, reqCount int
+	for _, c := range confs {
+		connections += c.Connections
+		rateLimit += c.RateLimit
+		reqCount += c.ReqCount
+	}
+
+	require.Equal(t, []int{4, 3, 3}, []int{confs[0].Connections, confs[1].Connections, confs[2].Connections})
+	require.Equal(t, 10, connections)
+	require.Equal(t, 100, rateLimit)
+	require.Equal(t, 1001, reqCount)
+	require.Equal(t, 10, conf.Connections)
+
+	_, err = Split(conf, 11)
+	require.NotNil(t, err)
+}
+
+func TestAgentURL(t *testing.T) {
+	tt := []struct {
+		Agent string
+		URL   string
+	}{
+		{"host1", "http://host1:" + DefaultPort},
+		{"host1:8000", "http://host1:8000"},
+		{"https://host1:8000/", "https://host1:8000"},
+	}
+
+	for _, tc := range tt {
+		u, err := agentURL(tc.Agent)
+		require.Nil(t, err)
+		require.Equal(t, tc.URL, u)
+	}
+}
+
+func newAgents(t *testing.T, n int) []string {
+	var agents []string
+	for i := 0; i < n; i++ {
+		ts := httptest.NewServer(NewAgent().Handler())
+		t.Cleanup(ts.Close)
+
+		agents = append(agents, strings.TrimPrefix(ts.URL, "http://"))
+	}
+
+	return agents
+}
+
+func TestController(t *testing.T) {
+	handler, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	u, err := url.JoinPath(ts.URL, "ok")
+	require.Nil(t, err)
+
+	controller, err := NewController(newAgents(t, 3))
+	require.Nil(t, err)
+
+	conf := &model.Loader{
+		URL:                          u,
+		Method:                       "GET",
+		HTTPEngine:                   "fast_http",
+		AggregateWindow:              time.Second,
+		GatherAggregateRequestsStats: true,
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    500,
+			Connections: 6,
+		},
+	}
+
+	summary, err := controller.Run(context.Background(), conf)
+	require.Nil(t, err)
+
+	require.Equal(t, u, summary.URL)
+	require.Equal(t, 500, summary.ReqCount)
+	require.Equal(t, 500, summary.SuccessReq)
+	require.Equal(t, map[int]int{200: 500}, summary.HTTPCodes)
+	require.Equal(t, uint64(500), handler.Stats.RequestCount)
+	require.NotZero(t, summary.P99ReqTime)
+	require.GreaterOrEqual(t, summary.P99ReqTime, summary.P50ReqTime)
+
+	var windowsCount int
+	for _, a := range summary.AggregatedStats {
+		windowsCount += a.RequestCount
+	}
+	require.Equal(t, 500, windowsCount)
+}
+
+func TestControllerStop(t *testing.T) {
+	_, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	u, err := url.JoinPath(ts.URL, "ok")
+	require.Nil(t, err)
+
+	agents := newAgents(t, 2)
+	controller, err := NewController(agents)
+	require.Nil(t, err)
+
+	var progress [2]int
+	controller.OnProgress = func(agent int, p *Progress) {
+		progress[agent]++
+	}
+
+	conf := &model.Loader{
+		URL:        u,
+		Method:     "GET",
+		HTTPEngine: "http",
+		LoaderReqDetails: model.LoaderReqDetails{
+			Duration:    time.Minute,
+			Connections: 2,
+			RateLimit:   100,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	summary, err := controller.Run(ctx, conf)
+	require.Nil(t, err)
+
+	require.Less(t, time.Since(start), 10*time.Second)
+	require.NotZero(t, summary.ReqCount)
+	require.Equal(t, summary.ReqCount, summary.HTTPCodes[200])
+	require.NotZero(t, progress[0])
+	require.NotZero(t, progress[1])
+
+	// The agent runs one benchmark at a time
+	busy := NewAgent()
+	busy.cancel = func() {}
+	busyServer := httptest.NewServer(busy.Handler())
+	defer busyServer.Close()
+
+	controller, err = NewController([]string{strings.TrimPrefix(busyServer.URL, "http://")})
+	require.Nil(t, err)
+
+	conf.Connections = 1
+	_, err = controller.Run(context.Background(), conf)
+	require.ErrorContains(t, err, "already running")
+}
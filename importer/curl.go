@@ -0,0 +1,428 @@
+package importer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// curlShortOptions maps the curl short options to the long ones
+var curlShortOptions = map[byte]string{
+	'X': "request",
+	'H': "header",
+	'd': "data",
+	'b': "cookie",
+	'u': "user",
+	'A': "user-agent",
+	'e': "referer",
+	'o': "output",
+	'm': "max-time",
+	'w': "write-out",
+	'k': "insecure",
+	'I': "head",
+	'G': "get",
+	's': "silent",
+	'S': "show-error",
+	'v': "verbose",
+	'i': "include",
+	'L': "location",
+	'f': "fail",
+}
+
+// curlValueOptions are the long options followed by the value
+var curlValueOptions = map[string]bool{
+	"request":         true,
+	"header":          true,
+	"data":            true,
+	"data-ascii":      true,
+	"data-binary":     true,
+	"data-raw":        true,
+	"data-urlencode":  true,
+	"cookie":          true,
+	"cacert":          true,
+	"cert":            true,
+	"key":             true,
+	"user":            true,
+	"user-agent":      true,
+	"referer":         true,
+	"url":             true,
+	"output":          true,
+	"max-time":        true,
+	"connect-timeout": true,
+	"write-out":       true,
+}
+
+// curlIgnoredOptions change only how curl itself works and have no loader counterpart
+var curlIgnoredOptions = map[string]bool{
+	"output":          true,
+	"max-time":        true,
+	"connect-timeout": true,
+	"write-out":       true,
+	"compressed":      true,
+	"silent":          true,
+	"show-error":      true,
+	"verbose":         true,
+	"include":         true,
+	"location":        true,
+	"fail":            true,
+	"http1.1":         true,
+	"http2":           true,
+}
+
+type curlRequest struct {
+	loader *model.Loader
+	data   []string
+	get    bool
+	head   bool
+}
+
+// Curl parses the curl command into the loader configuration.
+// Supported options are -X, -H, -d (also --data-raw, --data-binary and --data-urlencode, @file reads the file),
+// -b, -k, --cacert, --cert, --key, -u, -A, -e, -G and -I, options which change only the curl output are ignored.
+func Curl(command string) (*model.Loader, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+
+	c := &curlRequest{
+		loader: &model.Loader{
+			Headers: make(model.Headers),
+		},
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		var options []string
+		var value string
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			options = []string{arg[2:]}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Short options can be joined (-sk) and the value can follow the option (-XPOST)
+			for j := 1; j < len(arg); j++ {
+				option, ok := curlShortOptions[arg[j]]
+				if !ok {
+					return nil, fmt.Errorf("unsupported curl option -%c", arg[j])
+				}
+
+				options = append(options, option)
+				if curlValueOptions[option] {
+					value = arg[j+1:]
+					break
+				}
+			}
+		default:
+			if c.loader.URL != "" {
+				return nil, fmt.Errorf("unexpected curl argument %s", arg)
+			}
+
+			c.loader.URL = arg
+			continue
+		}
+
+		for _, option := range options {
+			if curlValueOptions[option] && value == "" {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("curl option %s needs a value", option)
+				}
+
+				i++
+				value = args[i]
+			}
+
+			err = c.apply(option, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c.build()
+}
+
+func (c *curlRequest) apply(option, value string) error {
+	l := c.loader
+
+	switch option {
+	case "request":
+		l.Method = strings.ToUpper(value)
+	case "header":
+		return l.Headers.Set(value)
+	case "data", "data-ascii":
+		if file, ok := strings.CutPrefix(value, "@"); ok {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			// curl strips the new lines from the file given to --data
+			value = strings.NewReplacer("\r", "", "\n", "").Replace(string(b))
+		}
+
+		c.data = append(c.data, value)
+	case "data-binary":
+		if file, ok := strings.CutPrefix(value, "@"); ok {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			value = string(b)
+		}
+
+		c.data = append(c.data, value)
+	case "data-raw":
+		c.data = append(c.data, value)
+	case "data-urlencode":
+		if name, content, ok := strings.Cut(value, "="); ok {
+			value = name + "=" + url.QueryEscape(content)
+		} else {
+			value = url.QueryEscape(value)
+		}
+
+		c.data = append(c.data, value)
+	case "cookie":
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("curl cookie file %s is not supported", value)
+		}
+
+		return l.Headers.Set("Cookie: " + value)
+	case "user":
+		return l.Headers.Set("Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(value)))
+	case "user-agent":
+		return l.Headers.Set("User-Agent: " + value)
+	case "referer":
+		return l.Headers.Set("Referer: " + value)
+	case "cacert", "cert", "key":
+		b, err := os.ReadFile(value)
+		if err != nil {
+			return err
+		}
+
+		switch option {
+		case "cacert":
+			l.CA = b
+		case "cert":
+			l.Cert = b
+		default:
+			l.Key = b
+		}
+	case "url":
+		l.URL = value
+	case "insecure":
+		l.SkipVerify = true
+	case "head":
+		c.head = true
+	case "get":
+		c.get = true
+	default:
+		if !curlIgnoredOptions[option] {
+			return fmt.Errorf("unsupported curl option --%s", option)
+		}
+	}
+
+	return nil
+}
+
+func (c *curlRequest) build() (*model.Loader, error) {
+	l := c.loader
+
+	if l.URL == "" {
+		return nil, errors.New("curl command has no url")
+	}
+
+	if !strings.Contains(l.URL, "://") {
+		l.URL = "http://" + l.URL
+	}
+
+	u, err := url.Parse(l.URL)
+	if err != nil {
+		return nil, fmt.Errorf("wrong url: %w", err)
+	}
+
+	data := strings.Join(c.data, "&")
+	switch {
+	case c.get && data != "":
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+
+		u.RawQuery += data
+		l.URL = u.String()
+	case data != "":
+		l.Body = []byte(data)
+
+		// curl sends the data as the form unless the content type is given
+		if _, ok := l.Headers["Content-Type"]; !ok {
+			l.Headers["Content-Type"] = []string{"application/x-www-form-urlencoded"}
+		}
+	}
+
+	if l.Method == "" {
+		switch {
+		case c.head:
+			l.Method = http.MethodHead
+		case len(l.Body) > 0:
+			l.Method = http.MethodPost
+		default:
+			l.Method = http.MethodGet
+		}
+	}
+
+	if len(l.Headers) == 0 {
+		l.Headers = nil
+	}
+
+	l.Name = requestName(l.Method, u)
+	l.Description = "Imported curl command"
+
+	return l, nil
+}
+
+// CurlCommand returns the curl command sending the loader request.
+// Only the first parameters set is used and the TLS certificates are not included as they are stored without the file names.
+func CurlCommand(l *model.Loader) string {
+	u := l.URL
+	var data string
+	if len(l.Parameters) > 0 {
+		parameters := make(url.Values)
+		for key, value := range l.Parameters[0] {
+			parameters.Set(key, value)
+		}
+
+		if l.Method != http.MethodGet && len(l.Body) == 0 {
+			data = parameters.Encode()
+		} else {
+			separator := "?"
+			if strings.Contains(u, "?") {
+				separator = "&"
+			}
+
+			u += separator + parameters.Encode()
+		}
+	}
+
+	args := []string{"curl"}
+	switch {
+	case l.Method == http.MethodHead:
+		// curl -X HEAD waits for the body announced by the Content-Length
+		args = append(args, "-I")
+	case l.Method != "" && !(l.Method == http.MethodGet || (l.Method == http.MethodPost && (len(l.Body) > 0 || data != ""))):
+		args = append(args, "-X", l.Method)
+	}
+
+	args = append(args, shellQuote(u))
+
+	keys := make([]string, 0, len(l.Headers))
+	for key := range l.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range l.Headers[key] {
+			args = append(args, "-H", shellQuote(key+": "+value))
+		}
+	}
+
+	switch {
+	case len(l.Body) > 0:
+		args = append(args, "--data-binary", shellQuote(string(l.Body)))
+	case data != "":
+		args = append(args, "-d", shellQuote(data))
+	}
+
+	if l.SkipVerify {
+		args = append(args, "-k")
+	}
+
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes the argument for the POSIX shell when it has special characters
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:,=@%+") == "" {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// splitCommand splits the shell command into arguments handling quotes, escapes and line continuations
+func splitCommand(s string) ([]string, error) {
+	var args []string
+	var b strings.Builder
+	var inArg bool
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+
+			if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+				continue
+			}
+
+			b.WriteByte(s[i])
+			inArg = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end == -1 {
+				return nil, errors.New("curl command has unclosed quote")
+			}
+
+			b.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) != -1 {
+					i++
+				}
+
+				b.WriteByte(s[i])
+			}
+
+			if i >= len(s) {
+				return nil, errors.New("curl command has unclosed quote")
+			}
+
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			b.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, b.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurl(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Command string
+		Loader  *model.Loader
+		Err     string
+	}{
+		{
+			Name:    "get",
+			Command: "curl https://api.example.com/items?page=2",
+			Loader: &model.Loader{
+				Name:   "GET api.example.com/items ?01698319",
+				URL:    "https://api.example.com/items?page=2",
+				Method: "GET",
+			},
+		},
+		{
+			Name: "post with headers",
+			Command: `curl -X PUT 'https://api.example.com/items/1' \
+  -H 'Content-Type: application/json' -H "Accept: */*" \
+  --data-raw '{"name":"it'\''s"}' -sk`,
+			Loader: &model.Loader{
+				Name:   "PUT api.example.com/items/1",
+				URL:    "https://api.example.com/items/1",
+				Method: "PUT",
+				Headers: model.Headers{
+					"Content-Type": {"application/json"},
+					"Accept":       {"*/*"},
+				},
+				Body:       []byte(`{"name":"it's"}`),
+				SkipVerify: true,
+			},
+		},
+		{
+			Name:    "form data and auth",
+			Command: "curl localhost:8080/login -d user=test -d pass=secret -u admin:admin -b 'session=abc'",
+			Loader: &model.Loader{
+				Name:   "POST localhost:8080/login",
+				URL:    "http://localhost:8080/login",
+				Method: "POST",
+				Headers: model.Headers{
+					"Content-Type":  {"application/x-www-form-urlencoded"},
+					"Authorization": {"Basic YWRtaW46YWRtaW4="},
+					"Cookie":        {"session=abc"},
+				},
+				Body: []byte("user=test&pass=secret"),
+			},
+		},
+		{
+			Name:    "data files",
+			Command: "curl -XPOST --url http://localhost/a --data-binary @testdata/body.json",
+			Loader: &model.Loader{
+				Name:   "POST localhost/a",
+				URL:    "http://localhost/a",
+				Method: "POST",
+				Headers: model.Headers{
+					"Content-Type": {"application/x-www-form-urlencoded"},
+				},
+				Body: []byte("{\"item\":\n42}\n"),
+			},
+		},
+		{
+			Name:    "data in query",
+			Command: "curl -G http://localhost/search?a=1 -d q=shoes",
+			Loader: &model.Loader{
+				Name:   "GET localhost/search ?90444012",
+				URL:    "http://localhost/search?a=1&q=shoes",
+				Method: "GET",
+			},
+		},
+		{
+			Name:    "no url",
+			Command: "curl -X GET",
+			Err:     "curl command has no url",
+		},
+		{
+			Name:    "unsupported option",
+			Command: "curl -F file=@a.txt http://localhost",
+			Err:     "unsupported curl option -F",
+		},
+		{
+			Name:    "unclosed quote",
+			Command: "curl 'http://localhost",
+			Err:     "curl command has unclosed quote",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			l, err := Curl(tc.Command)
+			if tc.Err != "" {
+				require.ErrorContains(t, err, tc.Err)
+				return
+			}
+
+			require.Nil(t, err)
+			tc.Loader.Description = "Imported curl command"
+			require.Equal(t, tc.Loader, l)
+		})
+	}
+
+	l, err := Curl("curl -d @testdata/body.json http://localhost/")
+	require.Nil(t, err)
+	require.Equal(t, []byte(`{"item":42}`), l.Body)
+}
+
+func TestCurlCommand(t *testing.T) {
+	l := &model.Loader{
+		URL:    "https://api.example.com/items",
+		Method: "DELETE",
+		Headers: model.Headers{
+			"X-Token": {"a b"},
+			"Accept":  {"application/json"},
+		},
+		Parameters: model.Parameters{{"id": "1"}},
+		Body:       []byte(`it's`),
+		SkipVerify: true,
+	}
+
+	command := CurlCommand(l)
+	require.Equal(t, `curl -X DELETE 'https://api.example.com/items?id=1' -H 'Accept: application/json' -H 'X-Token: a b' --data-binary 'it'\''s' -k`, command)
+
+	parsed, err := Curl(command)
+	require.Nil(t, err)
+	require.Equal(t, "https://api.example.com/items?id=1", parsed.URL)
+	require.Equal(t, l.Method, parsed.Method)
+	require.Equal(t, l.Body, parsed.Body)
+	require.True(t, parsed.SkipVerify)
+
+	l = &model.Loader{
+		URL:        "http://localhost/login",
+		Method:     "POST",
+		Parameters: model.Parameters{{"user": "test"}},
+	}
+	require.Equal(t, "curl http://localhost/login -d user=test", CurlCommand(l))
+
+	l = &model.Loader{
+		URL:     "http://localhost/health",
+		Method:  "HEAD",
+		Headers: model.Headers{"Accept": {"*/*"}},
+	}
+
+	command = CurlCommand(l)
+	require.Equal(t, "curl -I http://localhost/health -H 'Accept: */*'", command)
+
+	parsed, err = Curl(command)
+	require.Nil(t, err)
+	require.Equal(t, l.URL, parsed.URL)
+	require.Equal(t, l.Method, parsed.Method)
+	require.Equal(t, l.Headers, parsed.Headers)
+}
@@ -0,0 +1,239 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// DefaultDropHeaders are the headers which change with every request or are set by the HTTP client.
+// The name ending with * matches all headers with the prefix.
+var DefaultDropHeaders = []string{
+	"Content-Length",
+	"Host",
+	"Connection",
+	"Accept-Encoding",
+	"If-None-Match",
+	"If-Modified-Since",
+	"Sec-Ch-Ua*",
+	"Sec-Fetch-*",
+	"Traceparent",
+	"X-Request-Id",
+}
+
+// HAROptions filters the imported entries and headers
+type HAROptions struct {
+	// Hosts keeps only the entries sent to one of the hosts, all when empty
+	Hosts []string
+	// URLPattern keeps only the entries with the matching URL, all when nil
+	URLPattern *regexp.Regexp
+	// DropHeaders are not imported, see DefaultDropHeaders
+	DropHeaders []string
+}
+
+type har struct {
+	Log struct {
+		Entries []struct {
+			Request harRequest `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type harRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Headers  []harNameValue `json:"headers"`
+	Cookies  []harNameValue `json:"cookies"`
+	PostData *struct {
+		Text   string         `json:"text"`
+		Params []harNameValue `json:"params"`
+	} `json:"postData"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HAR reads the HAR capture and returns the loader configuration for every entry that passes the filters.
+// The query string of the GET requests is imported as the loader parameters.
+// Loader names are unique, the repeated requests get the occurrence number.
+func HAR(r io.Reader, opts HAROptions) ([]*model.Loader, error) {
+	var capture har
+	err := json.NewDecoder(r).Decode(&capture)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HAR: %w", err)
+	}
+
+	loaders := make([]*model.Loader, 0)
+	names := make(map[string]int)
+	for i, entry := range capture.Log.Entries {
+		req := entry.Request
+
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d has wrong url: %w", i, err)
+		}
+
+		if u.Scheme != "http" && u.Scheme != "https" {
+			continue
+		}
+
+		if !matchHost(u, opts.Hosts) {
+			continue
+		}
+
+		if opts.URLPattern != nil && !opts.URLPattern.MatchString(req.URL) {
+			continue
+		}
+
+		l := harLoader(i, req, u, opts.DropHeaders)
+
+		names[l.Name]++
+		if names[l.Name] > 1 {
+			l.Name = fmt.Sprintf("%s #%d", l.Name, names[l.Name])
+		}
+
+		loaders = append(loaders, l)
+	}
+
+	return loaders, nil
+}
+
+func harLoader(i int, req harRequest, u *url.URL, dropHeaders []string) *model.Loader {
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	l := &model.Loader{
+		Name:        requestName(method, u),
+		Description: fmt.Sprintf("Imported HAR entry %d", i),
+		Method:      method,
+		Headers:     make(model.Headers),
+	}
+
+	var cookieHeader bool
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Name, "Cookie") {
+			cookieHeader = true
+		}
+
+		// HTTP/2 pseudo headers like :authority are not real headers
+		if strings.HasPrefix(h.Name, ":") || dropHeader(h.Name, dropHeaders) {
+			continue
+		}
+
+		name := http.CanonicalHeaderKey(h.Name)
+		l.Headers[name] = append(l.Headers[name], h.Value)
+	}
+
+	// Cookies are sent in the Cookie header, the list is used only when the capture has no such header
+	if !cookieHeader && len(req.Cookies) > 0 && !dropHeader("Cookie", dropHeaders) {
+		cookies := make([]string, len(req.Cookies))
+		for j, c := range req.Cookies {
+			cookies[j] = c.Name + "=" + c.Value
+		}
+
+		l.Headers["Cookie"] = []string{strings.Join(cookies, "; ")}
+	}
+
+	if len(l.Headers) == 0 {
+		l.Headers = nil
+	}
+
+	// The query string becomes the parameters set when it can be one, otherwise it stays in the URL
+	query := u.Query()
+	if method == http.MethodGet && len(query) > 0 && parametersSet(query) {
+		parameters := make(map[string]string, len(query))
+		for key, values := range query {
+			parameters[key] = values[0]
+		}
+
+		l.Parameters = model.Parameters{parameters}
+		u.RawQuery = ""
+	}
+
+	l.URL = u.String()
+
+	if req.PostData != nil {
+		switch {
+		case req.PostData.Text != "":
+			l.Body = []byte(req.PostData.Text)
+		case len(req.PostData.Params) > 0:
+			form := make(url.Values)
+			for _, p := range req.PostData.Params {
+				form.Add(p.Name, p.Value)
+			}
+
+			l.Body = []byte(form.Encode())
+		}
+	}
+
+	return l
+}
+
+func matchHost(u *url.URL, hosts []string) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+
+	for _, host := range hosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func dropHeader(name string, dropHeaders []string) bool {
+	for _, drop := range dropHeaders {
+		if prefix, ok := strings.CutSuffix(drop, "*"); ok {
+			if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.EqualFold(name, drop) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parametersSet tells whether the query fits into the parameters set: no parameter is repeated
+// and the names and values can be stored in the key=value&key=value format
+func parametersSet(query url.Values) bool {
+	for key, values := range query {
+		if len(values) > 1 || strings.ContainsAny(key, "=&") || strings.Contains(values[0], "&") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestName is the imported loader name, the query string is kept as its short hash
+// so the requests differing only in the query get different names
+func requestName(method string, u *url.URL) string {
+	name := fmt.Sprintf("%s %s%s", method, u.Host, u.Path)
+	if u.RawQuery == "" {
+		return name
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(u.RawQuery))
+
+	return fmt.Sprintf("%s ?%08x", name, h.Sum32())
+}
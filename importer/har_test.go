@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHAR(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Options HAROptions
+		URLs    []string
+	}{
+		{
+			Name:    "all entries",
+			Options: HAROptions{DropHeaders: DefaultDropHeaders},
+			URLs: []string{
+				"https://shop.example.com/api/items",
+				"https://shop.example.com/api/cart?session=1",
+				"https://auth.example.com/login",
+				"https://cdn.example.com/static/app.js?v=1&v=2",
+			},
+		},
+		{
+			Name:    "filter by host",
+			Options: HAROptions{Hosts: []string{"shop.example.com"}},
+			URLs: []string{
+				"https://shop.example.com/api/items",
+				"https://shop.example.com/api/cart?session=1",
+			},
+		},
+		{
+			Name:    "filter by url pattern",
+			Options: HAROptions{URLPattern: regexp.MustCompile(`/api/`)},
+			URLs: []string{
+				"https://shop.example.com/api/items",
+				"https://shop.example.com/api/cart?session=1",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			f, err := os.Open("testdata/capture.har")
+			require.Nil(t, err)
+			defer f.Close()
+
+			loaders, err := HAR(f, tc.Options)
+			require.Nil(t, err)
+
+			urls := make([]string, len(loaders))
+			for i, l := range loaders {
+				urls[i] = l.URL
+			}
+			require.Equal(t, tc.URLs, urls)
+		})
+	}
+
+	f, err := os.Open("testdata/capture.har")
+	require.Nil(t, err)
+	defer f.Close()
+
+	loaders, err := HAR(f, HAROptions{DropHeaders: DefaultDropHeaders})
+	require.Nil(t, err)
+
+	items, cart, login := loaders[0], loaders[1], loaders[2]
+
+	require.Equal(t, "GET", items.Method)
+	require.Equal(t, "GET shop.example.com/api/items ?1030bf91", items.Name)
+	require.Equal(t, model.Parameters{{"q": "shoes", "page": "2"}}, items.Parameters)
+	require.Equal(t, model.Headers{
+		"Accept": {"application/json, text/plain"},
+		"Cookie": {"session=abc; theme=dark"},
+	}, items.Headers)
+
+	require.Equal(t, "POST", cart.Method)
+	require.Nil(t, cart.Parameters)
+	require.Equal(t, []byte(`{"item":42,"quantity":1}`), cart.Body)
+	require.Equal(t, model.Headers{
+		"Content-Type": {"application/json"},
+		"Cookie":       {"session=abc"},
+	}, cart.Headers)
+
+	require.Equal(t, []byte("password=secret&user=test"), login.Body)
+
+	// Repeated parameters stay in the URL
+	require.Nil(t, loaders[3].Parameters)
+	require.Nil(t, loaders[3].Headers)
+
+	_, err = HAR(f, HAROptions{})
+	require.NotNil(t, err)
+}
+
+func TestHARNames(t *testing.T) {
+	capture := `{"log": {"entries": [
+		{"request": {"method": "GET", "url": "https://api.example.com/items?page=1"}},
+		{"request": {"method": "GET", "url": "https://api.example.com/items?page=2"}},
+		{"request": {"method": "GET", "url": "https://api.example.com/items?page=1"}},
+		{"request": {"method": "GET", "url": "https://api.example.com/items"}}
+	]}}`
+
+	loaders, err := HAR(strings.NewReader(capture), HAROptions{})
+	require.Nil(t, err)
+
+	names := make([]string, len(loaders))
+	for i, l := range loaders {
+		names[i] = l.Name
+	}
+
+	require.Equal(t, []string{
+		"GET api.example.com/items ?fe697e60",
+		"GET api.example.com/items ?01698319",
+		"GET api.example.com/items ?fe697e60 #2",
+		"GET api.example.com/items",
+	}, names)
+}
@@ -0,0 +1,522 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMaxDepth stops the synthesis of the recursive schemas
+const openAPIMaxDepth = 8
+
+// OpenAPIOptions changes how the operations are imported
+type OpenAPIOptions struct {
+	// Server is the base URL used instead of the first server of the specification
+	Server string
+}
+
+// OpenAPIOperation is the loader configuration of the single API operation with its tags
+type OpenAPIOperation struct {
+	Loader *model.Loader
+	Tags   []*model.LoaderTag
+}
+
+type openAPISpec struct {
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Paths      map[string]*openAPIPathItem `yaml:"paths"`
+	Components struct {
+		Schemas       map[string]*openAPISchema      `yaml:"schemas"`
+		Parameters    map[string]*openAPIParameter   `yaml:"parameters"`
+		RequestBodies map[string]*openAPIRequestBody `yaml:"requestBodies"`
+	} `yaml:"components"`
+}
+
+type openAPIPathItem struct {
+	Parameters []*openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation   `yaml:"get"`
+	Put        *openAPIOperation   `yaml:"put"`
+	Post       *openAPIOperation   `yaml:"post"`
+	Delete     *openAPIOperation   `yaml:"delete"`
+	Options    *openAPIOperation   `yaml:"options"`
+	Head       *openAPIOperation   `yaml:"head"`
+	Patch      *openAPIOperation   `yaml:"patch"`
+}
+
+type openAPIOperation struct {
+	OperationID string              `yaml:"operationId"`
+	Summary     string              `yaml:"summary"`
+	Tags        []string            `yaml:"tags"`
+	Parameters  []*openAPIParameter `yaml:"parameters"`
+	RequestBody *openAPIRequestBody `yaml:"requestBody"`
+}
+
+type openAPIParameter struct {
+	Ref      string         `yaml:"$ref"`
+	Name     string         `yaml:"name"`
+	In       string         `yaml:"in"`
+	Required bool           `yaml:"required"`
+	Example  any            `yaml:"example"`
+	Schema   *openAPISchema `yaml:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string `yaml:"$ref"`
+	Content map[string]struct {
+		Schema   *openAPISchema `yaml:"schema"`
+		Example  any            `yaml:"example"`
+		Examples map[string]struct {
+			Value any `yaml:"value"`
+		} `yaml:"examples"`
+	} `yaml:"content"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `yaml:"$ref"`
+	Type       string                    `yaml:"type"`
+	Format     string                    `yaml:"format"`
+	Example    any                       `yaml:"example"`
+	Default    any                       `yaml:"default"`
+	Enum       []any                     `yaml:"enum"`
+	Minimum    *float64                  `yaml:"minimum"`
+	Properties map[string]*openAPISchema `yaml:"properties"`
+	Items      *openAPISchema            `yaml:"items"`
+	AllOf      []*openAPISchema          `yaml:"allOf"`
+	OneOf      []*openAPISchema          `yaml:"oneOf"`
+	AnyOf      []*openAPISchema          `yaml:"anyOf"`
+}
+
+// OpenAPI reads the OpenAPI 3 specification in YAML or JSON and returns the loader configuration for every operation.
+// Path, query, header and cookie parameters and the request body are synthesized from the schemas examples or defaults.
+// Every loader is tagged with the specification title (openapi), the operationId and the first API tag (tag).
+// Loader names are the operationId, or the method and path without it, prefixed with the specification title
+// as the names are unique across all loaders.
+func OpenAPI(r io.Reader, opts OpenAPIOptions) ([]*OpenAPIOperation, error) {
+	var spec openAPISpec
+	err := yaml.NewDecoder(r).Decode(&spec)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse OpenAPI specification: %w", err)
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, only OpenAPI 3 is supported", spec.OpenAPI)
+	}
+
+	server := strings.TrimSuffix(opts.Server, "/")
+	if server == "" {
+		if len(spec.Servers) == 0 {
+			return nil, errors.New("the specification has no servers, the server has to be set")
+		}
+
+		server = spec.Servers[0].URL
+		for name, variable := range spec.Servers[0].Variables {
+			server = strings.ReplaceAll(server, "{"+name+"}", variable.Default)
+		}
+
+		server = strings.TrimSuffix(server, "/")
+		if !strings.Contains(server, "://") {
+			return nil, fmt.Errorf("the specification server %s is relative, the server has to be set", server)
+		}
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	operations := make([]*OpenAPIOperation, 0)
+	for _, path := range paths {
+		item := spec.Paths[path]
+		if item == nil {
+			continue
+		}
+
+		for _, method := range []struct {
+			name      string
+			operation *openAPIOperation
+		}{
+			{http.MethodGet, item.Get},
+			{http.MethodPost, item.Post},
+			{http.MethodPut, item.Put},
+			{http.MethodPatch, item.Patch},
+			{http.MethodDelete, item.Delete},
+			{http.MethodHead, item.Head},
+			{http.MethodOptions, item.Options},
+		} {
+			if method.operation == nil {
+				continue
+			}
+
+			operation, err := spec.operation(server, path, method.name, item, method.operation)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method.name, path, err)
+			}
+
+			operations = append(operations, operation)
+		}
+	}
+
+	return operations, nil
+}
+
+func (s *openAPISpec) operation(server, path, method string, item *openAPIPathItem, op *openAPIOperation) (*OpenAPIOperation, error) {
+	l := &model.Loader{
+		Name:        op.OperationID,
+		Description: op.Summary,
+		Method:      method,
+		Headers:     make(model.Headers),
+	}
+
+	if l.Name == "" {
+		l.Name = fmt.Sprintf("%s %s", method, path)
+	}
+
+	if s.Info.Title != "" {
+		l.Name = s.Info.Title + " " + l.Name
+	}
+
+	if l.Description == "" {
+		l.Description = "Imported OpenAPI operation"
+	}
+
+	// The operation parameters override the path item ones with the same name and location
+	parameters := make(map[string]*openAPIParameter)
+	var order []string
+	for _, p := range append(append([]*openAPIParameter{}, item.Parameters...), op.Parameters...) {
+		p, err := s.parameter(p)
+		if err != nil {
+			return nil, err
+		}
+
+		key := p.In + ":" + p.Name
+		if _, ok := parameters[key]; !ok {
+			order = append(order, key)
+		}
+		parameters[key] = p
+	}
+
+	query := make(map[string]string)
+	var cookies []string
+	for _, key := range order {
+		p := parameters[key]
+
+		value, ok := s.parameterValue(p)
+		if !ok {
+			continue
+		}
+
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(value))
+		case "query":
+			query[p.Name] = value
+		case "header":
+			l.Headers[http.CanonicalHeaderKey(p.Name)] = []string{value}
+		case "cookie":
+			cookies = append(cookies, p.Name+"="+value)
+		}
+	}
+
+	if len(cookies) > 0 {
+		l.Headers["Cookie"] = []string{strings.Join(cookies, "; ")}
+	}
+
+	l.URL = server + path
+	if len(query) > 0 {
+		// GET query is stored as the parameters set like the imported HAR entries
+		if method == http.MethodGet {
+			l.Parameters = model.Parameters{query}
+		} else {
+			values := make(url.Values)
+			for key, value := range query {
+				values.Set(key, value)
+			}
+
+			l.URL += "?" + values.Encode()
+		}
+	}
+
+	if op.RequestBody != nil {
+		contentType, body, err := s.requestBody(op.RequestBody)
+		if err != nil {
+			return nil, err
+		}
+
+		if contentType != "" {
+			l.Headers["Content-Type"] = []string{contentType}
+			l.Body = body
+		}
+	}
+
+	if len(l.Headers) == 0 {
+		l.Headers = nil
+	}
+
+	var tags []*model.LoaderTag
+	if s.Info.Title != "" {
+		tags = append(tags, &model.LoaderTag{Key: "openapi", Value: s.Info.Title})
+	}
+
+	if op.OperationID != "" {
+		tags = append(tags, &model.LoaderTag{Key: "operationId", Value: op.OperationID})
+	}
+
+	if len(op.Tags) > 0 {
+		tags = append(tags, &model.LoaderTag{Key: "tag", Value: op.Tags[0]})
+	}
+
+	return &OpenAPIOperation{
+		Loader: l,
+		Tags:   tags,
+	}, nil
+}
+
+func (s *openAPISpec) parameter(p *openAPIParameter) (*openAPIParameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+
+	name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+	if !ok || s.Components.Parameters[name] == nil {
+		return nil, fmt.Errorf("unresolved parameter reference %s", p.Ref)
+	}
+
+	return s.Components.Parameters[name], nil
+}
+
+// parameterValue returns the parameter value, optional parameters are set only when they have the example or default
+func (s *openAPISpec) parameterValue(p *openAPIParameter) (string, bool) {
+	value := p.Example
+	if value == nil && p.Schema != nil {
+		schema := s.schema(p.Schema)
+		switch {
+		case schema.Example != nil:
+			value = schema.Example
+		case schema.Default != nil:
+			value = schema.Default
+		case len(schema.Enum) > 0:
+			value = schema.Enum[0]
+		case p.Required || p.In == "path":
+			value = s.sample(schema, 0)
+		}
+	}
+
+	if value == nil {
+		return "", false
+	}
+
+	if values, ok := value.([]any); ok {
+		items := make([]string, len(values))
+		for i, v := range values {
+			items[i] = fmt.Sprint(v)
+		}
+
+		return strings.Join(items, ","), true
+	}
+
+	return fmt.Sprint(value), true
+}
+
+// requestBody returns the content type and the body, JSON content is preferred
+func (s *openAPISpec) requestBody(rb *openAPIRequestBody) (string, []byte, error) {
+	if rb.Ref != "" {
+		name, ok := strings.CutPrefix(rb.Ref, "#/components/requestBodies/")
+		if !ok || s.Components.RequestBodies[name] == nil {
+			return "", nil, fmt.Errorf("unresolved request body reference %s", rb.Ref)
+		}
+
+		rb = s.Components.RequestBodies[name]
+	}
+
+	contentTypes := make([]string, 0, len(rb.Content))
+	for contentType := range rb.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Slice(contentTypes, func(i, j int) bool {
+		iJSON, jJSON := strings.Contains(contentTypes[i], "json"), strings.Contains(contentTypes[j], "json")
+		if iJSON != jJSON {
+			return iJSON
+		}
+
+		return contentTypes[i] < contentTypes[j]
+	})
+
+	if len(contentTypes) == 0 {
+		return "", nil, nil
+	}
+
+	contentType := contentTypes[0]
+	media := rb.Content[contentType]
+
+	value := media.Example
+	if value == nil && len(media.Examples) > 0 {
+		names := make([]string, 0, len(media.Examples))
+		for name := range media.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		value = media.Examples[names[0]].Value
+	}
+
+	if value == nil && media.Schema != nil {
+		value = s.sample(media.Schema, 0)
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		body, err := json.Marshal(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not encode request body: %w", err)
+		}
+
+		return contentType, body, nil
+	case contentType == "application/x-www-form-urlencoded":
+		form := make(url.Values)
+		if object, ok := value.(map[string]any); ok {
+			for key, v := range object {
+				form.Set(key, fmt.Sprint(v))
+			}
+		}
+
+		return contentType, []byte(form.Encode()), nil
+	case value == nil:
+		return contentType, nil, nil
+	default:
+		return contentType, []byte(fmt.Sprint(value)), nil
+	}
+}
+
+func (s *openAPISpec) schema(schema *openAPISchema) *openAPISchema {
+	for i := 0; schema.Ref != "" && i < openAPIMaxDepth; i++ {
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		if !ok || s.Components.Schemas[name] == nil {
+			return &openAPISchema{}
+		}
+
+		schema = s.Components.Schemas[name]
+	}
+
+	return schema
+}
+
+// sample synthesizes the schema value from the example, default or enum, otherwise from the type.
+// Strings with the uuid and date-time formats use the placeholders so every request gets the new value.
+// The recursive schema reference is expanded once.
+func (s *openAPISpec) sample(schema *openAPISchema, depth int, refs ...string) any {
+	if schema == nil || depth > openAPIMaxDepth {
+		return nil
+	}
+
+	if schema.Ref != "" {
+		for _, ref := range refs {
+			if ref == schema.Ref {
+				return nil
+			}
+		}
+
+		refs = append(refs, schema.Ref)
+	}
+
+	schema = s.schema(schema)
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		object := make(map[string]any)
+		for _, sub := range schema.AllOf {
+			if v, ok := s.sample(sub, depth+1, refs...).(map[string]any); ok {
+				for key, value := range v {
+					object[key] = value
+				}
+			}
+		}
+
+		return object
+	case len(schema.OneOf) > 0:
+		return s.sample(schema.OneOf[0], depth+1, refs...)
+	case len(schema.AnyOf) > 0:
+		return s.sample(schema.AnyOf[0], depth+1, refs...)
+	}
+
+	switch schema.Type {
+	case "array":
+		item := s.sample(schema.Items, depth+1, refs...)
+		if item == nil {
+			return []any{}
+		}
+
+		return []any{item}
+	case "integer":
+		if schema.Minimum != nil {
+			return int64(*schema.Minimum)
+		}
+
+		return 1
+	case "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+
+		return 1.5
+	case "boolean":
+		return true
+	case "string":
+		switch schema.Format {
+		case "uuid":
+			return "{{uuid}}"
+		case "date-time":
+			return "{{now}}"
+		case "date":
+			return "2006-01-02"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		default:
+			return "string"
+		}
+	case "object", "":
+		if schema.Properties == nil {
+			if schema.Type == "" {
+				return nil
+			}
+
+			return map[string]any{}
+		}
+
+		object := make(map[string]any, len(schema.Properties))
+		for name, property := range schema.Properties {
+			if v := s.sample(property, depth+1, refs...); v != nil {
+				object[name] = v
+			}
+		}
+
+		return object
+	}
+
+	return nil
+}
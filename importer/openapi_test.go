@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI(t *testing.T) {
+	f, err := os.Open("testdata/petstore.yaml")
+	require.Nil(t, err)
+	defer f.Close()
+
+	operations, err := OpenAPI(f, OpenAPIOptions{})
+	require.Nil(t, err)
+
+	names := make([]string, len(operations))
+	for i, op := range operations {
+		names[i] = op.Loader.Method + " " + op.Loader.URL + " " + op.Loader.Name
+	}
+	require.Equal(t, []string{
+		"GET https://api.example.com/v1/health Petstore health",
+		"GET https://api.example.com/v1/pets Petstore listPets",
+		"POST https://api.example.com/v1/pets Petstore createPet",
+		"PUT https://api.example.com/v1/pets/42 Petstore PUT /pets/{petId}",
+		"DELETE https://api.example.com/v1/pets/42 Petstore deletePet",
+	}, names)
+
+	list := operations[1]
+	require.Equal(t, "List all pets", list.Loader.Description)
+	require.Equal(t, model.Parameters{{"limit": "20"}}, list.Loader.Parameters)
+	require.Equal(t, model.Headers{"X-Trace": {"bench"}}, list.Loader.Headers)
+	require.Equal(t, []*model.LoaderTag{
+		{Key: "openapi", Value: "Petstore"},
+		{Key: "operationId", Value: "listPets"},
+		{Key: "tag", Value: "pets"},
+	}, list.Tags)
+
+	create := operations[2]
+	require.Equal(t, model.Headers{"Content-Type": {"application/json"}}, create.Loader.Headers)
+	require.JSONEq(t, `{"id":"{{uuid}}","name":"Rex","kind":"dog","tags":["string"],"owner":{"email":"user@example.com","vip":true}}`,
+		strings.ReplaceAll(string(create.Loader.Body), "\n", ""))
+
+	update := operations[3]
+	require.Equal(t, "Imported OpenAPI operation", update.Loader.Description)
+	require.Equal(t, model.Headers{"Content-Type": {"application/x-www-form-urlencoded"}}, update.Loader.Headers)
+	require.Contains(t, string(update.Loader.Body), "name=Rex")
+	require.Equal(t, []*model.LoaderTag{
+		{Key: "openapi", Value: "Petstore"},
+		{Key: "tag", Value: "pets"},
+	}, update.Tags)
+
+	require.Equal(t, model.Headers{"Cookie": {"session=string"}}, operations[4].Loader.Headers)
+
+	_, err = f.Seek(0, 0)
+	require.Nil(t, err)
+
+	operations, err = OpenAPI(f, OpenAPIOptions{Server: "http://localhost:8080/"})
+	require.Nil(t, err)
+	require.Equal(t, "http://localhost:8080/health", operations[0].Loader.URL)
+
+	_, err = OpenAPI(strings.NewReader(`swagger: "2.0"`), OpenAPIOptions{})
+	require.ErrorContains(t, err, "only OpenAPI 3 is supported")
+
+	_, err = OpenAPI(strings.NewReader(`{"openapi": "3.1.0", "servers": [{"url": "/api"}], "paths": {}}`), OpenAPIOptions{})
+	require.ErrorContains(t, err, "is relative")
+}
@@ -0,0 +1,205 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// responseView is the response the assertions check. The engines expose it before the response is released.
+type responseView interface {
+	statusCode() int
+	header(name string) (string, bool)
+	body() []byte
+}
+
+// checkedResponse decodes the JSON body once for all json assertions
+type checkedResponse struct {
+	responseView
+
+	decoded bool
+	json    any
+}
+
+func (r *checkedResponse) jsonBody() (any, bool) {
+	if !r.decoded {
+		r.decoded = true
+
+		d := json.NewDecoder(bytes.NewReader(r.body()))
+		d.UseNumber()
+		if d.Decode(&r.json) != nil {
+			r.json = nil
+		}
+	}
+
+	return r.json, r.json != nil
+}
+
+type assertion struct {
+	// name is the error the failed request is counted under
+	name  string
+	check func(r *checkedResponse) bool
+}
+
+// assertions are the loader assertions compiled at the loader start
+type assertions struct {
+	list []*assertion
+	// status is set when the status assertion replaces the 2xx check
+	status bool
+}
+
+func newAssertions(list model.Assertions) (*assertions, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	a := &assertions{}
+	for _, assertion := range list {
+		compiled, err := newAssertion(assertion)
+		if err != nil {
+			return nil, fmt.Errorf("assertion %s: %w", assertion, err)
+		}
+
+		if assertion.Type == model.AssertionStatus {
+			a.status = true
+		}
+
+		a.list = append(a.list, compiled)
+	}
+
+	return a, nil
+}
+
+func newAssertion(a *model.Assertion) (*assertion, error) {
+	switch a.Type {
+	case model.AssertionStatus:
+		codes := make(map[int]bool)
+		classes := make(map[int]bool)
+		for _, code := range strings.Split(a.Value, ",") {
+			code = strings.TrimSpace(strings.ToLower(code))
+			if len(code) == 3 && strings.HasSuffix(code, "xx") && code[0] >= '1' && code[0] <= '5' {
+				classes[int(code[0]-'0')] = true
+				continue
+			}
+
+			c, err := strconv.Atoi(code)
+			if err != nil || c < 100 || c > 599 {
+				return nil, fmt.Errorf("wrong status code %s", code)
+			}
+
+			codes[c] = true
+		}
+
+		return &assertion{
+			name: "assertion failed: status " + a.Value,
+			check: func(r *checkedResponse) bool {
+				return codes[r.statusCode()] || classes[r.statusCode()/100]
+			},
+		}, nil
+	case model.AssertionBody:
+		value := []byte(a.Value)
+		return &assertion{
+			name: fmt.Sprintf("assertion failed: body contains %q", a.Value),
+			check: func(r *checkedResponse) bool {
+				return bytes.Contains(r.body(), value)
+			},
+		}, nil
+	case model.AssertionBodyRegexp:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return &assertion{
+			name: fmt.Sprintf("assertion failed: body matches %q", a.Value),
+			check: func(r *checkedResponse) bool {
+				return re.Match(r.body())
+			},
+		}, nil
+	case model.AssertionJSON:
+		if a.Path == "" {
+			return nil, errors.New("json path has to be set")
+		}
+
+		name := fmt.Sprintf("assertion failed: json %s == %q", a.Path, a.Value)
+		if a.Value == "" {
+			name = fmt.Sprintf("assertion failed: json %s is present", a.Path)
+		}
+
+		return &assertion{
+			name: name,
+			check: func(r *checkedResponse) bool {
+				body, ok := r.jsonBody()
+				if !ok {
+					return false
+				}
+
+				value, ok := jsonPathValue(body, a.Path)
+				return ok && (a.Value == "" || value == a.Value)
+			},
+		}, nil
+	case model.AssertionHeader:
+		if a.Path == "" {
+			return nil, errors.New("header name has to be set")
+		}
+
+		name := fmt.Sprintf("assertion failed: header %s == %q", a.Path, a.Value)
+		if a.Value == "" {
+			name = fmt.Sprintf("assertion failed: header %s is present", a.Path)
+		}
+
+		return &assertion{
+			name: name,
+			check: func(r *checkedResponse) bool {
+				value, ok := r.header(a.Path)
+				return ok && (a.Value == "" || value == a.Value)
+			},
+		}, nil
+	case model.AssertionMaxBodySize:
+		size, err := strconv.Atoi(a.Value)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("wrong body size %s", a.Value)
+		}
+
+		return &assertion{
+			name: fmt.Sprintf("assertion failed: body size <= %d", size),
+			check: func(r *checkedResponse) bool {
+				return len(r.body()) <= size
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown assertion type %s", a.Type)
+	}
+}
+
+// check returns the name of the first failed assertion, empty when all of them are met.
+// Without the status assertion only the 2xx responses are checked as the rest fail anyway.
+func (a *assertions) check(v responseView) string {
+	if !a.status && (v.statusCode() < 200 || v.statusCode() >= 300) {
+		return ""
+	}
+
+	r := &checkedResponse{responseView: v}
+	for _, assertion := range a.list {
+		if !assertion.check(r) {
+			return assertion.name
+		}
+	}
+
+	return ""
+}
+
+// assert checks the response and marks the stat failed when one of the assertions is not met
+func (a *assertions) assert(stat *model.RequestStat, v responseView) {
+	stat.StatusAsserted = a.status
+	if name := a.check(v); name != "" {
+		stat.Error = name
+		stat.AssertionFailed = true
+	}
+}
@@ -0,0 +1,77 @@
+package loader
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// connectionsCounter counts the connections lifecycle of the HTTP engine
+type connectionsCounter struct {
+	opened atomic.Int64
+	reused atomic.Int64
+	closed atomic.Int64
+	reset  atomic.Int64
+}
+
+func (c *connectionsCounter) stats() model.ConnectionStats {
+	return model.ConnectionStats{
+		NewConnections:    int(c.opened.Load()),
+		ReusedConnections: int(c.reused.Load()),
+		ClosedConnections: int(c.closed.Load()),
+		ResetConnections:  int(c.reset.Load()),
+	}
+}
+
+// wrap counts the dialed connection as opened and its close and reset
+func (c *connectionsCounter) wrap(conn net.Conn) net.Conn {
+	c.opened.Add(1)
+
+	return &countedConn{
+		Conn:    conn,
+		counter: c,
+	}
+}
+
+// countedConn counts the connection close and the reset seen in reads or writes, each once
+type countedConn struct {
+	net.Conn
+
+	counter   *connectionsCounter
+	closeOnce sync.Once
+	resetOnce sync.Once
+}
+
+func (c *countedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.checkReset(err)
+
+	return n, err
+}
+
+func (c *countedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.checkReset(err)
+
+	return n, err
+}
+
+func (c *countedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.counter.closed.Add(1)
+	})
+
+	return c.Conn.Close()
+}
+
+func (c *countedConn) checkReset(err error) {
+	if err != nil && (errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)) {
+		c.resetOnce.Do(func() {
+			c.counter.reset.Add(1)
+		})
+	}
+}
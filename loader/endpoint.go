@@ -0,0 +1,272 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/caio/go-tdigest/v4"
+	"github.com/valyala/fasthttp"
+)
+
+// endpointPicker chooses the request mix endpoint for every request according to the endpoints weights
+type endpointPicker struct {
+	endpoints []*model.Endpoint
+	templates []*endpointTemplate
+	// cumulative holds the sum of weights up to and including the endpoint on the same position
+	cumulative []int
+}
+
+// newEndpointPicker builds the request mix from the loader options.
+// When the loader has no endpoints the mix is the single request described by the loader itself.
+func newEndpointPicker(opts *model.Loader) (*endpointPicker, error) {
+	if len(opts.Endpoints) == 0 {
+		endpoint := &model.Endpoint{
+			URL:        opts.URL,
+			Method:     opts.Method,
+			Weight:     1,
+			Body:       opts.Body,
+			Headers:    opts.Headers,
+			Parameters: opts.Parameters,
+		}
+
+		t, err := newEndpointTemplate(endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		return &endpointPicker{
+			endpoints:  []*model.Endpoint{endpoint},
+			templates:  []*endpointTemplate{t},
+			cumulative: []int{1},
+		}, nil
+	}
+
+	base, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("bad url format: %w", err)
+	}
+
+	p := &endpointPicker{
+		endpoints:  make([]*model.Endpoint, len(opts.Endpoints)),
+		templates:  make([]*endpointTemplate, len(opts.Endpoints)),
+		cumulative: make([]int, len(opts.Endpoints)),
+	}
+
+	var total int
+	names := make(map[string]struct{})
+	for i, e := range opts.Endpoints {
+		if e.Weight < 0 {
+			return nil, fmt.Errorf("endpoint %d weight has to be positive", i)
+		}
+
+		endpoint, err := resolveEndpoint(base, opts.Headers, e)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %d %w", i, err)
+		}
+
+		if endpoint.Weight == 0 {
+			endpoint.Weight = 1
+		}
+
+		if _, ok := names[endpoint.Name]; ok {
+			return nil, fmt.Errorf("endpoint name %s is not unique", endpoint.Name)
+		}
+		names[endpoint.Name] = struct{}{}
+
+		t, err := newEndpointTemplate(endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		total += endpoint.Weight
+		p.endpoints[i] = endpoint
+		p.templates[i] = t
+		p.cumulative[i] = total
+	}
+
+	if total == 0 {
+		return nil, errors.New("endpoints weights sum has to be positive")
+	}
+
+	return p, nil
+}
+
+// resolveURL joins the relative endpoint URL with the loader URL.
+// It is done on strings as the URL can hold flow variables which must not be escaped.
+func resolveURL(base *url.URL, ref string) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "${") {
+		return ref
+	}
+
+	if strings.HasPrefix(ref, "/") {
+		return base.Scheme + "://" + base.Host + ref
+	}
+
+	return strings.TrimSuffix(base.String(), "/") + "/" + ref
+}
+
+// resolveEndpoint returns the copy of the endpoint with the URL resolved against the loader URL,
+// the loader headers added and the default method and name set
+func resolveEndpoint(base *url.URL, headers model.Headers, e *model.Endpoint) (*model.Endpoint, error) {
+	if e.URL == "" {
+		return nil, errors.New("url has to be set")
+	}
+
+	endpoint := &model.Endpoint{
+		Name:       e.Name,
+		URL:        resolveURL(base, e.URL),
+		Method:     e.Method,
+		Weight:     e.Weight,
+		Body:       e.Body,
+		Headers:    make(model.Headers),
+		Parameters: e.Parameters,
+	}
+
+	if endpoint.Method == "" {
+		endpoint.Method = fasthttp.MethodGet
+	}
+
+	if endpoint.Name == "" {
+		endpoint.Name = endpoint.Method + " " + e.URL
+	}
+
+	// Endpoint headers replace the loader headers with the same name
+	for key, value := range headers {
+		endpoint.Headers[key] = value
+	}
+
+	for key, value := range e.Headers {
+		endpoint.Headers[key] = value
+	}
+
+	return endpoint, nil
+}
+
+func (p *endpointPicker) pick() *endpointTemplate {
+	if len(p.templates) == 1 {
+		return p.templates[0]
+	}
+
+	r := rand.IntN(p.cumulative[len(p.cumulative)-1])
+	return p.templates[sort.SearchInts(p.cumulative, r+1)]
+}
+
+// endpointStats collects the statistics of a single request mix endpoint
+type endpointStats struct {
+	name   string
+	url    string
+	method string
+
+	success         int
+	fail            int
+	dataTransferred int
+
+	minDuration   time.Duration
+	maxDuration   time.Duration
+	totalDuration time.Duration
+
+	t         *tdigest.TDigest
+	errors    map[string]int
+	httpCodes map[int]int
+}
+
+func newEndpointStats(endpoint *model.Endpoint) (*endpointStats, error) {
+	t, err := tdigest.New()
+	if err != nil {
+		return nil, fmt.Errorf("tdigest error: %w", err)
+	}
+
+	return &endpointStats{
+		name:      endpoint.Name,
+		url:       endpoint.URL,
+		method:    endpoint.Method,
+		t:         t,
+		errors:    make(map[string]int),
+		httpCodes: make(map[int]int),
+	}, nil
+}
+
+func (e *endpointStats) add(stat *model.RequestStat) error {
+	if e.success+e.fail == 0 || stat.Duration < e.minDuration {
+		e.minDuration = stat.Duration
+	}
+
+	if stat.Duration > e.maxDuration {
+		e.maxDuration = stat.Duration
+	}
+
+	e.totalDuration += stat.Duration
+
+	if !StatFailed(stat) {
+		e.success++
+		e.dataTransferred += stat.BodySize
+	} else {
+		e.fail++
+		e.errors[StatError(stat)]++
+	}
+
+	if StatResponded(stat) {
+		e.httpCodes[stat.RetCode]++
+	}
+
+	return e.t.Add(float64(stat.Duration))
+}
+
+func (e *endpointStats) summary(totalTime time.Duration) *model.EndpointSummary {
+	s := &model.EndpointSummary{
+		Name:            e.name,
+		URL:             e.url,
+		Method:          e.method,
+		ReqCount:        e.success + e.fail,
+		SuccessReq:      e.success,
+		FailReq:         e.fail,
+		DataTransferred: e.dataTransferred,
+		MinReqTime:      e.minDuration,
+		MaxReqTime:      e.maxDuration,
+		Errors:          e.errors,
+		HTTPCodes:       e.httpCodes,
+		TDigest:         e.t.ToBytes(nil),
+	}
+
+	if s.ReqCount != 0 {
+		s.AvgReqTime = e.totalDuration / time.Duration(s.ReqCount)
+		s.P50ReqTime = time.Duration(e.t.Quantile(0.5))
+		s.P75ReqTime = time.Duration(e.t.Quantile(0.75))
+		s.P90ReqTime = time.Duration(e.t.Quantile(0.9))
+		s.P99ReqTime = time.Duration(e.t.Quantile(0.99))
+	}
+
+	s.ReqPerSec = reqPerSecond(e.success, totalTime)
+
+	return s
+}
+
+// StatFailed tells whether the request failed, only 2xx responses are successful unless the status is asserted
+func StatFailed(stat *model.RequestStat) bool {
+	if stat.Error != "" {
+		return true
+	}
+
+	return !stat.StatusAsserted && (stat.RetCode < 200 || stat.RetCode >= 300)
+}
+
+// StatResponded tells whether the response was received, the status code is counted only then
+func StatResponded(stat *model.RequestStat) bool {
+	return stat.Error == "" || stat.AssertionFailed
+}
+
+// StatError returns the error name the failed request is counted under
+func StatError(stat *model.RequestStat) string {
+	if stat.Error != "" {
+		return stat.Error
+	}
+
+	return fasthttp.StatusMessage(stat.RetCode)
+}
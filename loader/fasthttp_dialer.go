@@ -0,0 +1,355 @@
+package loader
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/valyala/fasthttp"
+)
+
+// closedConnTTL is how long a closed connection waits for the requests answered over it to take their phases
+const closedConnTTL = time.Second
+
+// tracedDialer dials the fasthttp connections measuring the DNS, connect and TLS phases.
+// fasthttp has no client trace so the connection records when the requests were written and
+// when the responses started, the request takes its phases from the connection it was sent over.
+type tracedDialer struct {
+	// conns maps the connection addresses to the open connections
+	conns       sync.Map
+	connections *connectionsCounter
+
+	// timeout bounds the connect to every address and the TLS handshake, fasthttp.DefaultDialTimeout when zero
+	timeout time.Duration
+	// tcpDialer connects to the resolved addresses, the hosts are resolved and cached by the dialer
+	// so the DNS lookup is measured apart from the connect
+	tcpDialer fasthttp.TCPDialer
+	// dnsCache maps the host and the dual stack flag to the resolved addresses
+	dnsCache sync.Map
+}
+
+// dnsEntry are the addresses of a host, resolved again after fasthttp.DefaultDNSCacheDuration
+type dnsEntry struct {
+	ips         []net.IP
+	ipsIdx      atomic.Uint32
+	pending     atomic.Bool
+	resolveTime time.Time
+}
+
+// configure replaces the dial of the fasthttp host client, the TLS handshake is done by the dialer
+func (d *tracedDialer) configure(hc *fasthttp.HostClient) error {
+	isTLS := hc.IsTLS
+	dualStack := hc.DialDualStack
+
+	var tlsConfig *tls.Config
+	if isTLS {
+		tlsConfig = &tls.Config{}
+		if hc.TLSConfig != nil {
+			tlsConfig = hc.TLSConfig.Clone()
+		}
+
+		if tlsConfig.ClientSessionCache == nil {
+			tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+		}
+	}
+
+	hc.Dial = func(addr string) (net.Conn, error) {
+		return d.dial(fasthttp.AddMissingPort(addr, isTLS), dualStack, tlsConfig)
+	}
+
+	return nil
+}
+
+func (d *tracedDialer) dial(addr string, dualStack bool, tlsConfig *tls.Config) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := d.timeout
+	if timeout == 0 {
+		timeout = fasthttp.DefaultDialTimeout
+	}
+
+	var phases model.Phases
+	var idx uint32
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		var cached bool
+
+		dnsStart := time.Now()
+		ips, idx, cached, err = d.lookup(host, dualStack, timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		// A cached lookup takes no time, as with the reused connections the DNS phase is not reported
+		if !cached {
+			phases.DNS = time.Since(dnsStart)
+		}
+	}
+
+	var conn net.Conn
+
+	// Every address has its own deadline, the addresses are tried in turn as fasthttp does
+	connectStart := time.Now()
+	for n := uint32(len(ips)); n > 0; n-- {
+		ipAddr := net.JoinHostPort(ips[idx%uint32(len(ips))].String(), port)
+		if dualStack {
+			conn, err = d.tcpDialer.DialDualStackTimeout(ipAddr, timeout)
+		} else {
+			conn, err = d.tcpDialer.DialTimeout(ipAddr, timeout)
+		}
+
+		if err == nil {
+			break
+		}
+
+		idx++
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	phases.Connect = time.Since(connectStart)
+	conn = d.connections.wrap(conn)
+
+	if tlsConfig != nil {
+		// The server name is sent even when the certificate is not verified, as fasthttp does
+		config := tlsConfig
+		if config.ServerName == "" {
+			config = config.Clone()
+			config.ServerName = host
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		tlsStart := time.Now()
+		tlsConn := tls.Client(conn, config)
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		phases.TLS = time.Since(tlsStart)
+		conn = tlsConn
+	}
+
+	c := &tracedConn{
+		Conn:   conn,
+		dialer: d,
+		key:    connKey(conn.LocalAddr(), conn.RemoteAddr()),
+		dial:   phases,
+	}
+	d.conns.Store(c.key, c)
+
+	return c, nil
+}
+
+// lookup returns the cached addresses of the host with the index of the address to try first,
+// the expired entry is resolved again by one goroutine while the others keep using it
+func (d *tracedDialer) lookup(host string, dualStack bool, timeout time.Duration) ([]net.IP, uint32, bool, error) {
+	key := host
+	if dualStack {
+		key += "|dualstack"
+	}
+
+	cached := true
+	item, ok := d.dnsCache.Load(key)
+	e, _ := item.(*dnsEntry)
+	if ok && time.Since(e.resolveTime) > fasthttp.DefaultDNSCacheDuration {
+		if e.pending.CompareAndSwap(false, true) {
+			e = nil
+		}
+	}
+
+	if e == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		ips, err := lookupIPs(ctx, host, dualStack)
+		if err != nil {
+			// The failed lookup is not cached, another goroutine can retry it
+			if item, ok := d.dnsCache.Load(key); ok {
+				item.(*dnsEntry).pending.Store(false)
+			}
+
+			return nil, 0, false, err
+		}
+
+		e = &dnsEntry{
+			ips:         ips,
+			resolveTime: time.Now(),
+		}
+		d.dnsCache.Store(key, e)
+		cached = false
+	}
+
+	return e.ips, e.ipsIdx.Add(1), cached, nil
+}
+
+// lookupIPs resolves the host, only to the IPv4 addresses without the dual stack as the fasthttp dialer does
+func lookupIPs(ctx context.Context, host string, dualStack bool) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		if dualStack || addr.IP.To4() != nil {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil, errors.New("no such host " + host)
+	}
+
+	return ips, nil
+}
+
+// phases returns the phases of the request which received the response and whether it reused the connection
+func (d *tracedDialer) phases(resp *fasthttp.Response, end time.Time) (model.Phases, bool) {
+	if resp.LocalAddr() == nil || resp.RemoteAddr() == nil {
+		return model.Phases{}, false
+	}
+
+	c, ok := d.conns.Load(connKey(resp.LocalAddr(), resp.RemoteAddr()))
+	if !ok {
+		return model.Phases{}, false
+	}
+
+	return c.(*tracedConn).phases(end)
+}
+
+func connKey(local, remote net.Addr) string {
+	return local.String() + "-" + remote.String()
+}
+
+// connRequest are the times of one request sent over the connection
+type connRequest struct {
+	reused    bool
+	dial      model.Phases
+	wrote     time.Time
+	firstByte time.Time
+}
+
+// tracedConn records the times of the requests sent over the connection.
+// fasthttp releases the connection before the request returns so the next request can already
+// be sent over it, the requests are kept in the order they were written and taken in the same order.
+type tracedConn struct {
+	net.Conn
+
+	dialer *tracedDialer
+	key    string
+
+	mu sync.Mutex
+	// dial are the dial phases, reported by the first request only
+	dial     model.Phases
+	requests []*connRequest
+	sent     int
+	closed   bool
+}
+
+func (c *tracedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	now := time.Now()
+
+	c.mu.Lock()
+	// The request can be written in many parts, a new request starts after the response of the previous one
+	if len(c.requests) == 0 || !c.requests[len(c.requests)-1].firstByte.IsZero() {
+		c.requests = append(c.requests, &connRequest{reused: c.sent > 0, dial: c.dial})
+		c.dial = model.Phases{}
+		c.sent++
+	}
+
+	c.requests[len(c.requests)-1].wrote = now
+	c.mu.Unlock()
+
+	return n, err
+}
+
+func (c *tracedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+
+	if n > 0 {
+		now := time.Now()
+
+		c.mu.Lock()
+		if len(c.requests) > 0 && c.requests[len(c.requests)-1].firstByte.IsZero() {
+			c.requests[len(c.requests)-1].firstByte = now
+		}
+		c.mu.Unlock()
+	}
+
+	return n, err
+}
+
+// Close keeps the connection until the requests answered over it take their phases,
+// fasthttp closes the connection before the request returns when the server asked for it.
+// fasthttp also closes the connection when a request fails, so a failed request never shifts the
+// order of the next ones, but the answered request which failed never takes its phases and the
+// connection is dropped after closedConnTTL.
+func (c *tracedConn) Close() error {
+	c.mu.Lock()
+	c.closed = true
+
+	answered := c.requests[:0]
+	for _, r := range c.requests {
+		if !r.firstByte.IsZero() {
+			answered = append(answered, r)
+		}
+	}
+	c.requests = answered
+
+	if len(c.requests) == 0 {
+		c.dialer.conns.CompareAndDelete(c.key, c)
+	} else {
+		time.AfterFunc(closedConnTTL, func() {
+			c.dialer.conns.CompareAndDelete(c.key, c)
+		})
+	}
+	c.mu.Unlock()
+
+	return c.Conn.Close()
+}
+
+// Handshake tells fasthttp the TLS connection is already established by the dialer
+func (c *tracedConn) Handshake() error {
+	return nil
+}
+
+// phases takes the phases of the oldest request sent over the connection
+func (c *tracedConn) phases(end time.Time) (model.Phases, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.requests) == 0 {
+		return model.Phases{}, false
+	}
+
+	r := c.requests[0]
+	c.requests = c.requests[1:]
+
+	if c.closed && len(c.requests) == 0 {
+		c.dialer.conns.CompareAndDelete(c.key, c)
+	}
+
+	phases := r.dial
+	if !r.firstByte.IsZero() {
+		phases.TTFB = r.firstByte.Sub(r.wrote)
+		phases.Transfer = end.Sub(r.firstByte)
+	}
+
+	return phases, r.reused
+}
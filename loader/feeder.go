@@ -0,0 +1,164 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync/atomic"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// feeder gives every request or flow run one row of the data file
+type feeder struct {
+	mode    string
+	columns map[string]struct{}
+	rows    []map[string]string
+
+	next atomic.Int64
+}
+
+// newFeeder parses the feeder data, the file is read when the data is not set
+func newFeeder(opts *model.Feeder) (*feeder, error) {
+	if opts == nil {
+		return nil, nil
+	}
+
+	f := &feeder{
+		mode:    opts.Mode,
+		columns: make(map[string]struct{}),
+	}
+
+	switch f.mode {
+	case model.FeederModeSequential, model.FeederModeRandom, model.FeederModeUnique:
+	default:
+		return nil, fmt.Errorf("wrong feeder mode %s", f.mode)
+	}
+
+	data := opts.Data
+	if len(data) == 0 {
+		var err error
+		data, err = os.ReadFile(opts.Path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read feeder file: %w", err)
+		}
+	}
+
+	var err error
+	switch opts.Format {
+	case model.FeederFormatCSV:
+		err = f.parseCSV(data)
+	case model.FeederFormatJSONL:
+		err = f.parseJSONL(data)
+	default:
+		return nil, fmt.Errorf("wrong feeder format %s", opts.Format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse feeder %s: %w", opts.Path, err)
+	}
+
+	if len(f.rows) == 0 {
+		return nil, errors.New("feeder has no rows")
+	}
+
+	return f, nil
+}
+
+// parseCSV reads the CSV with the column names in the first row
+func (f *feeder) parseCSV(data []byte) error {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	header := records[0]
+	for _, column := range header {
+		f.columns[column] = struct{}{}
+	}
+
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+
+		f.rows = append(f.rows, row)
+	}
+
+	return nil
+}
+
+// parseJSONL reads one JSON object per line, nested values are kept as JSON
+func (f *feeder) parseJSONL(data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	var line int
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var object map[string]any
+		d := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		d.UseNumber()
+		err := d.Decode(&object)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := make(map[string]string, len(object))
+		for column, v := range object {
+			f.columns[column] = struct{}{}
+			row[column], _ = jsonPathValue(v, "")
+		}
+
+		f.rows = append(f.rows, row)
+	}
+
+	return scanner.Err()
+}
+
+// row returns the next row, false means the unique mode feeder ran out of rows
+func (f *feeder) row() (map[string]string, bool) {
+	switch f.mode {
+	case model.FeederModeRandom:
+		return f.rows[rand.IntN(len(f.rows))], true
+	case model.FeederModeUnique:
+		i := f.next.Add(1) - 1
+		if i >= int64(len(f.rows)) {
+			return nil, false
+		}
+
+		return f.rows[i], true
+	default:
+		i := f.next.Add(1) - 1
+		return f.rows[i%int64(len(f.rows))], true
+	}
+}
+
+// validate checks the feeder has all columns the placeholders use
+func (f *feeder) validate(columns []string) error {
+	for _, column := range columns {
+		if f == nil {
+			return fmt.Errorf("feeder column %s is used but the feeder is not set", column)
+		}
+
+		if _, ok := f.columns[column]; !ok {
+			return fmt.Errorf("feeder has no column %s", column)
+		}
+	}
+
+	return nil
+}
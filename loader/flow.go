@@ -0,0 +1,220 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// flowStep is the flow step with the URL and headers resolved against the loader options
+type flowStep struct {
+	template *endpointTemplate
+	extract  []*model.Extract
+}
+
+// newFlow validates the user flow and resolves its steps
+func newFlow(opts *model.Loader) ([]*flowStep, error) {
+	if len(opts.Flow) == 0 {
+		return nil, nil
+	}
+
+	if len(opts.Endpoints) != 0 {
+		return nil, errors.New("flow can't be used with endpoints")
+	}
+
+	base, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("bad url format: %w", err)
+	}
+
+	steps := make([]*flowStep, len(opts.Flow))
+	names := make(map[string]struct{})
+	for i, step := range opts.Flow {
+		endpoint, err := resolveEndpoint(base, opts.Headers, &step.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("flow step %d %w", i, err)
+		}
+
+		if _, ok := names[endpoint.Name]; ok {
+			return nil, fmt.Errorf("flow step name %s is not unique", endpoint.Name)
+		}
+		names[endpoint.Name] = struct{}{}
+
+		for _, extract := range step.Extract {
+			if extract.Variable == "" || extract.Path == "" {
+				return nil, fmt.Errorf("flow step %s extract needs variable and path", endpoint.Name)
+			}
+
+			switch extract.Source {
+			case model.ExtractSourceJSON, model.ExtractSourceHeader, model.ExtractSourceCookie:
+			default:
+				return nil, fmt.Errorf("flow step %s has wrong extract source %s", endpoint.Name, extract.Source)
+			}
+		}
+
+		t, err := newEndpointTemplate(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("flow step %w", err)
+		}
+
+		steps[i] = &flowStep{
+			template: t,
+			extract:  step.Extract,
+		}
+	}
+
+	return steps, nil
+}
+
+// runFlow runs all flow steps as the virtual user with the vars scope. Every step is reported as a separate stat
+// and the whole flow as the stat with the Flow flag set. The flow stops at the first failed step.
+func (l *Loader) runFlow(rc *renderContext, scheduled time.Time) {
+	flowStat := &model.RequestStat{
+		Start: time.Now(),
+		Flow:  true,
+	}
+
+	for i, step := range l.flow {
+		var stat *model.RequestStat
+
+		rc.seq = l.seq.Add(1)
+		req, err := step.template.request(rc)
+		if err != nil {
+			stat = renderErrorStat(step.template.endpoint, err)
+		} else {
+			req.KeepResponse = len(step.extract) > 0
+			req.assertions = l.assertions
+
+			var resp *Response
+			stat, resp = l.requester.Request(req)
+			if resp != nil && !StatFailed(stat) {
+				err = extractVariables(step.extract, resp, rc.vars)
+				if err != nil {
+					stat.Error = err.Error()
+				}
+			}
+		}
+
+		if i == 0 {
+			l.applySchedule(stat, scheduled)
+			flowStat.Start = stat.Start
+			flowStat.Late = stat.Late
+		}
+
+		flowStat.RetCode = stat.RetCode
+		flowStat.StatusAsserted = stat.StatusAsserted
+		flowStat.BodySize += stat.BodySize
+
+		l.statsChan <- stat
+
+		if StatFailed(stat) {
+			flowStat.Error = fmt.Sprintf("%s: %s", step.template.endpoint.Name, StatError(stat))
+			break
+		}
+	}
+
+	flowStat.End = time.Now()
+	flowStat.Duration = flowStat.End.Sub(flowStat.Start)
+
+	l.inFlight.Add(-1)
+
+	l.statsChan <- flowStat
+}
+
+// renderErrorStat is the stat of the request which could not be created
+func renderErrorStat(endpoint *model.Endpoint, err error) *model.RequestStat {
+	now := time.Now()
+	return &model.RequestStat{
+		Start:    now,
+		End:      now,
+		Error:    err.Error(),
+		Endpoint: endpoint.Name,
+	}
+}
+
+// extractVariables saves the values from the response into vars
+func extractVariables(extracts []*model.Extract, resp *Response, vars map[string]string) error {
+	var body any
+	for _, extract := range extracts {
+		var value string
+		var ok bool
+
+		switch extract.Source {
+		case model.ExtractSourceJSON:
+			if body == nil {
+				d := json.NewDecoder(bytes.NewReader(resp.Body))
+				d.UseNumber()
+				err := d.Decode(&body)
+				if err != nil {
+					return fmt.Errorf("could not extract %s, response body is not JSON: %w", extract.Variable, err)
+				}
+			}
+
+			value, ok = jsonPathValue(body, extract.Path)
+		case model.ExtractSourceHeader:
+			value = resp.Header.Get(extract.Path)
+			ok = value != ""
+		case model.ExtractSourceCookie:
+			value, ok = resp.Cookies[extract.Path]
+		}
+
+		if !ok {
+			return fmt.Errorf("could not extract %s from %s %s", extract.Variable, extract.Source, extract.Path)
+		}
+
+		vars[extract.Variable] = value
+	}
+
+	return nil
+}
+
+// jsonPathValue returns the value on the dot separated path, array elements are addressed by index
+func jsonPathValue(v any, path string) (string, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			switch node := v.(type) {
+			case map[string]any:
+				var ok bool
+				v, ok = node[key]
+				if !ok {
+					return "", false
+				}
+			case []any:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(node) {
+					return "", false
+				}
+
+				v = node[i]
+			default:
+				return "", false
+			}
+		}
+	}
+
+	switch value := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+
+		return string(b), true
+	}
+}
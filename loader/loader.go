@@ -7,33 +7,100 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net/http"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tmwalaszek/hload/model"
 
 	"github.com/caio/go-tdigest/v4"
-	"github.com/valyala/fasthttp"
 	"golang.org/x/time/rate"
 )
 
+// Requester sends the request with the HTTP engine. The response is returned only when the request
+// asks to keep it and the request did not fail with an error.
 type Requester interface {
-	Request() *model.RequestStat
+	Request(req *Request) (*model.RequestStat, *Response)
+	// Connections returns the connections lifecycle counters of the engine
+	Connections() model.ConnectionStats
+}
+
+// Request is one HTTP request of the endpoint or flow step
+type Request struct {
+	// Name is the endpoint or flow step name the request stat is reported for
+	Name       string
+	URL        string
+	Method     string
+	Headers    model.Headers
+	Parameters map[string]string
+	Body       []byte
+
+	// KeepResponse asks the Requester to return the response for the values extraction
+	KeepResponse bool
+
+	// assertions are checked by the Requester before the response is released
+	assertions *assertions
+}
+
+// StatsObserver is told about every request stat Loader.Do consumes, including the flow stats with Flow set.
+// It is called from the Do goroutine so it has to return quickly.
+type StatsObserver interface {
+	Observe(stat *model.RequestStat)
+}
+
+// Response is the part of the HTTP response the flow extracts the values from
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Cookies    map[string]string
+	Body       []byte
 }
 
 const DefaultConnection = 10
 const HTTPEngine = "http"
 const FastHTTPEngine = "fast_http"
 
+// LateThreshold is how long a request in the open model can wait for a free worker
+// before it is counted as late
+const LateThreshold = time.Millisecond
+
+// stageTick is how often the load is adjusted to the stages profile when no request can be sent
+const stageTick = 10 * time.Millisecond
+
 type Loader struct {
 	opts *model.Loader
 
-	reqChan   chan struct{}
+	// reqChan carries the scheduled send time in the open model, zero time otherwise
+	reqChan   chan time.Time
 	statsChan chan *model.RequestStat
 	requester Requester
 
+	picker     *endpointPicker
+	flow       []*flowStep
+	feeder     *feeder
+	assertions *assertions
+
+	// feederExhausted stops the benchmark when the unique feeder runs out of rows
+	feederExhausted chan struct{}
+
+	// endpoints are the request mix endpoints or the flow steps the summary is broken down by
+	endpoints []*model.Endpoint
+
 	progressChan chan struct{}
+	observers    []StatsObserver
+
+	dropped  atomic.Int64
+	inFlight atomic.Int64
+	// seq numbers the requests for the {{seq}} placeholder
+	seq atomic.Int64
+	// stageRate is the requests per second of the current rate_limit stage as float64 bits
+	stageRate atomic.Uint64
+
+	// timedOut is set when the benchmark did not finish within the BenchmarkTimeout
+	timedOut atomic.Bool
 }
 
 func NewLoader(opts *model.Loader) (*Loader, error) {
@@ -50,6 +117,83 @@ func NewLoaderProgress(opts *model.Loader, progressChan chan struct{}) (*Loader,
 	return l, nil
 }
 
+// AddObserver adds the observer of the request stats, it has to be added before Do is called
+func (l *Loader) AddObserver(o StatsObserver) {
+	l.observers = append(l.observers, o)
+}
+
+// InFlight returns the number of requests handed to the workers and not finished yet
+func (l *Loader) InFlight() int {
+	return int(l.inFlight.Load())
+}
+
+// TargetRate returns the requests per second the benchmark is set to send, zero when the rate is not limited
+func (l *Loader) TargetRate() float64 {
+	switch {
+	case l.opts.ArrivalRate != 0:
+		return float64(l.opts.ArrivalRate)
+	case l.opts.RateLimit != 0:
+		return float64(l.opts.RateLimit)
+	case len(l.opts.Stages) > 0 && l.opts.StageTarget == model.StageTargetRateLimit:
+		return math.Float64frombits(l.stageRate.Load())
+	case l.opts.RequestDelay != 0:
+		return float64(l.opts.Connections) * float64(time.Second) / float64(l.opts.RequestDelay)
+	}
+
+	return 0
+}
+
+// Connections returns the connections lifecycle counters of the HTTP engine
+func (l *Loader) Connections() model.ConnectionStats {
+	return l.requester.Connections()
+}
+
+// validateStages checks the load profile and sets the benchmark duration and connections from it
+func validateStages(opts *model.Loader) error {
+	if len(opts.Stages) == 0 {
+		return nil
+	}
+
+	if opts.ArrivalRate != 0 {
+		return errors.New("stages can't be used with arrival rate")
+	}
+
+	for i, stage := range opts.Stages {
+		if stage.Duration <= 0 {
+			return fmt.Errorf("stage %d duration has to be positive", i)
+		}
+
+		if stage.Target < 0 {
+			return fmt.Errorf("stage %d target has to be positive", i)
+		}
+
+		switch stage.Transition {
+		case "", model.StageTransitionLinear, model.StageTransitionStep:
+		default:
+			return fmt.Errorf("stage %d has wrong transition %s", i, stage.Transition)
+		}
+	}
+
+	switch opts.StageTarget {
+	case model.StageTargetConnections:
+		// Workers are started for the highest target and the stages decide how many of them are busy
+		if opts.Connections != 0 && opts.Connections != opts.Stages.MaxTarget() {
+			return fmt.Errorf("connections %d differ from the connections stages highest target %d", opts.Connections, opts.Stages.MaxTarget())
+		}
+		opts.Connections = opts.Stages.MaxTarget()
+	case model.StageTargetRateLimit:
+		if opts.RateLimit != 0 {
+			return errors.New("rate limit can't be used with rate_limit stages")
+		}
+	default:
+		return fmt.Errorf("wrong stage target %s", opts.StageTarget)
+	}
+
+	opts.Duration = opts.Stages.Duration()
+
+	return nil
+}
+
 func newLoader(opts *model.Loader) (*Loader, error) {
 	var tlsConfig tls.Config
 
@@ -58,6 +202,11 @@ func newLoader(opts *model.Loader) (*Loader, error) {
 		return nil, fmt.Errorf("bad url format: %w", err)
 	}
 
+	err = validateStages(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	if opts.Connections < 0 {
 		return nil, errors.New("number of connections has to be positive")
 	}
@@ -78,10 +227,23 @@ func newLoader(opts *model.Loader) (*Loader, error) {
 		return nil, errors.New("number of requests count has to be positive")
 	}
 
-	if opts.ReqCount == 0 && opts.Duration == 0 {
+	// The unique feeder can run until all rows are used
+	if opts.ReqCount == 0 && opts.Duration == 0 && (opts.Feeder == nil || opts.Feeder.Mode != model.FeederModeUnique) {
 		return nil, errors.New("requests count or duration has to be set")
 	}
 
+	if opts.ArrivalRate < 0 {
+		return nil, errors.New("arrival rate has to be positive")
+	}
+
+	if opts.ArrivalRate != 0 && (opts.RateLimit != 0 || opts.RequestDelay != 0) {
+		return nil, errors.New("arrival rate can't be used with rate limit or request delay")
+	}
+
+	if opts.CorrectCoordinatedOmission && opts.RateLimit == 0 && opts.RequestDelay == 0 {
+		return nil, errors.New("coordinated omission correction needs rate limit or request delay")
+	}
+
 	if opts.SkipVerify {
 		tlsConfig.InsecureSkipVerify = opts.SkipVerify
 	} else {
@@ -102,11 +264,11 @@ func newLoader(opts *model.Loader) (*Loader, error) {
 		}
 	}
 
-	var reqChan chan struct{}
+	var reqChan chan time.Time
 	if opts.Connections == 1 {
-		reqChan = make(chan struct{})
+		reqChan = make(chan time.Time)
 	} else {
-		reqChan = make(chan struct{}, opts.Connections)
+		reqChan = make(chan time.Time, opts.Connections)
 	}
 
 	statsChan := make(chan *model.RequestStat)
@@ -125,15 +287,104 @@ func newLoader(opts *model.Loader) (*Loader, error) {
 		return nil, err
 	}
 
+	flow, err := newFlow(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	picker, err := newEndpointPicker(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	feeder, err := newFeeder(opts.Feeder)
+	if err != nil {
+		return nil, err
+	}
+
+	assertions, err := newAssertions(opts.Assertions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ValidateThresholds(opts.Thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []*model.Endpoint
+	if len(opts.Endpoints) > 0 {
+		endpoints = picker.endpoints
+	}
+
+	for _, t := range picker.templates {
+		err = feeder.validate(t.columns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, step := range flow {
+		endpoints = append(endpoints, step.template.endpoint)
+
+		err = feeder.validate(step.template.columns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Loader{
-		opts:      opts,
-		requester: requester,
+		opts:       opts,
+		requester:  requester,
+		picker:     picker,
+		flow:       flow,
+		feeder:     feeder,
+		assertions: assertions,
+		endpoints:  endpoints,
+
+		feederExhausted: make(chan struct{}, 1),
 
 		reqChan:   reqChan,
 		statsChan: statsChan,
 	}, nil
 }
 
+// expectedInterval returns how often a single worker is expected to send a request.
+// It is zero when the benchmark does not set the request pace.
+func (l *Loader) expectedInterval() time.Duration {
+	if l.opts.RequestDelay != 0 {
+		return l.opts.RequestDelay
+	}
+
+	if l.opts.RateLimit != 0 {
+		return time.Second * time.Duration(l.opts.Connections) / time.Duration(l.opts.RateLimit)
+	}
+
+	return 0
+}
+
+// correctCoordinatedOmission adds the samples of requests that were not sent while the worker
+// was waiting for the response longer than the expected interval
+func correctCoordinatedOmission(t *tdigest.TDigest, duration, interval time.Duration) error {
+	err := t.Add(float64(duration))
+	if err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		return nil
+	}
+
+	for missing := duration - interval; missing >= interval; missing -= interval {
+		err = t.Add(float64(missing))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (l *Loader) aggregateStat(stat *model.RequestStat, start time.Time, aggStats *[]*model.AggregatedStat) {
 	diff := stat.Start.Sub(start)
 	win := int(diff / l.opts.AggregateWindow)
@@ -141,8 +392,9 @@ func (l *Loader) aggregateStat(stat *model.RequestStat, start time.Time, aggStat
 	if len(*aggStats) <= win {
 		for i := 0; i <= (win - len(*aggStats)); i++ {
 			aggStat := &model.AggregatedStat{
-				Start: start.Add(l.opts.AggregateWindow * time.Duration(win)),
-				End:   start.Add(l.opts.AggregateWindow * time.Duration(win+1)),
+				Start:           start.Add(l.opts.AggregateWindow * time.Duration(win)),
+				End:             start.Add(l.opts.AggregateWindow * time.Duration(win+1)),
+				ConnectionStats: l.requester.Connections(),
 			}
 
 			*aggStats = append(*aggStats, aggStat)
@@ -161,19 +413,53 @@ func (l *Loader) aggregateStat(stat *model.RequestStat, start time.Time, aggStat
 			(*aggStats)[win].MinRequestTime = stat.Duration
 		}
 	}
+	// The averages are the sums until the windows are finished
 	(*aggStats)[win].AvgRequestTime += stat.Duration
+	(*aggStats)[win].AvgDNS += stat.DNS
+	(*aggStats)[win].AvgConnect += stat.Connect
+	(*aggStats)[win].AvgTLS += stat.TLS
+	(*aggStats)[win].AvgTTFB += stat.TTFB
+	(*aggStats)[win].AvgTransfer += stat.Transfer
 	(*aggStats)[win].RequestCount++
 }
 
+// finishAggregatedStats sets the windows durations and turns the sums into the averages.
+// Until then the windows connections counters are the engine counters at the window start.
+func finishAggregatedStats(aggStats []*model.AggregatedStat, end time.Time, connections model.ConnectionStats) {
+	for i, aggStat := range aggStats {
+		if i < len(aggStats)-1 {
+			aggStat.Duration = aggStat.End.Sub(aggStat.Start)
+			aggStat.ConnectionStats = aggStats[i+1].ConnectionStats.Sub(aggStat.ConnectionStats)
+		} else {
+			aggStat.Duration = end.Sub(aggStat.Start)
+			aggStat.ConnectionStats = connections.Sub(aggStat.ConnectionStats)
+		}
+
+		if aggStat.RequestCount == 0 {
+			continue
+		}
+
+		count := time.Duration(aggStat.RequestCount)
+		aggStat.AvgRequestTime /= count
+		aggStat.AvgDNS /= count
+		aggStat.AvgConnect /= count
+		aggStat.AvgTLS /= count
+		aggStat.AvgTTFB /= count
+		aggStat.AvgTransfer /= count
+	}
+}
+
 func (l *Loader) Do(ctx context.Context) (*model.Summary, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	var wg sync.WaitGroup
 
+	l.timedOut.Store(false)
+
 	for i := 0; i < l.opts.Connections; i++ {
 		wg.Add(1)
-		go l.worker(&wg)
+		go l.worker(&wg, i)
 	}
 
 	done := make(chan struct{}, 1)
@@ -183,7 +469,7 @@ func (l *Loader) Do(ctx context.Context) (*model.Summary, error) {
 	errorsMap := make(map[string]int)
 	httpCodes := make(map[int]int)
 
-	var success, fail int
+	var success, fail, late int
 	var dataTransferred int
 
 	start := time.Now().UTC().Truncate(time.Second)
@@ -196,8 +482,9 @@ func (l *Loader) Do(ctx context.Context) (*model.Summary, error) {
 	// We need to create the first aggregated window
 	if l.opts.AggregateWindow != 0 && l.opts.GatherAggregateRequestsStats {
 		aggStat := &model.AggregatedStat{
-			Start: start,
-			End:   start.Add(l.opts.AggregateWindow),
+			Start:           start,
+			End:             start.Add(l.opts.AggregateWindow),
+			ConnectionStats: l.requester.Connections(),
 		}
 		aggStats = append(aggStats, aggStat)
 	}
@@ -206,6 +493,36 @@ func (l *Loader) Do(ctx context.Context) (*model.Summary, error) {
 	if err != nil {
 		return nil, fmt.Errorf("tdigest error: %w", err)
 	}
+
+	var corrected *tdigest.TDigest
+	interval := l.expectedInterval()
+	if l.opts.CorrectCoordinatedOmission {
+		corrected, err = tdigest.New()
+		if err != nil {
+			return nil, fmt.Errorf("tdigest error: %w", err)
+		}
+	}
+
+	phases, err := newPhasesStats()
+	if err != nil {
+		return nil, err
+	}
+
+	var flowStats *endpointStats
+	if len(l.flow) > 0 {
+		flowStats, err = newEndpointStats(&model.Endpoint{Name: "flow", URL: l.opts.URL})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	endpointsStats := make(map[string]*endpointStats, len(l.endpoints))
+	for _, endpoint := range l.endpoints {
+		endpointsStats[endpoint.Name], err = newEndpointStats(endpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
 MAIN:
 	for {
 		select {
@@ -214,10 +531,24 @@ MAIN:
 				break MAIN
 			}
 
-			if l.progressChan != nil {
+			for _, o := range l.observers {
+				o.Observe(stat)
+			}
+
+			// In the flow every request counts as the flow run
+			if l.progressChan != nil && (len(l.flow) == 0 || stat.Flow) {
 				l.progressChan <- struct{}{}
 			}
 
+			if stat.Flow {
+				err = flowStats.add(stat)
+				if err != nil {
+					log.Fatalf("error in flow duration stat: %v", err)
+				}
+
+				continue
+			}
+
 			if minDuration == 0 && maxDuration == 0 {
 				maxDuration = stat.Duration
 				minDuration = stat.Duration
@@ -233,12 +564,35 @@ MAIN:
 
 			avgDuration += stat.Duration
 
+			if stat.Late {
+				late++
+			}
+
 			// TODO(tmwalaszek) this should not really happen so we fatal here at the moment
 			err = t.Add(float64(stat.Duration))
 			if err != nil {
 				log.Fatalf("error in request duration stat: %v", err)
 			}
 
+			if corrected != nil {
+				err = correctCoordinatedOmission(corrected, stat.Duration, interval)
+				if err != nil {
+					log.Fatalf("error in corrected request duration stat: %v", err)
+				}
+			}
+
+			err = phases.add(stat.Phases)
+			if err != nil {
+				log.Fatalf("error in request phases stat: %v", err)
+			}
+
+			if es, ok := endpointsStats[stat.Endpoint]; ok {
+				err = es.add(stat)
+				if err != nil {
+					log.Fatalf("error in endpoint request duration stat: %v", err)
+				}
+			}
+
 			// calculate window
 			if l.opts.AggregateWindow != 0 && l.opts.GatherAggregateRequestsStats {
 				l.aggregateStat(stat, start, &aggStats)
@@ -249,25 +603,22 @@ MAIN:
 					Start:    stat.Start,
 					End:      stat.End,
 					Duration: stat.Duration,
+					Phases:   stat.Phases,
 					RetCode:  stat.RetCode,
 					BodySize: stat.BodySize,
 					Error:    stat.Error,
+					Endpoint: stat.Endpoint,
 				}
 
 				requestsTimes = append(requestsTimes, r)
 			}
 
-			if stat.RetCode >= 200 && stat.RetCode < 300 && stat.Error == "" {
+			if !StatFailed(stat) {
 				success++
 				dataTransferred += stat.BodySize
 			} else {
 				fail++
-				var errString string
-				if stat.Error != "" {
-					errString = stat.Error
-				} else {
-					errString = fasthttp.StatusMessage(stat.RetCode)
-				}
+				errString := StatError(stat)
 
 				if _, ok := errorsMap[errString]; !ok {
 					errorsMap[errString] = 1
@@ -276,7 +627,7 @@ MAIN:
 				}
 			}
 
-			if stat.Error == "" {
+			if StatResponded(stat) {
 				if _, ok := httpCodes[stat.RetCode]; !ok {
 					httpCodes[stat.RetCode] = 1
 				} else {
@@ -298,13 +649,8 @@ MAIN:
 	end := time.Now().UTC().Truncate(time.Second)
 	totalTime := time.Since(start)
 
-	for i := range aggStats {
-		if i < len(aggStats)-1 {
-			aggStats[i].Duration = aggStats[i].End.Sub(aggStats[i].Start)
-		} else {
-			aggStats[i].Duration = end.Sub(aggStats[i].Start)
-		}
-	}
+	connections := l.requester.Connections()
+	finishAggregatedStats(aggStats, end, connections)
 
 	p50 := time.Duration(t.Quantile(0.5))
 	p75 := time.Duration(t.Quantile(0.75))
@@ -318,13 +664,6 @@ MAIN:
 	}
 
 	reqCount := success + fail
-	var reqPerSecond float64
-
-	if totalTime > time.Second {
-		reqPerSecond = float64(success) / (float64(totalTime) / float64(time.Second))
-	} else {
-		reqPerSecond = float64(success)
-	}
 
 	summary := &model.Summary{
 		URL:             l.opts.URL,
@@ -332,10 +671,12 @@ MAIN:
 		End:             end,
 		TotalTime:       totalTime,
 		DataTransferred: dataTransferred,
-		ReqPerSec:       reqPerSecond,
+		ReqPerSec:       reqPerSecond(success, totalTime),
 		ReqCount:        reqCount,
 		SuccessReq:      success,
 		FailReq:         fail,
+		DroppedReq:      int(l.dropped.Load()),
+		LateReq:         late,
 		AvgReqTime:      avgDuration,
 		MinReqTime:      minDuration,
 		MaxReqTime:      maxDuration,
@@ -343,15 +684,45 @@ MAIN:
 		P75ReqTime:      p75,
 		P90ReqTime:      p90,
 		P99ReqTime:      p99,
+		TDigest:         t.ToBytes(nil),
 		Errors:          errorsMap,
 		HTTPCodes:       httpCodes,
+		Phases:          phases.summary(),
+		ConnectionStats: connections,
 		AggregatedStats: aggStats,
 		RequestStats:    requestsTimes,
+		Aborted:         aborted,
+		TimedOut:        l.timedOut.Load(),
+	}
+
+	for _, endpoint := range l.endpoints {
+		summary.Endpoints = append(summary.Endpoints, endpointsStats[endpoint.Name].summary(totalTime))
+	}
+
+	if flowStats != nil {
+		summary.Flow = flowStats.summary(totalTime)
+	}
+
+	if corrected != nil {
+		summary.CorrectedP50ReqTime = time.Duration(corrected.Quantile(0.5))
+		summary.CorrectedP75ReqTime = time.Duration(corrected.Quantile(0.75))
+		summary.CorrectedP90ReqTime = time.Duration(corrected.Quantile(0.9))
+		summary.CorrectedP99ReqTime = time.Duration(corrected.Quantile(0.99))
+		summary.CorrectedTDigest = corrected.ToBytes(nil)
 	}
 
 	return summary, nil
 }
 
+// reqPerSecond returns the successful requests per second, the benchmarks shorter than a second count as one second
+func reqPerSecond(success int, totalTime time.Duration) float64 {
+	if totalTime > time.Second {
+		return float64(success) / (float64(totalTime) / float64(time.Second))
+	}
+
+	return float64(success)
+}
+
 func outputFn[T any](wg *sync.WaitGroup, out chan struct{}, c <-chan T) {
 	for range c {
 		out <- struct{}{}
@@ -381,6 +752,25 @@ func merge(c1, c2 <-chan time.Time, done ...<-chan struct{}) <-chan struct{} {
 	return out
 }
 
+// applyStage adjusts the load to the stages profile level.
+// It returns false when no request should be sent at the moment.
+func (l *Loader) applyStage(level float64, limiter *rate.Limiter) bool {
+	switch l.opts.StageTarget {
+	case model.StageTargetConnections:
+		return l.inFlight.Load() < int64(level)
+	case model.StageTargetRateLimit:
+		l.stageRate.Store(math.Float64bits(max(0, level)))
+		if level <= 0 {
+			return false
+		}
+
+		limiter.SetLimit(rate.Limit(level))
+		limiter.SetBurst(max(1, int(level)))
+	}
+
+	return true
+}
+
 func (l *Loader) manageWorkers(ctx context.Context, done chan struct{}, wg *sync.WaitGroup) {
 	breakAfter := make(<-chan time.Time)
 	if l.opts.Duration != 0 {
@@ -389,7 +779,14 @@ func (l *Loader) manageWorkers(ctx context.Context, done chan struct{}, wg *sync
 
 	benchmarkTimeout := make(<-chan time.Time)
 	if l.opts.BenchmarkTimeout != 0 {
-		benchmarkTimeout = time.After(l.opts.BenchmarkTimeout)
+		timeout := make(chan time.Time, 1)
+		timer := time.AfterFunc(l.opts.BenchmarkTimeout, func() {
+			l.timedOut.Store(true)
+			timeout <- time.Now()
+		})
+		defer timer.Stop()
+
+		benchmarkTimeout = timeout
 	}
 
 	var limiter *rate.Limiter
@@ -397,12 +794,88 @@ func (l *Loader) manageWorkers(ctx context.Context, done chan struct{}, wg *sync
 		limiter = rate.NewLimiter(rate.Limit(l.opts.RateLimit), l.opts.RateLimit)
 	}
 
-	mergedChan := merge(breakAfter, benchmarkTimeout, ctx.Done(), done)
+	if len(l.opts.Stages) > 0 && l.opts.StageTarget == model.StageTargetRateLimit {
+		limiter = rate.NewLimiter(0, 1)
+	}
+
+	mergedChan := merge(breakAfter, benchmarkTimeout, ctx.Done(), done, l.feederExhausted)
+
+	// Open model: requests are scheduled at a fixed arrival rate whether the previous ones finished or not.
+	// When no worker can take the request at its scheduled time it is dropped.
+	runArrivalLoop := func() {
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		start := time.Now()
+		for i := 0; l.opts.ReqCount == 0 || i < l.opts.ReqCount; i++ {
+			scheduled := start.Add(arrivalOffset(i, l.opts.ArrivalRate))
+
+			if wait := time.Until(scheduled); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-mergedChan:
+					return
+				case <-timer.C:
+				}
+			} else {
+				select {
+				case <-mergedChan:
+					return
+				default:
+				}
+			}
+
+			select {
+			case l.reqChan <- scheduled:
+				l.inFlight.Add(1)
+			default:
+				l.dropped.Add(1)
+			}
+		}
+	}
+
+	// waitLimiter waits for the next request allowed by the limiter.
+	// With the stages a wait longer than the stage tick is cancelled and the stage level read again,
+	// a linear stage starting at 0 sets a rate close to 0 whose wait would outlast the stage.
+	waitLimiter := func(start time.Time) error {
+		if len(l.opts.Stages) == 0 {
+			return limiter.Wait(ctx)
+		}
+
+		for {
+			r := limiter.Reserve()
+			if delay := r.Delay(); delay <= stageTick {
+				select {
+				case <-ctx.Done():
+					r.Cancel()
+					return ctx.Err()
+				case <-time.After(delay):
+				}
+
+				return nil
+			}
+
+			r.Cancel()
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(stageTick):
+			}
+
+			// A paused stage is waited out by the requests loop
+			if !l.applyStage(l.opts.Stages.Level(time.Since(start)), limiter) {
+				return nil
+			}
+		}
+	}
 
 	runRequestLoop := func() {
 		ticker := time.NewTicker(time.Millisecond * 50)
 		defer ticker.Stop()
 
+		start := time.Now()
+
 		var i int
 		for {
 			select {
@@ -411,12 +884,23 @@ func (l *Loader) manageWorkers(ctx context.Context, done chan struct{}, wg *sync
 			default:
 			}
 
+			if len(l.opts.Stages) > 0 && !l.applyStage(l.opts.Stages.Level(time.Since(start)), limiter) {
+				select {
+				case <-mergedChan:
+					return
+				case <-time.After(stageTick):
+				}
+
+				continue
+			}
+
 			select {
-			case l.reqChan <- struct{}{}:
+			case l.reqChan <- time.Time{}:
+				l.inFlight.Add(1)
 				if limiter != nil {
 					ok := limiter.Allow()
 					if !ok {
-						err := limiter.Wait(ctx)
+						err := waitLimiter(start)
 						if err != nil {
 							break
 						}
@@ -432,7 +916,11 @@ func (l *Loader) manageWorkers(ctx context.Context, done chan struct{}, wg *sync
 		}
 	}
 
-	runRequestLoop()
+	if l.opts.ArrivalRate != 0 {
+		runArrivalLoop()
+	} else {
+		runRequestLoop()
+	}
 
 	close(l.reqChan)
 
@@ -440,11 +928,37 @@ func (l *Loader) manageWorkers(ctx context.Context, done chan struct{}, wg *sync
 	close(l.statsChan)
 }
 
-func (l *Loader) worker(wg *sync.WaitGroup) {
+// arrivalOffset returns the time the i-th open model request is scheduled at since the benchmark start.
+// It is computed from the request index so the integer interval rounding does not add up.
+func arrivalOffset(i, arrivalRate int) time.Duration {
+	return time.Duration(float64(i) * float64(time.Second) / float64(arrivalRate))
+}
+
+// applySchedule measures the open model latency from the scheduled send time, so the time spent
+// waiting for a free worker is part of it
+func (l *Loader) applySchedule(stat *model.RequestStat, scheduled time.Time) {
+	if !scheduled.IsZero() && !stat.Start.IsZero() {
+		wait := stat.Start.Sub(scheduled)
+		stat.Start = scheduled
+		stat.Duration += wait
+		stat.Late = wait > LateThreshold
+	}
+}
+
+func (l *Loader) worker(wg *sync.WaitGroup, id int) {
 	savedReqTime := time.Time{}
 
+	rc := &renderContext{
+		worker: id,
+	}
+
+	if len(l.flow) > 0 {
+		// vars is the virtual user variables scope, it is kept between the flow runs
+		rc.vars = make(map[string]string)
+	}
+
 	for {
-		_, ok := <-l.reqChan
+		scheduled, ok := <-l.reqChan
 		if !ok {
 			break
 		}
@@ -457,9 +971,43 @@ func (l *Loader) worker(wg *sync.WaitGroup) {
 			}
 		}
 
-		stat := l.requester.Request()
+		if l.feeder != nil {
+			var ok bool
+			rc.row, ok = l.feeder.row()
+			if !ok {
+				select {
+				case l.feederExhausted <- struct{}{}:
+				default:
+				}
+
+				l.inFlight.Add(-1)
+				continue
+			}
+		}
+
+		if len(l.flow) > 0 {
+			l.runFlow(rc, scheduled)
+			savedReqTime = time.Now()
+			continue
+		}
+
+		var stat *model.RequestStat
+
+		t := l.picker.pick()
+		rc.seq = l.seq.Add(1)
+		req, err := t.request(rc)
+		if err != nil {
+			stat = renderErrorStat(t.endpoint, err)
+		} else {
+			req.assertions = l.assertions
+			stat, _ = l.requester.Request(req)
+		}
 		savedReqTime = time.Now()
 
+		l.applySchedule(stat, scheduled)
+
+		l.inFlight.Add(-1)
+
 		l.statsChan <- stat
 	}
 
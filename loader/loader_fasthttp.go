@@ -4,7 +4,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"math/rand"
+	"net/http"
 	"net/url"
 	"time"
 
@@ -15,6 +15,7 @@ import (
 
 type LoaderFastHTTP struct {
 	client *fasthttp.Client
+	dialer *tracedDialer
 	opts   *model.Loader
 }
 
@@ -50,6 +51,10 @@ func NewLoaderFastHTTP(opts *model.Loader) (*LoaderFastHTTP, error) {
 		}
 	}
 
+	dialer := &tracedDialer{
+		connections: &connectionsCounter{},
+		timeout:     opts.Timeout,
+	}
 	client := &fasthttp.Client{
 		Name:                "hload",
 		MaxConnsPerHost:     opts.Connections,
@@ -57,24 +62,26 @@ func NewLoaderFastHTTP(opts *model.Loader) (*LoaderFastHTTP, error) {
 		WriteTimeout:        opts.WriteTimeout,
 		MaxIdleConnDuration: opts.KeepAlive,
 		TLSConfig:           &tlsConfig,
+		ConfigureClient:     dialer.configure,
 	}
 
 	return &LoaderFastHTTP{
 		opts:   opts,
 		client: client,
+		dialer: dialer,
 	}, nil
 }
 
-func (l *LoaderFastHTTP) Request() *model.RequestStat {
+func (l *LoaderFastHTTP) Request(r *Request) (*model.RequestStat, *Response) {
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
 	args := fasthttp.AcquireArgs()
 
-	req.SetRequestURI(l.opts.URL)
-	req.Header.SetMethod(l.opts.Method)
+	req.SetRequestURI(r.URL)
+	req.Header.SetMethod(r.Method)
 
 	// Set all Headers into Request
-	for key, value := range l.opts.Headers {
+	for key, value := range r.Headers {
 		if len(value) > 1 {
 			for _, v := range value {
 				req.Header.Add(key, v)
@@ -85,25 +92,23 @@ func (l *LoaderFastHTTP) Request() *model.RequestStat {
 		}
 	}
 
-	if len(l.opts.Parameters) > 0 {
-		r := rand.Intn(len(l.opts.Parameters))
-
+	if len(r.Parameters) > 0 {
 		// Set args if any
-		for key, value := range l.opts.Parameters[r] {
+		for key, value := range r.Parameters {
 			args.Add(key, value)
 		}
 
-		if l.opts.Method == fasthttp.MethodGet {
+		if r.Method == fasthttp.MethodGet {
 			reqArgs := req.URI().QueryArgs()
 			args.CopyTo(reqArgs)
-		} else if l.opts.Method == fasthttp.MethodPost || l.opts.Method == fasthttp.MethodPut {
+		} else if r.Method == fasthttp.MethodPost || r.Method == fasthttp.MethodPut {
 			reqArgs := req.PostArgs()
 			args.CopyTo(reqArgs)
 		}
 	}
 
-	if len(l.opts.Body) != 0 && (l.opts.Method == fasthttp.MethodPost || l.opts.Method == fasthttp.MethodPut) {
-		req.SetBody(l.opts.Body)
+	if len(r.Body) != 0 && (r.Method == fasthttp.MethodPost || r.Method == fasthttp.MethodPut) {
+		req.SetBody(r.Body)
 	}
 
 	start := time.Now()
@@ -116,21 +121,87 @@ func (l *LoaderFastHTTP) Request() *model.RequestStat {
 
 	statusCode := resp.StatusCode()
 
-	fasthttp.ReleaseRequest(req)
-	fasthttp.ReleaseResponse(resp)
-	fasthttp.ReleaseArgs(args)
-
 	var errorMsg string
 	if err != nil {
 		errorMsg = err.Error()
 	}
 
-	return &model.RequestStat{
+	stat := &model.RequestStat{
 		Start:    start,
 		End:      end,
 		Duration: duration,
 		BodySize: bodySize,
 		RetCode:  statusCode,
 		Error:    errorMsg,
+		Endpoint: r.Name,
+	}
+
+	var response *Response
+	if err == nil {
+		var reused bool
+		stat.Phases, reused = l.dialer.phases(resp, end)
+		if reused {
+			l.dialer.connections.reused.Add(1)
+		}
+
+		if r.assertions != nil {
+			r.assertions.assert(stat, fastHTTPResponseView{resp})
+		}
+
+		if r.KeepResponse {
+			response = newFastHTTPResponse(resp)
+		}
 	}
+
+	fasthttp.ReleaseRequest(req)
+	fasthttp.ReleaseResponse(resp)
+	fasthttp.ReleaseArgs(args)
+
+	return stat, response
+}
+
+func (l *LoaderFastHTTP) Connections() model.ConnectionStats {
+	return l.dialer.connections.stats()
+}
+
+// fastHTTPResponseView exposes the response to the assertions without copying it
+type fastHTTPResponseView struct {
+	resp *fasthttp.Response
+}
+
+func (v fastHTTPResponseView) statusCode() int {
+	return v.resp.StatusCode()
+}
+
+func (v fastHTTPResponseView) header(name string) (string, bool) {
+	value := v.resp.Header.Peek(name)
+	return string(value), value != nil
+}
+
+func (v fastHTTPResponseView) body() []byte {
+	return v.resp.Body()
+}
+
+// newFastHTTPResponse copies the response as it is released after the request
+func newFastHTTPResponse(resp *fasthttp.Response) *Response {
+	response := &Response{
+		StatusCode: resp.StatusCode(),
+		Header:     make(http.Header),
+		Cookies:    make(map[string]string),
+		Body:       append([]byte(nil), resp.Body()...),
+	}
+
+	resp.Header.VisitAll(func(key, value []byte) {
+		response.Header.Add(string(key), string(value))
+	})
+
+	cookie := fasthttp.AcquireCookie()
+	resp.Header.VisitAllCookie(func(key, value []byte) {
+		if cookie.ParseBytes(value) == nil {
+			response.Cookies[string(key)] = string(cookie.Value())
+		}
+	})
+	fasthttp.ReleaseCookie(cookie)
+
+	return response
 }
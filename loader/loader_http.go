@@ -2,13 +2,16 @@ package loader
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"io"
-	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/tmwalaszek/hload/model"
@@ -17,8 +20,9 @@ import (
 )
 
 type LoaderHTTP struct {
-	client *http.Client
-	opts   *model.Loader
+	client      *http.Client
+	connections *connectionsCounter
+	opts        *model.Loader
 }
 
 func NewLoaderHTTP(opts *model.Loader) (*LoaderHTTP, error) {
@@ -53,8 +57,19 @@ func NewLoaderHTTP(opts *model.Loader) (*LoaderHTTP, error) {
 		}
 	}
 
+	connections := &connectionsCounter{}
+	var dialer net.Dialer
+
 	client := &http.Client{
 		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := dialer.DialContext(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+
+				return connections.wrap(conn), nil
+			},
 			MaxConnsPerHost: opts.Connections,
 			IdleConnTimeout: opts.KeepAlive,
 			TLSClientConfig: &tlsConfig,
@@ -63,30 +78,36 @@ func NewLoaderHTTP(opts *model.Loader) (*LoaderHTTP, error) {
 	}
 
 	return &LoaderHTTP{
-		opts:   opts,
-		client: client,
+		opts:        opts,
+		client:      client,
+		connections: connections,
 	}, nil
 }
 
-func (l *LoaderHTTP) Request() *model.RequestStat {
+func (l *LoaderHTTP) Connections() model.ConnectionStats {
+	return l.connections.stats()
+}
+
+func (l *LoaderHTTP) Request(r *Request) (*model.RequestStat, *Response) {
 	var bodyReader *bytes.Reader
 	var req *http.Request
 	var err error
 
-	if len(l.opts.Body) != 0 && (l.opts.Method == fasthttp.MethodPost || l.opts.Method == fasthttp.MethodPut) {
-		bodyReader = bytes.NewReader(l.opts.Body)
-		req, err = http.NewRequest(l.opts.Method, l.opts.URL, bodyReader)
+	if len(r.Body) != 0 && (r.Method == fasthttp.MethodPost || r.Method == fasthttp.MethodPut) {
+		bodyReader = bytes.NewReader(r.Body)
+		req, err = http.NewRequest(r.Method, r.URL, bodyReader)
 	} else {
-		req, err = http.NewRequest(l.opts.Method, l.opts.URL, nil)
+		req, err = http.NewRequest(r.Method, r.URL, nil)
 	}
 
 	if err != nil {
 		return &model.RequestStat{
-			Error: err.Error(),
-		}
+			Error:    err.Error(),
+			Endpoint: r.Name,
+		}, nil
 	}
 
-	for key, value := range l.opts.Headers {
+	for key, value := range r.Headers {
 		if len(value) > 1 {
 			for _, v := range value {
 				req.Header.Set(key, v)
@@ -98,16 +119,17 @@ func (l *LoaderHTTP) Request() *model.RequestStat {
 		}
 	}
 
-	if len(l.opts.Parameters) > 0 {
-		r := rand.Intn(len(l.opts.Parameters))
-
+	if len(r.Parameters) > 0 {
 		q := req.URL.Query()
-		for key, value := range l.opts.Parameters[r] {
+		for key, value := range r.Parameters {
 			q.Add(key, value)
 		}
 		req.URL.RawQuery = q.Encode()
 	}
 
+	tracer := &httpTracer{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.clientTrace()))
+
 	start := time.Now()
 	resp, err := l.client.Do(req)
 	end := time.Now()
@@ -118,28 +140,185 @@ func (l *LoaderHTTP) Request() *model.RequestStat {
 			Start:    start,
 			End:      end,
 			Duration: duration,
+			Phases:   tracer.phases(end),
 			Error:    err.Error(),
-		}
+			Endpoint: r.Name,
+		}, nil
 	}
 
 	defer resp.Body.Close()
 
+	if tracer.reusedConn() {
+		l.connections.reused.Add(1)
+	}
+
+	// The duration ends with the response headers, the body read is only reported in the transfer phase
 	body, err := io.ReadAll(resp.Body)
+	bodyEnd := time.Now()
 	if err != nil {
 		return &model.RequestStat{
 			Start:    start,
 			End:      end,
 			Duration: duration,
+			Phases:   tracer.phases(bodyEnd),
 			RetCode:  resp.StatusCode,
 			Error:    err.Error(),
-		}
+			Endpoint: r.Name,
+		}, nil
 	}
 
-	return &model.RequestStat{
+	stat := &model.RequestStat{
 		Start:    start,
 		End:      end,
 		Duration: duration,
+		Phases:   tracer.phases(bodyEnd),
 		BodySize: len(body),
 		RetCode:  resp.StatusCode,
+		Endpoint: r.Name,
+	}
+
+	if r.assertions != nil {
+		r.assertions.assert(stat, httpResponseView{resp: resp, data: body})
 	}
+
+	var response *Response
+	if r.KeepResponse {
+		response = &Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Cookies:    make(map[string]string),
+			Body:       body,
+		}
+
+		for _, cookie := range resp.Cookies() {
+			response.Cookies[cookie.Name] = cookie.Value
+		}
+	}
+
+	return stat, response
+}
+
+// httpResponseView exposes the response with the read body to the assertions
+type httpResponseView struct {
+	resp *http.Response
+	data []byte
+}
+
+func (v httpResponseView) statusCode() int {
+	return v.resp.StatusCode
+}
+
+func (v httpResponseView) header(name string) (string, bool) {
+	values := v.resp.Header.Values(name)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+func (v httpResponseView) body() []byte {
+	return v.data
+}
+
+// httpTracer records the request phases from the client trace.
+// The transport can dial in its own goroutine so the times are guarded by the mutex.
+type httpTracer struct {
+	mu sync.Mutex
+
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wrote        time.Time
+	firstByte    time.Time
+	reused       bool
+
+	dns     time.Duration
+	connect time.Duration
+	tls     time.Duration
+}
+
+func (t *httpTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.dns = time.Since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			// With many addresses the connect lasts from the first attempt
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			if err == nil {
+				t.connect = time.Since(t.connectStart)
+			}
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			t.mu.Lock()
+			if err == nil {
+				t.tls = time.Since(t.tlsStart)
+			}
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			t.wrote = time.Now()
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.firstByte = time.Now()
+			t.mu.Unlock()
+		},
+	}
+}
+
+func (t *httpTracer) reusedConn() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.reused
+}
+
+// phases returns the phases of the request which ended at end.
+// The connection dialed for the request can be given to another one, only the phases of the used connection are reported.
+func (t *httpTracer) phases(end time.Time) model.Phases {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var phases model.Phases
+	if !t.reused {
+		phases.DNS = t.dns
+		phases.Connect = t.connect
+		phases.TLS = t.tls
+	}
+
+	if !t.wrote.IsZero() && !t.firstByte.IsZero() {
+		phases.TTFB = t.firstByte.Sub(t.wrote)
+		phases.Transfer = end.Sub(t.firstByte)
+	}
+
+	return phases
 }
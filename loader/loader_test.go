@@ -2,8 +2,11 @@ package loader
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"strconv"
@@ -86,6 +89,135 @@ func TestHeaders(t *testing.T) {
 	}
 }
 
+func TestStagesLevel(t *testing.T) {
+	t.Parallel()
+
+	stages := make(model.Stages, 0)
+	for _, s := range []string{"10s:100", "20s:100:step", "10s:50:linear", "5s:10:step"} {
+		err := stages.Set(s)
+		require.Nil(t, err)
+	}
+
+	require.Equal(t, 45*time.Second, stages.Duration())
+	require.Equal(t, 100, stages.MaxTarget())
+
+	var tt = []struct {
+		Elapsed time.Duration
+		Level   float64
+	}{
+		{Elapsed: 0, Level: 0},
+		{Elapsed: 5 * time.Second, Level: 50},
+		{Elapsed: 10 * time.Second, Level: 100},
+		{Elapsed: 25 * time.Second, Level: 100},
+		{Elapsed: 35 * time.Second, Level: 75},
+		{Elapsed: 40 * time.Second, Level: 10},
+		{Elapsed: time.Minute, Level: 10},
+	}
+
+	for _, tc := range tt {
+		require.InDelta(t, tc.Level, stages.Level(tc.Elapsed), 0.001, "elapsed %v", tc.Elapsed)
+	}
+
+	for _, s := range []string{"10s", "10s:a", "x:10", "10s:10:step:1"} {
+		err := stages.Set(s)
+		require.NotNil(t, err, s)
+	}
+}
+
+func TestLoaderStages(t *testing.T) {
+	t.Parallel()
+
+	_, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	u, err := url.JoinPath(ts.URL, "ok")
+	require.Nil(t, err)
+
+	var tt = []struct {
+		Name        string
+		StageTarget string
+		Stages      []string
+		Connections int
+		MinRequests int
+		MaxRequests int
+	}{
+		{
+			Name:        "rate limit steps",
+			StageTarget: model.StageTargetRateLimit,
+			Stages:      []string{"2s:10:step", "1s:30:step"},
+			Connections: 4,
+			MinRequests: 40,
+			MaxRequests: 70,
+		},
+		{
+			Name:        "rate limit ramp up from zero",
+			StageTarget: model.StageTargetRateLimit,
+			Stages:      []string{"3s:60"},
+			Connections: 4,
+			MinRequests: 50,
+			MaxRequests: 130,
+		},
+		{
+			Name:        "connections ramp up and down",
+			StageTarget: model.StageTargetConnections,
+			Stages:      []string{"1s:4", "1s:4:step", "1s:0"},
+			MinRequests: 1,
+		},
+	}
+
+	for _, engine := range httpEngines {
+		for _, tc := range tt {
+			t.Run(fmt.Sprintf("Testcase %s for engine %s", tc.Name, engine), func(t *testing.T) {
+				stages := make(model.Stages, 0)
+				for _, s := range tc.Stages {
+					err := stages.Set(s)
+					require.Nil(t, err)
+				}
+
+				opts := &model.Loader{
+					URL:        u,
+					Method:     "GET",
+					HTTPEngine: engine,
+					Stages:     stages,
+					LoaderReqDetails: model.LoaderReqDetails{
+						StageTarget: tc.StageTarget,
+						Connections: tc.Connections,
+					},
+				}
+
+				loader, err := NewLoader(opts)
+				require.Nil(t, err)
+				require.Equal(t, 3*time.Second, opts.Duration)
+
+				start := time.Now()
+				summary, err := loader.Do(context.Background())
+				require.Nil(t, err)
+				require.Equal(t, 3*time.Second, time.Since(start).Truncate(time.Second))
+				require.GreaterOrEqual(t, summary.ReqCount, tc.MinRequests)
+				if tc.MaxRequests != 0 {
+					require.LessOrEqual(t, summary.ReqCount, tc.MaxRequests)
+				}
+			})
+		}
+	}
+
+	// Connections differing from the connections stages highest target are rejected
+	stages := make(model.Stages, 0)
+	err = stages.Set("1s:4")
+	require.Nil(t, err)
+
+	_, err = NewLoader(&model.Loader{
+		URL:    u,
+		Method: "GET",
+		Stages: stages,
+		LoaderReqDetails: model.LoaderReqDetails{
+			StageTarget: model.StageTargetConnections,
+			Connections: 10,
+		},
+	})
+	require.NotNil(t, err)
+}
+
 func TestMixedRequests(t *testing.T) {
 	t.Parallel()
 
@@ -208,6 +340,872 @@ func TestLoaderOKDurationLimiter(t *testing.T) {
 	}
 }
 
+func TestLoaderEndpoints(t *testing.T) {
+	t.Parallel()
+
+	h, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	for _, engine := range httpEngines {
+		t.Run(fmt.Sprintf("Testcase weighted request mix for engine %s", engine), func(t *testing.T) {
+			opts := &model.Loader{
+				URL:        ts.URL,
+				Method:     "GET",
+				HTTPEngine: engine,
+				Endpoints: model.Endpoints{
+					{Name: "ok", URL: "/ok", Weight: 6},
+					{Name: "body", URL: "/body", Method: "POST", Weight: 3, Body: []byte("endpoint body")},
+					{Name: "missing", URL: "/missing", Weight: 1},
+				},
+				LoaderReqDetails: model.LoaderReqDetails{
+					ReqCount:    1000,
+					Connections: 4,
+				},
+			}
+
+			loader, err := NewLoader(opts)
+			require.Nil(t, err)
+
+			summary, err := loader.Do(context.Background())
+			require.Nil(t, err)
+			require.Equal(t, 1000, summary.ReqCount)
+			require.Len(t, summary.Endpoints, 3)
+
+			var reqCount int
+			for _, endpoint := range summary.Endpoints {
+				reqCount += endpoint.ReqCount
+			}
+			require.Equal(t, summary.ReqCount, reqCount)
+
+			okEndpoint, bodyEndpoint, missingEndpoint := summary.Endpoints[0], summary.Endpoints[1], summary.Endpoints[2]
+			require.Equal(t, ts.URL+"/ok", okEndpoint.URL)
+			require.InDelta(t, 600, okEndpoint.ReqCount, 100)
+			require.InDelta(t, 300, bodyEndpoint.ReqCount, 100)
+			require.InDelta(t, 100, missingEndpoint.ReqCount, 60)
+
+			require.Equal(t, okEndpoint.ReqCount, okEndpoint.SuccessReq)
+			require.Equal(t, okEndpoint.ReqCount, okEndpoint.HTTPCodes[200])
+			require.Equal(t, "POST", bodyEndpoint.Method)
+			require.Equal(t, missingEndpoint.ReqCount, missingEndpoint.FailReq)
+			require.Equal(t, missingEndpoint.ReqCount, missingEndpoint.Errors["Not Found"])
+			require.Equal(t, missingEndpoint.FailReq, summary.FailReq)
+			require.NotZero(t, okEndpoint.P99ReqTime)
+
+			require.Contains(t, h.Body, []byte("endpoint body"))
+		})
+	}
+}
+
+func TestLoaderFlow(t *testing.T) {
+	t.Parallel()
+
+	_, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	var tt = []struct {
+		Name        string
+		Cookie      string
+		FailedStep  string
+		FailedFlows bool
+	}{
+		{
+			Name:   "token and cookie are passed to the next step",
+			Cookie: "session_id=${session}",
+		},
+		{
+			Name:        "profile fails without the session cookie",
+			FailedStep:  "profile",
+			FailedFlows: true,
+		},
+	}
+
+	for _, engine := range httpEngines {
+		for _, tc := range tt {
+			t.Run(fmt.Sprintf("Testcase %s for engine %s", tc.Name, engine), func(t *testing.T) {
+				headers := model.Headers{"Authorization": {"Bearer ${token}"}}
+				if tc.Cookie != "" {
+					headers["Cookie"] = []string{tc.Cookie}
+				}
+
+				opts := &model.Loader{
+					URL:        ts.URL,
+					Method:     "GET",
+					HTTPEngine: engine,
+					Flow: model.Flow{
+						{
+							Endpoint: model.Endpoint{Name: "login", URL: "/login", Method: "POST"},
+							Extract: []*model.Extract{
+								{Variable: "token", Source: model.ExtractSourceJSON, Path: "data.token"},
+								{Variable: "session", Source: model.ExtractSourceCookie, Path: "session_id"},
+							},
+						},
+						{
+							Endpoint: model.Endpoint{Name: "profile", URL: "/profile", Headers: headers},
+						},
+					},
+					LoaderReqDetails: model.LoaderReqDetails{
+						ReqCount:    100,
+						Connections: 4,
+					},
+				}
+
+				loader, err := NewLoader(opts)
+				require.Nil(t, err)
+
+				summary, err := loader.Do(context.Background())
+				require.Nil(t, err)
+
+				require.Equal(t, 200, summary.ReqCount)
+				require.Len(t, summary.Endpoints, 2)
+				require.Equal(t, 100, summary.Endpoints[0].SuccessReq)
+				require.Equal(t, 100, summary.Endpoints[1].ReqCount)
+
+				require.NotNil(t, summary.Flow)
+				require.Equal(t, 100, summary.Flow.ReqCount)
+				require.GreaterOrEqual(t, summary.Flow.MinReqTime, summary.Endpoints[0].MinReqTime)
+
+				if tc.FailedFlows {
+					require.Equal(t, 100, summary.Flow.FailReq)
+					require.Equal(t, 100, summary.Endpoints[1].HTTPCodes[401])
+					require.Equal(t, 100, summary.Flow.Errors[tc.FailedStep+": Unauthorized"])
+				} else {
+					require.Equal(t, 100, summary.Flow.SuccessReq)
+					require.Equal(t, 100, summary.Endpoints[1].SuccessReq)
+				}
+			})
+		}
+	}
+}
+
+func TestLoaderFlowStatusAssertion(t *testing.T) {
+	t.Parallel()
+
+	_, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	for _, engine := range httpEngines {
+		t.Run(fmt.Sprintf("Testcase asserted 404 last step for engine %s", engine), func(t *testing.T) {
+			opts := &model.Loader{
+				URL:        ts.URL,
+				Method:     "GET",
+				HTTPEngine: engine,
+				Flow: model.Flow{
+					{
+						Endpoint: model.Endpoint{Name: "login", URL: "/login", Method: "POST"},
+					},
+					{
+						Endpoint: model.Endpoint{Name: "missing", URL: "/missing"},
+					},
+				},
+				Assertions: model.Assertions{
+					{Type: model.AssertionStatus, Value: "200,404"},
+				},
+				LoaderReqDetails: model.LoaderReqDetails{
+					ReqCount:    50,
+					Connections: 2,
+				},
+			}
+
+			loader, err := NewLoader(opts)
+			require.Nil(t, err)
+
+			summary, err := loader.Do(context.Background())
+			require.Nil(t, err)
+
+			require.Equal(t, 50, summary.Endpoints[1].HTTPCodes[404])
+			require.Equal(t, 50, summary.Endpoints[1].SuccessReq)
+			require.NotNil(t, summary.Flow)
+			require.Equal(t, 50, summary.Flow.SuccessReq)
+			require.Zero(t, summary.Flow.FailReq)
+		})
+	}
+}
+
+func TestFlowVariables(t *testing.T) {
+	vars := map[string]string{"token": "abc", "id": "7"}
+
+	p, err := compilePlaceholders("/items/${id}?token=${token}&x=$y")
+	require.Nil(t, err)
+
+	s, err := p.render(&renderContext{vars: vars})
+	require.Nil(t, err)
+	require.Equal(t, "/items/7?token=abc&x=$y", s)
+
+	// Outside the flow the variables are not expanded
+	s, err = p.render(&renderContext{})
+	require.Nil(t, err)
+	require.Equal(t, "/items/${id}?token=${token}&x=$y", s)
+
+	p, err = compilePlaceholders("${missing}")
+	require.Nil(t, err)
+
+	_, err = p.render(&renderContext{vars: vars})
+	require.NotNil(t, err)
+
+	resp := &Response{
+		Header: http.Header{"X-Request-Id": {"req-1"}},
+		Body:   []byte(`{"data":{"items":[{"id":12345678901234},{"id":2,"ok":true}]}}`),
+	}
+
+	err = extractVariables([]*model.Extract{
+		{Variable: "first", Source: model.ExtractSourceJSON, Path: "data.items.0.id"},
+		{Variable: "ok", Source: model.ExtractSourceJSON, Path: "$.data.items.1.ok"},
+		{Variable: "second", Source: model.ExtractSourceJSON, Path: "data.items.1"},
+		{Variable: "request", Source: model.ExtractSourceHeader, Path: "X-Request-Id"},
+	}, resp, vars)
+	require.Nil(t, err)
+	require.Equal(t, "12345678901234", vars["first"])
+	require.Equal(t, "true", vars["ok"])
+	require.Equal(t, `{"id":2,"ok":true}`, vars["second"])
+	require.Equal(t, "req-1", vars["request"])
+
+	err = extractVariables([]*model.Extract{
+		{Variable: "missing", Source: model.ExtractSourceJSON, Path: "data.items.5.id"},
+	}, resp, vars)
+	require.NotNil(t, err)
+}
+
+func TestPlaceholders(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Input   string
+		Pattern string
+		Err     bool
+	}{
+		{Name: "static string", Input: "plain {body}", Pattern: `^plain \{body\}$`},
+		{Name: "uuid", Input: `{"id":"{{uuid}}"}`, Pattern: `^\{"id":"[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}"\}$`},
+		{Name: "seq and worker", Input: "{{seq}}-{{ worker }}", Pattern: `^42-3$`},
+		{Name: "randInt", Input: "{{randInt 5 7}}", Pattern: `^[5-7]$`},
+		{Name: "randString", Input: "{{randString 32}}", Pattern: `^[a-zA-Z0-9]{32}$`},
+		{Name: "now", Input: "{{now}}", Pattern: `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`},
+		{Name: "now unix", Input: "{{now unix}}", Pattern: `^\d{10}$`},
+		{Name: "unknown placeholder", Input: "{{missing}}", Err: true},
+		{Name: "wrong arguments", Input: "{{randInt 10 1}}", Err: true},
+		{Name: "not closed", Input: "{{uuid", Err: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("Testcase %s", tc.Name), func(t *testing.T) {
+			p, err := compilePlaceholders(tc.Input)
+			if tc.Err {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+
+			for i := 0; i < 10; i++ {
+				s, err := p.render(&renderContext{worker: 3, seq: 42})
+				require.Nil(t, err)
+				require.Regexp(t, tc.Pattern, s)
+			}
+		})
+	}
+}
+
+func TestLoaderPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	h, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	for _, engine := range httpEngines {
+		t.Run(fmt.Sprintf("Testcase placeholders for engine %s", engine), func(t *testing.T) {
+			h.ResetStats()
+
+			opts := &model.Loader{
+				URL:        ts.URL + "/body",
+				Method:     "POST",
+				HTTPEngine: engine,
+				Body:       []byte(`{"id":"{{uuid}}","seq":{{seq}}}`),
+				Headers:    model.Headers{"X-Worker": {"{{worker}}"}},
+				LoaderReqDetails: model.LoaderReqDetails{
+					ReqCount:    100,
+					Connections: 4,
+				},
+			}
+
+			loader, err := NewLoader(opts)
+			require.Nil(t, err)
+
+			summary, err := loader.Do(context.Background())
+			require.Nil(t, err)
+			require.Equal(t, 100, summary.SuccessReq)
+
+			// Every request has its own body
+			require.Len(t, h.Body, 100)
+		})
+	}
+
+	_, err := NewLoader(&model.Loader{
+		URL:        ts.URL,
+		Method:     "GET",
+		HTTPEngine: "http",
+		Body:       []byte("{{unknown}}"),
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    1,
+			Connections: 1,
+		},
+	})
+	require.ErrorContains(t, err, "unknown placeholder unknown")
+}
+
+func TestLoaderFeeder(t *testing.T) {
+	t.Parallel()
+
+	h, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	var csvData, jsonlData strings.Builder
+	csvData.WriteString("id,name\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&csvData, "%d,user%d\n", i, i)
+		fmt.Fprintf(&jsonlData, "{\"id\":%d,\"name\":\"user%d\"}\n", i, i)
+	}
+
+	tt := []struct {
+		Name     string
+		Feeder   *model.Feeder
+		ReqCount int
+		Requests int
+		Bodies   int
+	}{
+		{
+			Name:     "unique rows stop the benchmark",
+			Feeder:   &model.Feeder{Format: model.FeederFormatCSV, Mode: model.FeederModeUnique, Data: []byte(csvData.String())},
+			Requests: 20,
+			Bodies:   20,
+		},
+		{
+			Name:     "sequential rows are reused",
+			Feeder:   &model.Feeder{Format: model.FeederFormatJSONL, Mode: model.FeederModeSequential, Data: []byte(jsonlData.String())},
+			ReqCount: 100,
+			Requests: 100,
+			Bodies:   20,
+		},
+		{
+			Name:     "random rows",
+			Feeder:   &model.Feeder{Format: model.FeederFormatCSV, Mode: model.FeederModeRandom, Data: []byte(csvData.String())},
+			ReqCount: 100,
+			Requests: 100,
+		},
+	}
+
+	for _, engine := range httpEngines {
+		for _, tc := range tt {
+			t.Run(fmt.Sprintf("Testcase %s for engine %s", tc.Name, engine), func(t *testing.T) {
+				h.ResetStats()
+
+				opts := &model.Loader{
+					URL:        ts.URL + "/body?id={{feed id}}",
+					Method:     "POST",
+					HTTPEngine: engine,
+					Body:       []byte("name={{feed name}}"),
+					Headers:    model.Headers{"X-User": {"{{feed id}}"}},
+					Feeder:     tc.Feeder,
+					LoaderReqDetails: model.LoaderReqDetails{
+						ReqCount:    tc.ReqCount,
+						Connections: 4,
+					},
+				}
+
+				loader, err := NewLoader(opts)
+				require.Nil(t, err)
+
+				summary, err := loader.Do(context.Background())
+				require.Nil(t, err)
+				require.Equal(t, tc.Requests, summary.SuccessReq)
+
+				if tc.Bodies != 0 {
+					require.Len(t, h.Body, tc.Bodies)
+				} else {
+					require.LessOrEqual(t, len(h.Body), 20)
+				}
+				require.Contains(t, h.Body, []byte("name=user7"))
+			})
+		}
+	}
+
+	_, err := NewLoader(&model.Loader{
+		URL:        ts.URL + "/{{feed missing}}",
+		Method:     "GET",
+		HTTPEngine: "http",
+		Feeder:     &model.Feeder{Format: model.FeederFormatCSV, Mode: model.FeederModeSequential, Data: []byte(csvData.String())},
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    1,
+			Connections: 1,
+		},
+	})
+	require.ErrorContains(t, err, "feeder has no column missing")
+
+	_, err = NewLoader(&model.Loader{
+		URL:        ts.URL + "/{{feed id}}",
+		Method:     "GET",
+		HTTPEngine: "http",
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    1,
+			Connections: 1,
+		},
+	})
+	require.ErrorContains(t, err, "feeder is not set")
+}
+
+func TestLoaderAssertions(t *testing.T) {
+	t.Parallel()
+
+	var tt = []struct {
+		Name       string
+		Path       string
+		Assertions []string
+		Success    int
+		Errors     map[string]int
+		HTTPCodes  map[int]int
+	}{
+		{
+			Name:       "all assertions met",
+			Path:       "login",
+			Assertions: []string{"json:$.data.user.id", "header:Content-Type=application/json", "body_regexp:token-[0-9]+", "max_body_size:1024"},
+			Success:    10,
+			Errors:     map[string]int{},
+			HTTPCodes:  map[int]int{200: 10},
+		},
+		{
+			Name:       "json value",
+			Path:       "login",
+			Assertions: []string{"json:data.token=token-1"},
+			Success:    1,
+			Errors:     map[string]int{`assertion failed: json data.token == "token-1"`: 9},
+			HTTPCodes:  map[int]int{200: 10},
+		},
+		{
+			Name:       "status set",
+			Path:       "mixed",
+			Assertions: []string{"status:200,4xx"},
+			Success:    10,
+			Errors:     map[string]int{},
+			HTTPCodes:  map[int]int{200: 5, 404: 5},
+		},
+		{
+			Name:       "not 2xx responses are not checked",
+			Path:       "mixed",
+			Assertions: []string{"body:OK"},
+			Success:    0,
+			Errors:     map[string]int{"Not Found": 5, `assertion failed: body contains "OK"`: 5},
+			HTTPCodes:  map[int]int{200: 5, 404: 5},
+		},
+		{
+			Name:       "body size",
+			Path:       "ok",
+			Assertions: []string{"max_body_size:1", "header:X-Missing"},
+			Success:    0,
+			Errors:     map[string]int{"assertion failed: body size <= 1": 10},
+			HTTPCodes:  map[int]int{200: 10},
+		},
+	}
+
+	for _, engine := range httpEngines {
+		for _, tc := range tt {
+			t.Run(fmt.Sprintf("Testcase %s for engine %s", tc.Name, engine), func(t *testing.T) {
+				_, ts := mock.NewServer(5)
+				defer ts.Close()
+
+				u, err := url.JoinPath(ts.URL, tc.Path)
+				require.Nil(t, err)
+
+				var assertions model.Assertions
+				for _, assertion := range tc.Assertions {
+					require.Nil(t, assertions.Set(assertion))
+				}
+
+				opts := &model.Loader{
+					URL:        u,
+					HTTPEngine: engine,
+					Method:     "GET",
+					Assertions: assertions,
+					LoaderReqDetails: model.LoaderReqDetails{
+						ReqCount:    10,
+						Connections: 1,
+					},
+				}
+				loader, err := NewLoader(opts)
+				require.Nil(t, err)
+
+				summary, err := loader.Do(context.Background())
+				require.Nil(t, err)
+				require.Equal(t, 10, summary.ReqCount)
+				require.Equal(t, tc.Success, summary.SuccessReq)
+				require.Equal(t, 10-tc.Success, summary.FailReq)
+				require.Equal(t, tc.Errors, summary.Errors)
+				require.Equal(t, tc.HTTPCodes, summary.HTTPCodes)
+			})
+		}
+	}
+
+	for _, assertion := range []string{"status:2x", "body_regexp:[", "max_body_size:big", "json:=x"} {
+		var assertions model.Assertions
+		require.Nil(t, assertions.Set(assertion))
+
+		_, err := NewLoader(&model.Loader{
+			URL:        "http://127.0.0.1",
+			HTTPEngine: "http",
+			Method:     "GET",
+			Assertions: assertions,
+			LoaderReqDetails: model.LoaderReqDetails{
+				ReqCount:    1,
+				Connections: 1,
+			},
+		})
+		require.ErrorContains(t, err, "assertion "+assertion)
+	}
+
+	var assertions model.Assertions
+	require.ErrorIs(t, assertions.Set("unknown:1"), model.ErrWrongAssertionFormat)
+	require.ErrorIs(t, assertions.Set("body:"), model.ErrWrongAssertionFormat)
+}
+
+func TestLoaderPhases(t *testing.T) {
+	t.Parallel()
+
+	for _, engine := range httpEngines {
+		t.Run(fmt.Sprintf("Testcase slow server for engine %s", engine), func(t *testing.T) {
+			_, ts := mock.NewServer(0)
+			defer ts.Close()
+
+			u, err := url.JoinPath(ts.URL, "slow")
+			require.Nil(t, err)
+
+			opts := &model.Loader{
+				URL:                          u,
+				Method:                       "GET",
+				HTTPEngine:                   engine,
+				AggregateWindow:              time.Minute,
+				GatherFullRequestsStats:      true,
+				GatherAggregateRequestsStats: true,
+				LoaderReqDetails: model.LoaderReqDetails{
+					ReqCount:    10,
+					Connections: 2,
+				},
+			}
+
+			loader, err := NewLoader(opts)
+			require.Nil(t, err)
+
+			summary, err := loader.Do(context.Background())
+			require.Nil(t, err)
+			require.Equal(t, 10, summary.SuccessReq)
+
+			// The server is an IP address so there is no DNS lookup and no TLS
+			phases := make(map[string]*model.PhaseSummary)
+			for _, phase := range summary.Phases {
+				phases[phase.Name] = phase
+			}
+			require.Len(t, phases, 3)
+
+			require.GreaterOrEqual(t, phases[model.PhaseConnect].ReqCount, 1)
+			require.LessOrEqual(t, phases[model.PhaseConnect].ReqCount, 2)
+			require.Equal(t, 10, phases[model.PhaseTTFB].ReqCount)
+			require.GreaterOrEqual(t, phases[model.PhaseTTFB].P50Time, 200*time.Millisecond)
+			require.Equal(t, 10, phases[model.PhaseTransfer].ReqCount)
+
+			require.Len(t, summary.RequestStats, 10)
+			for _, stat := range summary.RequestStats {
+				require.GreaterOrEqual(t, stat.TTFB, 200*time.Millisecond)
+				// The net/http duration ends with the response headers, before the body transfer
+				require.LessOrEqual(t, stat.Connect+stat.TTFB, stat.Duration)
+			}
+
+			require.Len(t, summary.AggregatedStats, 1)
+			require.GreaterOrEqual(t, summary.AggregatedStats[0].AvgTTFB, 200*time.Millisecond)
+			require.LessOrEqual(t, summary.AggregatedStats[0].AvgRequestTime, summary.MaxReqTime)
+		})
+	}
+}
+
+func TestLoaderDNSCache(t *testing.T) {
+	t.Parallel()
+
+	_, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	server, err := url.Parse(ts.URL)
+	require.Nil(t, err)
+
+	opts := &model.Loader{
+		URL:                     fmt.Sprintf("http://localhost:%s/close", server.Port()),
+		Method:                  "GET",
+		HTTPEngine:              "fast_http",
+		GatherFullRequestsStats: true,
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    10,
+			Connections: 1,
+		},
+	}
+
+	loader, err := NewLoader(opts)
+	require.Nil(t, err)
+
+	summary, err := loader.Do(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 10, summary.SuccessReq)
+
+	phases := make(map[string]*model.PhaseSummary)
+	for _, phase := range summary.Phases {
+		phases[phase.Name] = phase
+	}
+
+	// Every request opens a new connection but only the first one resolves the host
+	require.Equal(t, 10, phases[model.PhaseConnect].ReqCount)
+	require.Equal(t, 1, phases[model.PhaseDNS].ReqCount)
+}
+
+func TestLoaderTLSServerName(t *testing.T) {
+	t.Parallel()
+
+	for _, engine := range httpEngines {
+		t.Run(fmt.Sprintf("Testcase skip verify for engine %s", engine), func(t *testing.T) {
+			var serverNames sync.Map
+
+			ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			ts.TLS = &tls.Config{
+				GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+					serverNames.Store(hello.ServerName, true)
+					return nil, nil
+				},
+			}
+			ts.StartTLS()
+			defer ts.Close()
+
+			server, err := url.Parse(ts.URL)
+			require.Nil(t, err)
+
+			opts := &model.Loader{
+				URL:        fmt.Sprintf("https://localhost:%s/", server.Port()),
+				Method:     "GET",
+				HTTPEngine: engine,
+				SkipVerify: true,
+				LoaderReqDetails: model.LoaderReqDetails{
+					ReqCount:    2,
+					Connections: 1,
+				},
+			}
+
+			loader, err := NewLoader(opts)
+			require.Nil(t, err)
+
+			summary, err := loader.Do(context.Background())
+			require.Nil(t, err)
+			require.Equal(t, 2, summary.SuccessReq)
+
+			// The server name is sent without the certificate verification too
+			_, ok := serverNames.Load("localhost")
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestLoaderConnections(t *testing.T) {
+	t.Parallel()
+
+	var tt = []struct {
+		Name     string
+		Endpoint string
+		Check    func(t *testing.T, summary *model.Summary)
+	}{
+		{
+			Name:     "keep alive",
+			Endpoint: "ok",
+			Check: func(t *testing.T, summary *model.Summary) {
+				require.Equal(t, 20, summary.SuccessReq)
+				require.GreaterOrEqual(t, summary.NewConnections, 1)
+				require.LessOrEqual(t, summary.NewConnections, 2)
+				// net/http counts the connection dialed for the request which got the idle one as reused
+				require.GreaterOrEqual(t, summary.ReusedConnections, 20-summary.NewConnections)
+				require.Less(t, summary.ReusedConnections, 20)
+				require.Equal(t, 0, summary.ResetConnections)
+			},
+		},
+		{
+			Name:     "connection close",
+			Endpoint: "close",
+			Check: func(t *testing.T, summary *model.Summary) {
+				require.Equal(t, 20, summary.SuccessReq)
+				require.Equal(t, 20, summary.NewConnections)
+				require.Equal(t, 0, summary.ReusedConnections)
+				require.GreaterOrEqual(t, summary.ClosedConnections, 18)
+			},
+		},
+		{
+			Name:     "connection reset",
+			Endpoint: "reset",
+			Check: func(t *testing.T, summary *model.Summary) {
+				require.Equal(t, 20, summary.FailReq)
+				require.GreaterOrEqual(t, summary.ResetConnections, 1)
+				require.Equal(t, 0, summary.ReusedConnections)
+			},
+		},
+	}
+
+	for _, engine := range httpEngines {
+		for _, tc := range tt {
+			t.Run(fmt.Sprintf("Testcase %s for engine %s", tc.Name, engine), func(t *testing.T) {
+				_, ts := mock.NewServer(0)
+				defer ts.Close()
+
+				u, err := url.JoinPath(ts.URL, tc.Endpoint)
+				require.Nil(t, err)
+
+				opts := &model.Loader{
+					URL:                          u,
+					Method:                       "GET",
+					HTTPEngine:                   engine,
+					AggregateWindow:              time.Minute,
+					GatherAggregateRequestsStats: true,
+					LoaderReqDetails: model.LoaderReqDetails{
+						ReqCount:    20,
+						Connections: 2,
+					},
+				}
+
+				loader, err := NewLoader(opts)
+				require.Nil(t, err)
+
+				summary, err := loader.Do(context.Background())
+				require.Nil(t, err)
+				tc.Check(t, summary)
+
+				require.Len(t, summary.AggregatedStats, 1)
+				require.Equal(t, summary.ConnectionStats, summary.AggregatedStats[0].ConnectionStats)
+			})
+		}
+	}
+}
+
+func TestLoaderOpenModel(t *testing.T) {
+	t.Parallel()
+
+	_, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	var tt = []struct {
+		Name        string
+		Path        string
+		Duration    time.Duration
+		Connections int
+		ArrivalRate int
+		Dropped     bool
+	}{
+		{
+			Name:        "fast server keeps up with the arrival rate",
+			Path:        "ok",
+			Duration:    time.Second * 3,
+			Connections: 4,
+			ArrivalRate: 20,
+			Dropped:     false,
+		},
+		{
+			Name:        "slow server can't keep up with the arrival rate",
+			Path:        "slow",
+			Duration:    time.Second * 2,
+			Connections: 2,
+			ArrivalRate: 20,
+			Dropped:     true,
+		},
+	}
+
+	for _, engine := range httpEngines {
+		for _, tc := range tt {
+			t.Run(fmt.Sprintf("Testcase %s for engine %s", tc.Name, engine), func(t *testing.T) {
+				u, err := url.JoinPath(ts.URL, tc.Path)
+				require.Nil(t, err)
+
+				opts := &model.Loader{
+					URL:        u,
+					Method:     "GET",
+					HTTPEngine: engine,
+					LoaderReqDetails: model.LoaderReqDetails{
+						Duration:    tc.Duration,
+						Connections: tc.Connections,
+						ArrivalRate: tc.ArrivalRate,
+					},
+				}
+
+				loader, err := NewLoader(opts)
+				require.Nil(t, err)
+
+				summary, err := loader.Do(context.Background())
+				require.Nil(t, err)
+
+				scheduled := tc.ArrivalRate * int(tc.Duration/time.Second)
+				require.InDelta(t, scheduled, summary.ReqCount+summary.DroppedReq, 2)
+
+				if tc.Dropped {
+					require.Greater(t, summary.DroppedReq, 0)
+					require.Greater(t, summary.LateReq, 0)
+					// Latency includes the time waited for the busy worker
+					require.Greater(t, summary.MaxReqTime, 200*time.Millisecond)
+				} else {
+					require.Equal(t, 0, summary.DroppedReq)
+				}
+			})
+		}
+	}
+}
+
+func TestArrivalOffset(t *testing.T) {
+	require.Equal(t, time.Duration(0), arrivalOffset(0, 3))
+	require.Equal(t, time.Second, arrivalOffset(3, 3))
+	require.Equal(t, 333333333*time.Nanosecond, arrivalOffset(1, 3))
+	// The integer interval of the rate above 1e9 is zero
+	require.Equal(t, time.Second, arrivalOffset(2_000_000_000, 2_000_000_000))
+}
+
+func TestLoaderCoordinatedOmission(t *testing.T) {
+	t.Parallel()
+
+	for _, engine := range httpEngines {
+		t.Run(fmt.Sprintf("Testcase stalled server for engine %s", engine), func(t *testing.T) {
+			_, ts := mock.NewServer(0)
+			defer ts.Close()
+
+			u, err := url.JoinPath(ts.URL, "stall")
+			require.Nil(t, err)
+
+			opts := &model.Loader{
+				URL:                        u,
+				Method:                     "GET",
+				HTTPEngine:                 engine,
+				CorrectCoordinatedOmission: true,
+				LoaderReqDetails: model.LoaderReqDetails{
+					ReqCount:    30,
+					Connections: 1,
+					RateLimit:   10,
+				},
+			}
+
+			loader, err := NewLoader(opts)
+			require.Nil(t, err)
+
+			summary, err := loader.Do(context.Background())
+			require.Nil(t, err)
+			require.Less(t, summary.P75ReqTime, 100*time.Millisecond)
+			require.Greater(t, summary.CorrectedP75ReqTime, 100*time.Millisecond)
+		})
+	}
+
+	_, err := NewLoader(&model.Loader{
+		URL:                        "http://127.0.0.1",
+		Method:                     "GET",
+		HTTPEngine:                 HTTPEngine,
+		CorrectCoordinatedOmission: true,
+		LoaderReqDetails: model.LoaderReqDetails{
+			ReqCount:    1,
+			Connections: 1,
+		},
+	})
+	require.NotNil(t, err)
+}
+
 func TestLoaderOKDelay(t *testing.T) {
 	t.Parallel()
 
@@ -516,10 +1514,12 @@ func TestLoaderBenchmarkTimeout(t *testing.T) {
 				require.Nil(t, err)
 
 				s := time.Now()
-				_, err = loader.Do(context.Background())
+				summary, err := loader.Do(context.Background())
 				d := time.Since(s)
 				require.Nil(t, err)
 				require.LessOrEqual(t, d/1e6, tc.BenchmarkTimeout)
+				require.True(t, summary.TimedOut)
+				require.False(t, summary.Aborted)
 			})
 		}
 	}
@@ -569,6 +1569,8 @@ func TestLoaderAbortRequest(t *testing.T) {
 				require.Nil(t, err)
 				require.Equal(t, summary.ReqCount, int(handler.Stats.RequestCount))
 				require.LessOrEqual(t, summary.ReqCount, tc.Abort+(tc.Connections*2)+1)
+				require.True(t, summary.Aborted)
+				require.False(t, summary.TimedOut)
 			})
 		}
 	}
@@ -0,0 +1,466 @@
+package loader
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/caio/go-tdigest/v4"
+)
+
+// MergeSummaries merges the summaries of the benchmarks run side by side into one summary.
+// The counters and maps are summed, the averages weighted and the percentiles taken from the merged t-digests,
+// a summary without the t-digest contributes its requests stats, when it has none the percentiles
+// of the summary with the most requests are kept.
+func MergeSummaries(summaries []*model.Summary) (*model.Summary, error) {
+	return mergeSummaries(summaries, false)
+}
+
+// MergeSequentialSummaries merges the summaries of the benchmarks run one after another into one summary.
+// It merges like MergeSummaries but the total time is the sum of the summaries total times.
+func MergeSequentialSummaries(summaries []*model.Summary) (*model.Summary, error) {
+	return mergeSummaries(summaries, true)
+}
+
+// SummariesOverlap reports whether any two summaries were running at the same time
+func SummariesOverlap(summaries []*model.Summary) bool {
+	for i, a := range summaries {
+		for _, b := range summaries[i+1:] {
+			if a.Start.Before(b.End) && b.Start.Before(a.End) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func mergeSummaries(summaries []*model.Summary, sequential bool) (*model.Summary, error) {
+	if len(summaries) == 0 {
+		return nil, fmt.Errorf("no summaries to merge")
+	}
+
+	merged := &model.Summary{
+		URL:         summaries[0].URL,
+		Description: summaries[0].Description,
+		Errors:      make(map[string]int),
+		HTTPCodes:   make(map[int]int),
+	}
+
+	digests := make([][]byte, 0, len(summaries))
+	correctedDigests := make([][]byte, 0, len(summaries))
+
+	var totalDuration time.Duration
+	var flows []*model.EndpointSummary
+	var endpoints [][]*model.EndpointSummary
+	var phaseSummaries [][]*model.PhaseSummary
+	var aggStats []*model.AggregatedStat
+	var busiest *model.Summary
+
+	for _, s := range summaries {
+		if merged.Start.IsZero() || s.Start.Before(merged.Start) {
+			merged.Start = s.Start
+		}
+
+		if s.End.After(merged.End) {
+			merged.End = s.End
+		}
+
+		if sequential {
+			merged.TotalTime += s.TotalTime
+		} else {
+			merged.TotalTime = max(merged.TotalTime, s.TotalTime)
+		}
+
+		merged.ReqCount += s.ReqCount
+		merged.SuccessReq += s.SuccessReq
+		merged.FailReq += s.FailReq
+		merged.DroppedReq += s.DroppedReq
+		merged.LateReq += s.LateReq
+		merged.DataTransferred += s.DataTransferred
+		merged.Aborted = merged.Aborted || s.Aborted
+		merged.TimedOut = merged.TimedOut || s.TimedOut
+
+		merged.NewConnections += s.NewConnections
+		merged.ReusedConnections += s.ReusedConnections
+		merged.ClosedConnections += s.ClosedConnections
+		merged.ResetConnections += s.ResetConnections
+
+		// The summary average is the requests durations sum over the successful requests
+		totalDuration += s.AvgReqTime * time.Duration(s.SuccessReq)
+
+		if s.ReqCount != 0 {
+			if merged.MinReqTime == 0 || s.MinReqTime < merged.MinReqTime {
+				merged.MinReqTime = s.MinReqTime
+			}
+
+			merged.MaxReqTime = max(merged.MaxReqTime, s.MaxReqTime)
+		}
+
+		if busiest == nil || s.ReqCount > busiest.ReqCount {
+			busiest = s
+		}
+
+		for k, v := range s.Errors {
+			merged.Errors[k] += v
+		}
+
+		for k, v := range s.HTTPCodes {
+			merged.HTTPCodes[k] += v
+		}
+
+		digest, err := summaryDigest(s)
+		if err != nil {
+			return nil, err
+		}
+
+		digests = append(digests, digest)
+		if s.CorrectedTDigest != nil {
+			correctedDigests = append(correctedDigests, s.CorrectedTDigest)
+		}
+
+		if s.Flow != nil {
+			flows = append(flows, s.Flow)
+		}
+
+		endpoints = append(endpoints, s.Endpoints)
+		phaseSummaries = append(phaseSummaries, s.Phases)
+		aggStats = append(aggStats, s.AggregatedStats...)
+		merged.RequestStats = append(merged.RequestStats, s.RequestStats...)
+	}
+
+	if merged.SuccessReq != 0 {
+		merged.AvgReqTime = totalDuration / time.Duration(merged.SuccessReq)
+	}
+
+	merged.ReqPerSec = reqPerSecond(merged.SuccessReq, merged.TotalTime)
+
+	t, err := mergeDigests(digests)
+	if err != nil {
+		return nil, err
+	}
+
+	if t != nil {
+		merged.P50ReqTime = time.Duration(t.Quantile(0.5))
+		merged.P75ReqTime = time.Duration(t.Quantile(0.75))
+		merged.P90ReqTime = time.Duration(t.Quantile(0.9))
+		merged.P99ReqTime = time.Duration(t.Quantile(0.99))
+		merged.TDigest = t.ToBytes(nil)
+	} else {
+		merged.P50ReqTime = busiest.P50ReqTime
+		merged.P75ReqTime = busiest.P75ReqTime
+		merged.P90ReqTime = busiest.P90ReqTime
+		merged.P99ReqTime = busiest.P99ReqTime
+	}
+
+	if len(correctedDigests) == len(summaries) {
+		corrected, err := mergeDigests(correctedDigests)
+		if err != nil {
+			return nil, err
+		}
+
+		merged.CorrectedP50ReqTime = time.Duration(corrected.Quantile(0.5))
+		merged.CorrectedP75ReqTime = time.Duration(corrected.Quantile(0.75))
+		merged.CorrectedP90ReqTime = time.Duration(corrected.Quantile(0.9))
+		merged.CorrectedP99ReqTime = time.Duration(corrected.Quantile(0.99))
+		merged.CorrectedTDigest = corrected.ToBytes(nil)
+	}
+
+	merged.Endpoints, err = mergeEndpointsSummaries(endpoints, merged.TotalTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(flows) != 0 {
+		flow, err := mergeEndpointsSummaries([][]*model.EndpointSummary{flows}, merged.TotalTime)
+		if err != nil {
+			return nil, err
+		}
+
+		merged.Flow = flow[0]
+	}
+
+	merged.Phases, err = mergePhasesSummaries(phaseSummaries)
+	if err != nil {
+		return nil, err
+	}
+
+	merged.AggregatedStats = mergeAggregatedStats(aggStats)
+
+	sort.SliceStable(merged.RequestStats, func(i, j int) bool {
+		return merged.RequestStats[i].Start.Before(merged.RequestStats[j].Start)
+	})
+
+	return merged, nil
+}
+
+// summaryDigest returns the summary t-digest, or the t-digest of the requests stats durations
+// for the summaries saved before the t-digest was kept, nil when the summary has neither
+func summaryDigest(s *model.Summary) ([]byte, error) {
+	if s.TDigest != nil || len(s.RequestStats) == 0 {
+		return s.TDigest, nil
+	}
+
+	t, err := tdigest.New()
+	if err != nil {
+		return nil, fmt.Errorf("tdigest error: %w", err)
+	}
+
+	for _, stat := range s.RequestStats {
+		err = t.Add(float64(stat.Duration))
+		if err != nil {
+			return nil, fmt.Errorf("tdigest error: %w", err)
+		}
+	}
+
+	return t.ToBytes(nil), nil
+}
+
+// mergeDigests merges the serialized t-digests, it returns nil when any of them is missing
+func mergeDigests(digests [][]byte) (*tdigest.TDigest, error) {
+	merged, err := tdigest.New()
+	if err != nil {
+		return nil, fmt.Errorf("tdigest error: %w", err)
+	}
+
+	for _, digest := range digests {
+		if digest == nil {
+			return nil, nil
+		}
+
+		t, err := tdigest.New()
+		if err != nil {
+			return nil, fmt.Errorf("tdigest error: %w", err)
+		}
+
+		err = t.FromBytes(digest)
+		if err != nil {
+			return nil, fmt.Errorf("tdigest error: %w", err)
+		}
+
+		err = merged.Merge(t)
+		if err != nil {
+			return nil, fmt.Errorf("tdigest error: %w", err)
+		}
+	}
+
+	return merged, nil
+}
+
+// mergeEndpointsSummaries merges the endpoints summaries with the same name keeping the first summary order
+func mergeEndpointsSummaries(summaries [][]*model.EndpointSummary, totalTime time.Duration) ([]*model.EndpointSummary, error) {
+	var merged []*model.EndpointSummary
+	byName := make(map[string][]*model.EndpointSummary)
+
+	for _, endpoints := range summaries {
+		for _, e := range endpoints {
+			if _, ok := byName[e.Name]; !ok {
+				merged = append(merged, &model.EndpointSummary{
+					Name:      e.Name,
+					URL:       e.URL,
+					Method:    e.Method,
+					Errors:    make(map[string]int),
+					HTTPCodes: make(map[int]int),
+				})
+			}
+
+			byName[e.Name] = append(byName[e.Name], e)
+		}
+	}
+
+	for _, m := range merged {
+		var totalDuration time.Duration
+		var busiest *model.EndpointSummary
+		digests := make([][]byte, 0, len(byName[m.Name]))
+
+		for _, e := range byName[m.Name] {
+			if busiest == nil || e.ReqCount > busiest.ReqCount {
+				busiest = e
+			}
+
+			m.ReqCount += e.ReqCount
+			m.SuccessReq += e.SuccessReq
+			m.FailReq += e.FailReq
+			m.DataTransferred += e.DataTransferred
+
+			totalDuration += e.AvgReqTime * time.Duration(e.ReqCount)
+
+			if e.ReqCount != 0 {
+				if m.MinReqTime == 0 || e.MinReqTime < m.MinReqTime {
+					m.MinReqTime = e.MinReqTime
+				}
+
+				m.MaxReqTime = max(m.MaxReqTime, e.MaxReqTime)
+			}
+
+			for k, v := range e.Errors {
+				m.Errors[k] += v
+			}
+
+			for k, v := range e.HTTPCodes {
+				m.HTTPCodes[k] += v
+			}
+
+			digests = append(digests, e.TDigest)
+		}
+
+		m.ReqPerSec = reqPerSecond(m.SuccessReq, totalTime)
+		if m.ReqCount == 0 {
+			continue
+		}
+
+		m.AvgReqTime = totalDuration / time.Duration(m.ReqCount)
+
+		t, err := mergeDigests(digests)
+		if err != nil {
+			return nil, err
+		}
+
+		if t != nil {
+			m.P50ReqTime = time.Duration(t.Quantile(0.5))
+			m.P75ReqTime = time.Duration(t.Quantile(0.75))
+			m.P90ReqTime = time.Duration(t.Quantile(0.9))
+			m.P99ReqTime = time.Duration(t.Quantile(0.99))
+			m.TDigest = t.ToBytes(nil)
+		} else {
+			// The saved endpoints summaries have no t-digests
+			m.P50ReqTime = busiest.P50ReqTime
+			m.P75ReqTime = busiest.P75ReqTime
+			m.P90ReqTime = busiest.P90ReqTime
+			m.P99ReqTime = busiest.P99ReqTime
+		}
+	}
+
+	return merged, nil
+}
+
+// mergePhasesSummaries merges the phases summaries in the phases order
+func mergePhasesSummaries(summaries [][]*model.PhaseSummary) ([]*model.PhaseSummary, error) {
+	var merged []*model.PhaseSummary
+
+	for _, name := range []string{model.PhaseDNS, model.PhaseConnect, model.PhaseTLS, model.PhaseTTFB, model.PhaseTransfer} {
+		m := &model.PhaseSummary{Name: name}
+
+		var totalDuration time.Duration
+		var busiest *model.PhaseSummary
+		var digests [][]byte
+		for _, phases := range summaries {
+			for _, p := range phases {
+				if p.Name != name || p.ReqCount == 0 {
+					continue
+				}
+
+				if busiest == nil || p.ReqCount > busiest.ReqCount {
+					busiest = p
+				}
+
+				if m.ReqCount == 0 || p.MinTime < m.MinTime {
+					m.MinTime = p.MinTime
+				}
+
+				m.MaxTime = max(m.MaxTime, p.MaxTime)
+				m.ReqCount += p.ReqCount
+				totalDuration += p.AvgTime * time.Duration(p.ReqCount)
+				digests = append(digests, p.TDigest)
+			}
+		}
+
+		if m.ReqCount == 0 {
+			continue
+		}
+
+		m.AvgTime = totalDuration / time.Duration(m.ReqCount)
+
+		t, err := mergeDigests(digests)
+		if err != nil {
+			return nil, err
+		}
+
+		if t != nil {
+			m.P50Time = time.Duration(t.Quantile(0.5))
+			m.P75Time = time.Duration(t.Quantile(0.75))
+			m.P90Time = time.Duration(t.Quantile(0.9))
+			m.P99Time = time.Duration(t.Quantile(0.99))
+			m.TDigest = t.ToBytes(nil)
+		} else {
+			// The saved phases summaries have no t-digests
+			m.P50Time = busiest.P50Time
+			m.P75Time = busiest.P75Time
+			m.P90Time = busiest.P90Time
+			m.P99Time = busiest.P99Time
+		}
+
+		merged = append(merged, m)
+	}
+
+	return merged, nil
+}
+
+// mergeAggregatedStats merges the windows starting at the same time
+func mergeAggregatedStats(aggStats []*model.AggregatedStat) []*model.AggregatedStat {
+	var merged []*model.AggregatedStat
+	byStart := make(map[time.Time]*model.AggregatedStat)
+
+	for _, a := range aggStats {
+		m, ok := byStart[a.Start]
+		if !ok {
+			m = &model.AggregatedStat{
+				Start: a.Start,
+				End:   a.End,
+			}
+
+			byStart[a.Start] = m
+			merged = append(merged, m)
+		}
+
+		if a.End.After(m.End) {
+			m.End = a.End
+		}
+		m.Duration = max(m.Duration, a.Duration)
+
+		if a.RequestCount != 0 {
+			if m.RequestCount == 0 || a.MinRequestTime < m.MinRequestTime {
+				m.MinRequestTime = a.MinRequestTime
+			}
+
+			m.MaxRequestTime = max(m.MaxRequestTime, a.MaxRequestTime)
+		}
+
+		// The averages are the sums until all the windows are merged
+		count := time.Duration(a.RequestCount)
+		m.AvgRequestTime += a.AvgRequestTime * count
+		m.AvgDNS += a.AvgDNS * count
+		m.AvgConnect += a.AvgConnect * count
+		m.AvgTLS += a.AvgTLS * count
+		m.AvgTTFB += a.AvgTTFB * count
+		m.AvgTransfer += a.AvgTransfer * count
+		m.RequestCount += a.RequestCount
+
+		m.NewConnections += a.NewConnections
+		m.ReusedConnections += a.ReusedConnections
+		m.ClosedConnections += a.ClosedConnections
+		m.ResetConnections += a.ResetConnections
+	}
+
+	for _, m := range merged {
+		if m.RequestCount == 0 {
+			continue
+		}
+
+		count := time.Duration(m.RequestCount)
+		m.AvgRequestTime /= count
+		m.AvgDNS /= count
+		m.AvgConnect /= count
+		m.AvgTLS /= count
+		m.AvgTTFB /= count
+		m.AvgTransfer /= count
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Start.Before(merged[j].Start)
+	})
+
+	return merged
+}
@@ -0,0 +1,165 @@
+package loader
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/tmwalaszek/hload/mock"
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeSummaries(t *testing.T) {
+	_, ts := mock.NewServer(0)
+	defer ts.Close()
+
+	u, err := url.JoinPath(ts.URL, "ok")
+	require.Nil(t, err)
+
+	var summaries []*model.Summary
+	for _, reqCount := range []int{100, 300} {
+		l, err := NewLoader(&model.Loader{
+			URL:                          u,
+			Method:                       "GET",
+			HTTPEngine:                   "fast_http",
+			AggregateWindow:              time.Second,
+			GatherAggregateRequestsStats: true,
+			GatherFullRequestsStats:      true,
+			LoaderReqDetails: model.LoaderReqDetails{
+				ReqCount:    reqCount,
+				Connections: 2,
+			},
+		})
+		require.Nil(t, err)
+
+		summary, err := l.Do(context.Background())
+		require.Nil(t, err)
+
+		summaries = append(summaries, summary)
+	}
+
+	merged, err := MergeSummaries(summaries)
+	require.Nil(t, err)
+
+	require.Equal(t, 400, merged.ReqCount)
+	require.Equal(t, 400, merged.SuccessReq)
+	require.Equal(t, map[int]int{200: 400}, merged.HTTPCodes)
+	require.Equal(t, summaries[0].DataTransferred+summaries[1].DataTransferred, merged.DataTransferred)
+	require.Equal(t, summaries[0].NewConnections+summaries[1].NewConnections, merged.NewConnections)
+	require.Equal(t, min(summaries[0].MinReqTime, summaries[1].MinReqTime), merged.MinReqTime)
+	require.Equal(t, max(summaries[0].MaxReqTime, summaries[1].MaxReqTime), merged.MaxReqTime)
+	require.Len(t, merged.RequestStats, 400)
+	require.NotNil(t, merged.TDigest)
+
+	require.GreaterOrEqual(t, merged.P50ReqTime, merged.MinReqTime)
+	require.GreaterOrEqual(t, merged.P99ReqTime, merged.P50ReqTime)
+	require.LessOrEqual(t, merged.P99ReqTime, merged.MaxReqTime)
+
+	var windowsCount int
+	for _, a := range merged.AggregatedStats {
+		windowsCount += a.RequestCount
+	}
+	require.Equal(t, 400, windowsCount)
+
+	require.NotEmpty(t, merged.Phases)
+	for _, phase := range merged.Phases {
+		var count int
+		for _, s := range summaries {
+			for _, p := range s.Phases {
+				if p.Name == phase.Name {
+					count += p.ReqCount
+				}
+			}
+		}
+		require.Equal(t, count, phase.ReqCount, phase.Name)
+	}
+
+	// Without the t-digest the requests stats durations are merged
+	summaries[0].TDigest = nil
+	merged, err = MergeSummaries(summaries)
+	require.Nil(t, err)
+	require.NotNil(t, merged.TDigest)
+	require.LessOrEqual(t, merged.P99ReqTime, merged.MaxReqTime)
+
+	// Without the t-digests and the requests stats the percentiles of the summary with the most requests are kept
+	summaries[0].RequestStats = nil
+	merged, err = MergeSummaries(summaries)
+	require.Nil(t, err)
+	require.Nil(t, merged.TDigest)
+	require.Equal(t, summaries[1].P99ReqTime, merged.P99ReqTime)
+
+	_, err = MergeSummaries(nil)
+	require.NotNil(t, err)
+}
+
+func TestMergeSequentialSummaries(t *testing.T) {
+	start := time.Date(2023, 10, 28, 1, 30, 0, 0, time.UTC)
+
+	summaries := []*model.Summary{
+		{
+			Start:      start,
+			End:        start.Add(10 * time.Second),
+			TotalTime:  10 * time.Second,
+			ReqCount:   3,
+			SuccessReq: 2,
+			FailReq:    1,
+			AvgReqTime: 20 * time.Millisecond,
+			MinReqTime: 10 * time.Millisecond,
+			MaxReqTime: 30 * time.Millisecond,
+			Errors:     map[string]int{"timeout": 1},
+			HTTPCodes:  map[int]int{200: 2},
+			RequestStats: []*model.RequestStat{
+				{Start: start, Duration: 10 * time.Millisecond, RetCode: 200},
+				{Start: start.Add(time.Second), Duration: 30 * time.Millisecond, RetCode: 200},
+				{Start: start.Add(2 * time.Second), Duration: 30 * time.Millisecond, Error: "timeout"},
+			},
+		},
+		{
+			Start:      start.Add(time.Minute),
+			End:        start.Add(time.Minute + 30*time.Second),
+			TotalTime:  30 * time.Second,
+			ReqCount:   2,
+			SuccessReq: 2,
+			AvgReqTime: 50 * time.Millisecond,
+			MinReqTime: 40 * time.Millisecond,
+			MaxReqTime: 60 * time.Millisecond,
+			HTTPCodes:  map[int]int{200: 1, 201: 1},
+			RequestStats: []*model.RequestStat{
+				{Start: start.Add(time.Minute), Duration: 40 * time.Millisecond, RetCode: 200},
+				{Start: start.Add(time.Minute + time.Second), Duration: 60 * time.Millisecond, RetCode: 201},
+			},
+		},
+	}
+
+	require.False(t, SummariesOverlap(summaries))
+
+	merged, err := MergeSequentialSummaries(summaries)
+	require.Nil(t, err)
+
+	require.Equal(t, start, merged.Start)
+	require.Equal(t, summaries[1].End, merged.End)
+	require.Equal(t, 40*time.Second, merged.TotalTime)
+	require.Equal(t, 5, merged.ReqCount)
+	require.Equal(t, 4, merged.SuccessReq)
+	require.Equal(t, 1, merged.FailReq)
+	require.Equal(t, 0.1, merged.ReqPerSec)
+	require.Equal(t, map[string]int{"timeout": 1}, merged.Errors)
+	require.Equal(t, map[int]int{200: 3, 201: 1}, merged.HTTPCodes)
+	require.Equal(t, 35*time.Millisecond, merged.AvgReqTime)
+	require.Equal(t, 10*time.Millisecond, merged.MinReqTime)
+	require.Equal(t, 60*time.Millisecond, merged.MaxReqTime)
+	require.Equal(t, 30*time.Millisecond, merged.P50ReqTime)
+	require.NotNil(t, merged.TDigest)
+	require.Len(t, merged.RequestStats, 5)
+
+	// Side by side the total time is the longest one
+	merged, err = MergeSummaries(summaries)
+	require.Nil(t, err)
+	require.Equal(t, 30*time.Second, merged.TotalTime)
+
+	summaries[1].Start = start.Add(5 * time.Second)
+	require.True(t, SummariesOverlap(summaries))
+}
@@ -0,0 +1,95 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/caio/go-tdigest/v4"
+)
+
+var (
+	ErrWrongPercentile = errors.New("wrong percentile")
+	// ErrNoTDigest is returned for the summaries saved before the t-digest was kept
+	ErrNoTDigest = errors.New("summary has no t-digest")
+)
+
+// ParsePercentile parses the percentile p99.9 or 99.9, it has to be greater than 0 and at most 100
+func ParsePercentile(value string) (float64, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(value), "p")
+	p, err := strconv.ParseFloat(v, 64)
+	if err != nil || p <= 0 || p > 100 {
+		return 0, fmt.Errorf("%w %q, expected e.g. p99.9", ErrWrongPercentile, value)
+	}
+
+	return p, nil
+}
+
+// ParsePercentiles parses the percentiles in the given order
+func ParsePercentiles(values []string) ([]float64, error) {
+	percentiles := make([]float64, 0, len(values))
+	for _, value := range values {
+		p, err := ParsePercentile(value)
+		if err != nil {
+			return nil, err
+		}
+
+		percentiles = append(percentiles, p)
+	}
+
+	return percentiles, nil
+}
+
+// Quantile returns the request duration at the percentile of the serialized t-digest
+func Quantile(digest []byte, percentile float64) (time.Duration, error) {
+	if len(digest) == 0 {
+		return 0, ErrNoTDigest
+	}
+
+	t, err := tdigest.New()
+	if err != nil {
+		return 0, fmt.Errorf("tdigest error: %w", err)
+	}
+
+	err = t.FromBytes(digest)
+	if err != nil {
+		return 0, fmt.Errorf("tdigest error: %w", err)
+	}
+
+	return time.Duration(t.Quantile(percentile / 100)), nil
+}
+
+// SetPercentiles computes the percentiles from the summary t-digest
+func SetPercentiles(summary *model.Summary, percentiles []float64) error {
+	if len(percentiles) == 0 {
+		return nil
+	}
+
+	if len(summary.TDigest) == 0 {
+		return ErrNoTDigest
+	}
+
+	t, err := tdigest.New()
+	if err != nil {
+		return fmt.Errorf("tdigest error: %w", err)
+	}
+
+	err = t.FromBytes(summary.TDigest)
+	if err != nil {
+		return fmt.Errorf("tdigest error: %w", err)
+	}
+
+	summary.Percentiles = make([]*model.Percentile, 0, len(percentiles))
+	for _, p := range percentiles {
+		summary.Percentiles = append(summary.Percentiles, &model.Percentile{
+			Percentile: p,
+			Value:      time.Duration(t.Quantile(p / 100)),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package loader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caio/go-tdigest/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/tmwalaszek/hload/model"
+)
+
+func TestParsePercentiles(t *testing.T) {
+	percentiles, err := ParsePercentiles([]string{"p99.9", "99.99", " p10", "p100"})
+	require.NoError(t, err)
+	require.Equal(t, []float64{99.9, 99.99, 10, 100}, percentiles)
+
+	for _, wrong := range []string{"", "p", "p0", "p100.1", "-1", "pp99", "99%"} {
+		_, err := ParsePercentile(wrong)
+		require.ErrorIs(t, err, ErrWrongPercentile, wrong)
+	}
+}
+
+func TestSetPercentiles(t *testing.T) {
+	digest, err := tdigest.New()
+	require.NoError(t, err)
+
+	for i := 1; i <= 10000; i++ {
+		require.NoError(t, digest.Add(float64(time.Duration(i)*time.Microsecond)))
+	}
+
+	summary := &model.Summary{TDigest: digest.ToBytes(nil)}
+	require.NoError(t, SetPercentiles(summary, []float64{10, 99.9, 99.99}))
+	require.Len(t, summary.Percentiles, 3)
+	require.Equal(t, 99.9, summary.Percentiles[1].Percentile)
+	require.InDelta(t, time.Millisecond, summary.Percentiles[0].Value, float64(20*time.Microsecond))
+	require.InDelta(t, 9990*time.Microsecond, summary.Percentiles[1].Value, float64(20*time.Microsecond))
+	require.InDelta(t, 9999*time.Microsecond, summary.Percentiles[2].Value, float64(20*time.Microsecond))
+
+	p, err := Quantile(summary.TDigest, 99.9)
+	require.NoError(t, err)
+	require.Equal(t, summary.Percentiles[1].Value, p)
+
+	require.NoError(t, SetPercentiles(&model.Summary{}, nil))
+	require.ErrorIs(t, SetPercentiles(&model.Summary{}, []float64{99}), ErrNoTDigest)
+
+	_, err = Quantile(nil, 99)
+	require.ErrorIs(t, err, ErrNoTDigest)
+}
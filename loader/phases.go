@@ -0,0 +1,96 @@
+package loader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/caio/go-tdigest/v4"
+)
+
+// phaseStat collects the durations of one request phase
+type phaseStat struct {
+	name string
+
+	count         int
+	minDuration   time.Duration
+	maxDuration   time.Duration
+	totalDuration time.Duration
+
+	t *tdigest.TDigest
+}
+
+// phasesStats collects the request phases in the model.Phases fields order
+type phasesStats []*phaseStat
+
+func newPhasesStats() (phasesStats, error) {
+	names := []string{model.PhaseDNS, model.PhaseConnect, model.PhaseTLS, model.PhaseTTFB, model.PhaseTransfer}
+
+	p := make(phasesStats, len(names))
+	for i, name := range names {
+		t, err := tdigest.New()
+		if err != nil {
+			return nil, fmt.Errorf("tdigest error: %w", err)
+		}
+
+		p[i] = &phaseStat{name: name, t: t}
+	}
+
+	return p, nil
+}
+
+// add adds the phases of the request, the phases which did not happen are skipped
+func (p phasesStats) add(phases model.Phases) error {
+	durations := []time.Duration{phases.DNS, phases.Connect, phases.TLS, phases.TTFB, phases.Transfer}
+
+	for i, d := range durations {
+		if d <= 0 {
+			continue
+		}
+
+		s := p[i]
+		if s.count == 0 || d < s.minDuration {
+			s.minDuration = d
+		}
+
+		if d > s.maxDuration {
+			s.maxDuration = d
+		}
+
+		s.count++
+		s.totalDuration += d
+
+		err := s.t.Add(float64(d))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// summary returns the summaries of the phases measured at least once
+func (p phasesStats) summary() []*model.PhaseSummary {
+	var summaries []*model.PhaseSummary
+	for _, s := range p {
+		if s.count == 0 {
+			continue
+		}
+
+		summaries = append(summaries, &model.PhaseSummary{
+			Name:     s.name,
+			ReqCount: s.count,
+			AvgTime:  s.totalDuration / time.Duration(s.count),
+			MinTime:  s.minDuration,
+			MaxTime:  s.maxDuration,
+			P50Time:  time.Duration(s.t.Quantile(0.5)),
+			P75Time:  time.Duration(s.t.Quantile(0.75)),
+			P90Time:  time.Duration(s.t.Quantile(0.9)),
+			P99Time:  time.Duration(s.t.Quantile(0.99)),
+			TDigest:  s.t.ToBytes(nil),
+		})
+	}
+
+	return summaries
+}
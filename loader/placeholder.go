@@ -0,0 +1,384 @@
+package loader
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/google/uuid"
+)
+
+const randStringLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// renderContext is what the placeholders of a single request are rendered with
+type renderContext struct {
+	worker int
+	// seq is the request sequence number within the benchmark
+	seq int64
+	// vars is the flow virtual user variables scope, nil outside the flow where ${name} is kept as is
+	vars map[string]string
+	// row is the feeder row of the request or the flow run
+	row map[string]string
+}
+
+type placeholderFunc func(b *strings.Builder, rc *renderContext) error
+
+// placeholderString is the string compiled at the loader start. Placeholders are rendered per request:
+// {{uuid}}, {{seq}}, {{randInt min max}}, {{randString n}}, {{now [unix|unixmilli]}}, {{worker}}, {{feed column}}
+// and the flow variables ${name}.
+type placeholderString struct {
+	literal string
+	parts   []placeholderFunc
+	// columns are the feeder columns the string uses
+	columns []string
+}
+
+func compilePlaceholders(s string) (*placeholderString, error) {
+	p := &placeholderString{
+		literal: s,
+	}
+
+	if !strings.Contains(s, "{{") && !strings.Contains(s, "${") {
+		return p, nil
+	}
+
+	literal := func(l string) {
+		if l != "" {
+			p.parts = append(p.parts, func(b *strings.Builder, rc *renderContext) error {
+				b.WriteString(l)
+				return nil
+			})
+		}
+	}
+
+	for s != "" {
+		fn := strings.Index(s, "{{")
+		variable := strings.Index(s, "${")
+
+		switch {
+		case fn == -1 && variable == -1:
+			literal(s)
+			s = ""
+		case variable == -1 || (fn != -1 && fn < variable):
+			end := strings.Index(s[fn:], "}}")
+			if end == -1 {
+				return nil, fmt.Errorf("placeholder %s is not closed", s[fn:])
+			}
+
+			expr := s[fn+2 : fn+end]
+			f, err := placeholder(expr)
+			if err != nil {
+				return nil, err
+			}
+
+			if fields := strings.Fields(expr); fields[0] == "feed" {
+				p.columns = append(p.columns, fields[1])
+			}
+
+			literal(s[:fn])
+			p.parts = append(p.parts, f)
+			s = s[fn+end+2:]
+		default:
+			end := strings.IndexByte(s[variable:], '}')
+			if end == -1 {
+				literal(s)
+				s = ""
+				break
+			}
+
+			name := s[variable+2 : variable+end]
+			raw := s[variable : variable+end+1]
+			literal(s[:variable])
+			p.parts = append(p.parts, func(b *strings.Builder, rc *renderContext) error {
+				if rc.vars == nil {
+					b.WriteString(raw)
+					return nil
+				}
+
+				value, ok := rc.vars[name]
+				if !ok {
+					return fmt.Errorf("flow variable %s is not set", name)
+				}
+
+				b.WriteString(value)
+				return nil
+			})
+			s = s[variable+end+1:]
+		}
+	}
+
+	return p, nil
+}
+
+// placeholder returns the generator function of the placeholder expression like "randInt 1 1000"
+func placeholder(expr string) (placeholderFunc, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty placeholder")
+	}
+
+	name, args := fields[0], fields[1:]
+	wrongArgs := fmt.Errorf("wrong %s placeholder arguments %v", name, args)
+
+	switch name {
+	case "uuid":
+		if len(args) != 0 {
+			return nil, wrongArgs
+		}
+
+		return func(b *strings.Builder, rc *renderContext) error {
+			b.WriteString(uuid.NewString())
+			return nil
+		}, nil
+	case "seq":
+		if len(args) != 0 {
+			return nil, wrongArgs
+		}
+
+		return func(b *strings.Builder, rc *renderContext) error {
+			b.WriteString(strconv.FormatInt(rc.seq, 10))
+			return nil
+		}, nil
+	case "worker":
+		if len(args) != 0 {
+			return nil, wrongArgs
+		}
+
+		return func(b *strings.Builder, rc *renderContext) error {
+			b.WriteString(strconv.Itoa(rc.worker))
+			return nil
+		}, nil
+	case "now":
+		if len(args) > 1 {
+			return nil, wrongArgs
+		}
+
+		format := "rfc3339"
+		if len(args) == 1 {
+			format = args[0]
+		}
+
+		switch format {
+		case "rfc3339":
+			return func(b *strings.Builder, rc *renderContext) error {
+				b.WriteString(time.Now().UTC().Format(time.RFC3339))
+				return nil
+			}, nil
+		case "unix":
+			return func(b *strings.Builder, rc *renderContext) error {
+				b.WriteString(strconv.FormatInt(time.Now().Unix(), 10))
+				return nil
+			}, nil
+		case "unixmilli":
+			return func(b *strings.Builder, rc *renderContext) error {
+				b.WriteString(strconv.FormatInt(time.Now().UnixMilli(), 10))
+				return nil
+			}, nil
+		default:
+			return nil, wrongArgs
+		}
+	case "randInt":
+		if len(args) != 2 {
+			return nil, wrongArgs
+		}
+
+		minValue, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return nil, wrongArgs
+		}
+
+		maxValue, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || maxValue < minValue {
+			return nil, wrongArgs
+		}
+
+		return func(b *strings.Builder, rc *renderContext) error {
+			b.WriteString(strconv.FormatInt(minValue+rand.Int64N(maxValue-minValue+1), 10))
+			return nil
+		}, nil
+	case "randString":
+		if len(args) != 1 {
+			return nil, wrongArgs
+		}
+
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return nil, wrongArgs
+		}
+
+		return func(b *strings.Builder, rc *renderContext) error {
+			for i := 0; i < n; i++ {
+				b.WriteByte(randStringLetters[rand.IntN(len(randStringLetters))])
+			}
+			return nil
+		}, nil
+	case "feed":
+		if len(args) != 1 {
+			return nil, wrongArgs
+		}
+
+		column := args[0]
+		return func(b *strings.Builder, rc *renderContext) error {
+			value, ok := rc.row[column]
+			if !ok {
+				return fmt.Errorf("feeder column %s is not set", column)
+			}
+
+			b.WriteString(value)
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown placeholder %s", name)
+	}
+}
+
+func (p *placeholderString) static() bool {
+	return p.parts == nil
+}
+
+func (p *placeholderString) render(rc *renderContext) (string, error) {
+	if p.static() {
+		return p.literal, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(p.literal))
+	for _, part := range p.parts {
+		err := part(&b, rc)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return b.String(), nil
+}
+
+// endpointTemplate is the endpoint with the URL, headers, body and parameters values compiled
+type endpointTemplate struct {
+	endpoint *model.Endpoint
+
+	url        *placeholderString
+	body       *placeholderString
+	headers    map[string][]*placeholderString
+	parameters []map[string]*placeholderString
+
+	// static is set when nothing has to be rendered
+	static bool
+	// columns are the feeder columns the endpoint uses
+	columns []string
+}
+
+func newEndpointTemplate(endpoint *model.Endpoint) (*endpointTemplate, error) {
+	var err error
+
+	t := &endpointTemplate{
+		endpoint: endpoint,
+		headers:  make(map[string][]*placeholderString, len(endpoint.Headers)),
+		static:   true,
+	}
+
+	compile := func(s string) *placeholderString {
+		if err != nil {
+			return nil
+		}
+
+		var p *placeholderString
+		p, err = compilePlaceholders(s)
+		if err == nil && !p.static() {
+			t.static = false
+			t.columns = append(t.columns, p.columns...)
+		}
+
+		return p
+	}
+
+	t.url = compile(endpoint.URL)
+	t.body = compile(string(endpoint.Body))
+
+	for key, values := range endpoint.Headers {
+		for _, value := range values {
+			t.headers[key] = append(t.headers[key], compile(value))
+		}
+	}
+
+	for _, parameters := range endpoint.Parameters {
+		compiled := make(map[string]*placeholderString, len(parameters))
+		for key, value := range parameters {
+			compiled[key] = compile(value)
+		}
+
+		t.parameters = append(t.parameters, compiled)
+	}
+
+	if err != nil && endpoint.Name != "" {
+		return nil, fmt.Errorf("endpoint %s: %w", endpoint.Name, err)
+	}
+
+	return t, err
+}
+
+// request creates the request with one of the endpoint parameters sets chosen randomly
+// and all placeholders rendered
+func (t *endpointTemplate) request(rc *renderContext) (*Request, error) {
+	req := &Request{
+		Name:    t.endpoint.Name,
+		URL:     t.endpoint.URL,
+		Method:  t.endpoint.Method,
+		Headers: t.endpoint.Headers,
+		Body:    t.endpoint.Body,
+	}
+
+	var parametersIndex int
+	if len(t.endpoint.Parameters) > 0 {
+		parametersIndex = rand.IntN(len(t.endpoint.Parameters))
+		req.Parameters = t.endpoint.Parameters[parametersIndex]
+	}
+
+	if t.static {
+		return req, nil
+	}
+
+	var err error
+	req.URL, err = t.url.render(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.body.static() {
+		body, err := t.body.render(rc)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = []byte(body)
+	}
+
+	req.Headers = make(model.Headers, len(t.headers))
+	for key, values := range t.headers {
+		req.Headers[key] = make([]string, len(values))
+		for i, value := range values {
+			req.Headers[key][i], err = value.render(rc)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if req.Parameters != nil {
+		parameters := make(map[string]string, len(req.Parameters))
+		for key, value := range t.parameters[parametersIndex] {
+			parameters[key], err = value.render(rc)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		req.Parameters = parameters
+	}
+
+	return req, nil
+}
@@ -0,0 +1,218 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+const (
+	ProbeModeBinary = "binary"
+	ProbeModeStep   = "step"
+)
+
+// DefaultMinThroughput is the part of the probed rate the benchmark has to achieve to be sustainable
+const DefaultMinThroughput = 0.9
+
+// ProbeSLO is the service level objective every probe step is checked against
+type ProbeSLO struct {
+	// Percentile is one of the summary percentiles: 50, 75, 90 or 99
+	Percentile int
+	MaxLatency time.Duration
+	// MaxErrorRate is the allowed failed requests ratio, from 0 to 1
+	MaxErrorRate float64
+	// MinThroughput is the achieved to probed rate ratio, from 0 to 1
+	MinThroughput float64
+}
+
+type ProbeOptions struct {
+	Mode string
+
+	StartRate int
+	MaxRate   int
+	// RateStep is the rate increase in step mode and the search precision in binary mode
+	RateStep int
+
+	SLO ProbeSLO
+}
+
+// ProbeStep is the result of one benchmark run at the given rate
+type ProbeStep struct {
+	Rate      int
+	Latency   time.Duration
+	ErrorRate float64
+	Pass      bool
+	Reason    string
+
+	Summary *model.Summary
+}
+
+type ProbeResult struct {
+	Steps []*ProbeStep
+
+	// Best is the step with the highest rate that met the SLO, nil when none did
+	Best *ProbeStep
+}
+
+// ProbeRunFunc runs one benchmark at the given rate
+type ProbeRunFunc func(ctx context.Context, rate int) (*model.Summary, error)
+
+// Prober searches for the highest rate the target can sustain without breaking the SLO
+type Prober struct {
+	opts ProbeOptions
+	run  ProbeRunFunc
+
+	// OnStep is called after every probe step if set
+	OnStep func(step *ProbeStep)
+}
+
+func NewProber(opts ProbeOptions, run ProbeRunFunc) (*Prober, error) {
+	switch opts.Mode {
+	case ProbeModeBinary, ProbeModeStep:
+	default:
+		return nil, fmt.Errorf("wrong probe mode %s", opts.Mode)
+	}
+
+	if opts.StartRate <= 0 {
+		return nil, errors.New("probe start rate has to be positive")
+	}
+
+	if opts.MaxRate < opts.StartRate {
+		return nil, errors.New("probe max rate has to be greater than start rate")
+	}
+
+	if opts.RateStep <= 0 {
+		return nil, errors.New("probe rate step has to be positive")
+	}
+
+	switch opts.SLO.Percentile {
+	case 50, 75, 90, 99:
+	default:
+		return nil, fmt.Errorf("unsupported SLO percentile %d", opts.SLO.Percentile)
+	}
+
+	if opts.SLO.MaxErrorRate < 0 || opts.SLO.MaxErrorRate > 1 {
+		return nil, errors.New("SLO error rate has to be between 0 and 1")
+	}
+
+	if opts.SLO.MinThroughput < 0 || opts.SLO.MinThroughput > 1 {
+		return nil, errors.New("SLO min throughput has to be between 0 and 1")
+	}
+
+	return &Prober{
+		opts: opts,
+		run:  run,
+	}, nil
+}
+
+// Probe runs the benchmarks until the search is finished, ctx cancellation stops it early
+func (p *Prober) Probe(ctx context.Context) (*ProbeResult, error) {
+	result := &ProbeResult{}
+
+	check := func(rate int) (bool, error) {
+		summary, err := p.run(ctx, rate)
+		if err != nil {
+			return false, err
+		}
+
+		// The step stopped by the cancellation is partial and is not judged
+		if ctx.Err() != nil {
+			return false, nil
+		}
+
+		step := p.evaluate(rate, summary)
+		result.Steps = append(result.Steps, step)
+		if step.Pass && (result.Best == nil || step.Rate > result.Best.Rate) {
+			result.Best = step
+		}
+
+		if p.OnStep != nil {
+			p.OnStep(step)
+		}
+
+		return step.Pass, nil
+	}
+
+	switch p.opts.Mode {
+	case ProbeModeStep:
+		for rate := p.opts.StartRate; rate <= p.opts.MaxRate; rate += p.opts.RateStep {
+			if ctx.Err() != nil {
+				break
+			}
+
+			pass, err := check(rate)
+			if err != nil {
+				return nil, err
+			}
+
+			if !pass {
+				break
+			}
+		}
+	case ProbeModeBinary:
+		low, high := p.opts.StartRate, p.opts.MaxRate
+		for low <= high {
+			if ctx.Err() != nil {
+				break
+			}
+
+			rate := low + (high-low)/2
+			pass, err := check(rate)
+			if err != nil {
+				return nil, err
+			}
+
+			if pass {
+				low = rate + p.opts.RateStep
+			} else {
+				high = rate - p.opts.RateStep
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (p *Prober) evaluate(rate int, summary *model.Summary) *ProbeStep {
+	step := &ProbeStep{
+		Rate:    rate,
+		Latency: summaryPercentile(summary, p.opts.SLO.Percentile),
+		Summary: summary,
+	}
+
+	if summary.ReqCount == 0 {
+		step.Reason = "no requests sent"
+		return step
+	}
+
+	step.ErrorRate = float64(summary.FailReq+summary.DroppedReq) / float64(summary.ReqCount+summary.DroppedReq)
+
+	switch {
+	case p.opts.SLO.MaxLatency != 0 && step.Latency > p.opts.SLO.MaxLatency:
+		step.Reason = fmt.Sprintf("p%d latency %v above %v", p.opts.SLO.Percentile, step.Latency, p.opts.SLO.MaxLatency)
+	case step.ErrorRate > p.opts.SLO.MaxErrorRate:
+		step.Reason = fmt.Sprintf("error rate %.4f above %.4f", step.ErrorRate, p.opts.SLO.MaxErrorRate)
+	case summary.ReqPerSec < float64(rate)*p.opts.SLO.MinThroughput:
+		step.Reason = fmt.Sprintf("throughput %.2f req/s below %.2f req/s", summary.ReqPerSec, float64(rate)*p.opts.SLO.MinThroughput)
+	default:
+		step.Pass = true
+	}
+
+	return step
+}
+
+func summaryPercentile(summary *model.Summary, percentile int) time.Duration {
+	switch percentile {
+	case 50:
+		return summary.P50ReqTime
+	case 75:
+		return summary.P75ReqTime
+	case 90:
+		return summary.P90ReqTime
+	default:
+		return summary.P99ReqTime
+	}
+}
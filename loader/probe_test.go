@@ -0,0 +1,200 @@
+package loader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTarget pretends to be a service that can handle capacity requests per second
+func fakeTarget(capacity int) ProbeRunFunc {
+	return func(ctx context.Context, rate int) (*model.Summary, error) {
+		summary := &model.Summary{
+			ReqCount:   rate * 10,
+			SuccessReq: rate * 10,
+			ReqPerSec:  float64(rate),
+			P99ReqTime: 10 * time.Millisecond,
+		}
+
+		if rate > capacity {
+			summary.ReqPerSec = float64(capacity)
+			summary.P99ReqTime = time.Second
+		}
+
+		return summary, nil
+	}
+}
+
+func TestProber(t *testing.T) {
+	t.Parallel()
+
+	var tt = []struct {
+		Name     string
+		Opts     ProbeOptions
+		Capacity int
+		Best     int
+		MaxSteps int
+	}{
+		{
+			Name: "step search",
+			Opts: ProbeOptions{
+				Mode:      ProbeModeStep,
+				StartRate: 100,
+				MaxRate:   1000,
+				RateStep:  100,
+			},
+			Capacity: 450,
+			Best:     400,
+			MaxSteps: 5,
+		},
+		{
+			Name: "binary search",
+			Opts: ProbeOptions{
+				Mode:      ProbeModeBinary,
+				StartRate: 100,
+				MaxRate:   1000,
+				RateStep:  10,
+			},
+			Capacity: 450,
+			Best:     450,
+			MaxSteps: 8,
+		},
+		{
+			Name: "binary search max rate is sustainable",
+			Opts: ProbeOptions{
+				Mode:      ProbeModeBinary,
+				StartRate: 100,
+				MaxRate:   1000,
+				RateStep:  10,
+			},
+			Capacity: 5000,
+			Best:     1000,
+			MaxSteps: 8,
+		},
+		{
+			Name: "start rate is not sustainable",
+			Opts: ProbeOptions{
+				Mode:      ProbeModeStep,
+				StartRate: 100,
+				MaxRate:   1000,
+				RateStep:  100,
+			},
+			Capacity: 50,
+			Best:     0,
+			MaxSteps: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Opts.SLO = ProbeSLO{
+				Percentile:    99,
+				MaxLatency:    100 * time.Millisecond,
+				MinThroughput: DefaultMinThroughput,
+			}
+
+			p, err := NewProber(tc.Opts, fakeTarget(tc.Capacity))
+			require.Nil(t, err)
+
+			var steps int
+			p.OnStep = func(step *ProbeStep) {
+				steps++
+			}
+
+			result, err := p.Probe(context.Background())
+			require.Nil(t, err)
+			require.Len(t, result.Steps, steps)
+			require.LessOrEqual(t, steps, tc.MaxSteps)
+
+			if tc.Best == 0 {
+				require.Nil(t, result.Best)
+				return
+			}
+
+			require.NotNil(t, result.Best)
+			require.InDelta(t, tc.Best, result.Best.Rate, float64(tc.Opts.RateStep))
+			require.LessOrEqual(t, result.Best.Rate, tc.Capacity)
+		})
+	}
+}
+
+func TestProberErrorRate(t *testing.T) {
+	t.Parallel()
+
+	run := func(ctx context.Context, rate int) (*model.Summary, error) {
+		return &model.Summary{
+			ReqCount:   1000,
+			SuccessReq: 1000 - rate/10,
+			FailReq:    rate / 10,
+			ReqPerSec:  float64(rate),
+		}, nil
+	}
+
+	p, err := NewProber(ProbeOptions{
+		Mode:      ProbeModeStep,
+		StartRate: 100,
+		MaxRate:   1000,
+		RateStep:  100,
+		SLO: ProbeSLO{
+			Percentile:   99,
+			MaxErrorRate: 0.05,
+		},
+	}, run)
+	require.Nil(t, err)
+
+	result, err := p.Probe(context.Background())
+	require.Nil(t, err)
+	require.NotNil(t, result.Best)
+	require.Equal(t, 500, result.Best.Rate)
+	require.False(t, result.Steps[len(result.Steps)-1].Pass)
+	require.NotEmpty(t, result.Steps[len(result.Steps)-1].Reason)
+
+	_, err = NewProber(ProbeOptions{Mode: "linear", StartRate: 1, MaxRate: 2, RateStep: 1, SLO: ProbeSLO{Percentile: 99}}, run)
+	require.NotNil(t, err)
+
+	_, err = NewProber(ProbeOptions{Mode: ProbeModeStep, StartRate: 1, MaxRate: 2, RateStep: 1, SLO: ProbeSLO{Percentile: 95}}, run)
+	require.NotNil(t, err)
+
+	_, err = NewProber(ProbeOptions{Mode: ProbeModeStep, StartRate: 1, MaxRate: 2, RateStep: 1, SLO: ProbeSLO{Percentile: 99, MinThroughput: 1.5}}, run)
+	require.NotNil(t, err)
+}
+
+func TestProberCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The step at 300 req/s is cancelled and its partial summary breaks the latency SLO
+	target := fakeTarget(1000)
+	run := func(ctx context.Context, rate int) (*model.Summary, error) {
+		summary, err := target(ctx, rate)
+		if rate == 300 {
+			cancel()
+			summary.P99ReqTime = time.Second
+		}
+
+		return summary, err
+	}
+
+	p, err := NewProber(ProbeOptions{
+		Mode:      ProbeModeStep,
+		StartRate: 100,
+		MaxRate:   1000,
+		RateStep:  100,
+		SLO: ProbeSLO{
+			Percentile: 99,
+			MaxLatency: 100 * time.Millisecond,
+		},
+	}, run)
+	require.Nil(t, err)
+
+	result, err := p.Probe(ctx)
+	require.Nil(t, err)
+	require.Len(t, result.Steps, 2)
+	require.Equal(t, 200, result.Best.Rate)
+}
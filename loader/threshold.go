@@ -0,0 +1,284 @@
+package loader
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+// Threshold metrics
+const (
+	ThresholdP50       = "p50"
+	ThresholdP75       = "p75"
+	ThresholdP90       = "p90"
+	ThresholdP99       = "p99"
+	ThresholdAvg       = "avg"
+	ThresholdMin       = "min"
+	ThresholdMax       = "max"
+	ThresholdReqPerSec = "req_per_sec"
+	ThresholdRequests  = "requests"
+	ThresholdSuccess   = "success"
+	ThresholdFail      = "fail"
+	ThresholdFailRate  = "fail_rate"
+	ThresholdErrors    = "errors"
+	ThresholdDropped   = "dropped"
+	ThresholdLate      = "late"
+)
+
+// httpCodeMetric is the http_code[5xx] metric, the code can have x for any digit
+var httpCodeMetric = regexp.MustCompile(`^http_code\[([0-9x]{3})\]$`)
+
+type thresholdKind int
+
+const (
+	thresholdDuration thresholdKind = iota
+	thresholdRate
+	thresholdNumber
+)
+
+type thresholdMetric struct {
+	kind  thresholdKind
+	value func(s *model.Summary) float64
+}
+
+func durationValue(value func(s *model.Summary) time.Duration) thresholdMetric {
+	return thresholdMetric{
+		kind: thresholdDuration,
+		value: func(s *model.Summary) float64 {
+			return float64(value(s))
+		},
+	}
+}
+
+func numberValue(value func(s *model.Summary) int) thresholdMetric {
+	return thresholdMetric{
+		kind: thresholdNumber,
+		value: func(s *model.Summary) float64 {
+			return float64(value(s))
+		},
+	}
+}
+
+var thresholdMetrics = map[string]thresholdMetric{
+	ThresholdP50: durationValue(func(s *model.Summary) time.Duration { return s.P50ReqTime }),
+	ThresholdP75: durationValue(func(s *model.Summary) time.Duration { return s.P75ReqTime }),
+	ThresholdP90: durationValue(func(s *model.Summary) time.Duration { return s.P90ReqTime }),
+	ThresholdP99: durationValue(func(s *model.Summary) time.Duration { return s.P99ReqTime }),
+	ThresholdAvg: durationValue(func(s *model.Summary) time.Duration { return s.AvgReqTime }),
+	ThresholdMin: durationValue(func(s *model.Summary) time.Duration { return s.MinReqTime }),
+	ThresholdMax: durationValue(func(s *model.Summary) time.Duration { return s.MaxReqTime }),
+	ThresholdReqPerSec: {
+		kind:  thresholdNumber,
+		value: func(s *model.Summary) float64 { return s.ReqPerSec },
+	},
+	ThresholdRequests: numberValue(func(s *model.Summary) int { return s.ReqCount }),
+	ThresholdSuccess:  numberValue(func(s *model.Summary) int { return s.SuccessReq }),
+	ThresholdFail:     numberValue(func(s *model.Summary) int { return s.FailReq }),
+	ThresholdDropped:  numberValue(func(s *model.Summary) int { return s.DroppedReq }),
+	ThresholdLate:     numberValue(func(s *model.Summary) int { return s.LateReq }),
+	ThresholdErrors: numberValue(func(s *model.Summary) int {
+		var errors int
+		for _, count := range s.Errors {
+			errors += count
+		}
+
+		return errors
+	}),
+	ThresholdFailRate: {
+		kind: thresholdRate,
+		value: func(s *model.Summary) float64 {
+			if s.ReqCount == 0 {
+				return 0
+			}
+
+			return float64(s.FailReq) / float64(s.ReqCount)
+		},
+	},
+}
+
+// ThresholdResult is the threshold check of the summary
+type ThresholdResult struct {
+	Threshold *model.Threshold `json:"threshold"`
+	// Actual is the summary metric value formatted like the threshold value
+	Actual string `json:"actual"`
+	Pass   bool   `json:"pass"`
+}
+
+// matchHTTPCode reports whether the code matches the pattern, x matches any digit
+func matchHTTPCode(pattern string, code int) bool {
+	c := strconv.Itoa(code)
+	if len(c) != len(pattern) {
+		return false
+	}
+
+	for i := range pattern {
+		if pattern[i] != 'x' && pattern[i] != c[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lookupThresholdMetric returns the metric of the threshold
+func lookupThresholdMetric(name string) (thresholdMetric, error) {
+	if m, ok := thresholdMetrics[name]; ok {
+		return m, nil
+	}
+
+	match := httpCodeMetric.FindStringSubmatch(name)
+	if match == nil {
+		return thresholdMetric{}, fmt.Errorf("%w: unknown metric %s", model.ErrWrongThresholdFormat, name)
+	}
+
+	pattern := match[1]
+	return thresholdMetric{
+		kind: thresholdNumber,
+		value: func(s *model.Summary) float64 {
+			var count int
+			for code, c := range s.HTTPCodes {
+				if matchHTTPCode(pattern, code) {
+					count += c
+				}
+			}
+
+			return float64(count)
+		},
+	}, nil
+}
+
+// parseThresholdValue returns the threshold value in the metric unit, the durations in nanoseconds
+// and the rates as the fraction, "0.5%" and "0.005" are the same rate
+func parseThresholdValue(kind thresholdKind, value string) (float64, error) {
+	switch kind {
+	case thresholdDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%w: wrong duration %s", model.ErrWrongThresholdFormat, value)
+		}
+
+		return float64(d), nil
+	case thresholdRate:
+		percent := strings.HasSuffix(value, "%")
+		v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: wrong rate %s", model.ErrWrongThresholdFormat, value)
+		}
+
+		if percent {
+			v /= 100
+		}
+
+		return v, nil
+	default:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: wrong number %s", model.ErrWrongThresholdFormat, value)
+		}
+
+		return v, nil
+	}
+}
+
+func formatThresholdValue(kind thresholdKind, value float64) string {
+	switch kind {
+	case thresholdDuration:
+		return time.Duration(value).String()
+	case thresholdRate:
+		return strconv.FormatFloat(value*100, 'f', 2, 64) + "%"
+	default:
+		if value == math.Trunc(value) {
+			return strconv.FormatFloat(value, 'f', 0, 64)
+		}
+
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	}
+}
+
+func compareThreshold(operator string, actual, expected float64) (bool, error) {
+	switch operator {
+	case model.ThresholdLess:
+		return actual < expected, nil
+	case model.ThresholdLessEqual:
+		return actual <= expected, nil
+	case model.ThresholdGreater:
+		return actual > expected, nil
+	case model.ThresholdGreaterEqual:
+		return actual >= expected, nil
+	case model.ThresholdEqual:
+		return actual == expected, nil
+	case model.ThresholdNotEqual:
+		return actual != expected, nil
+	default:
+		return false, fmt.Errorf("%w: unknown operator %s", model.ErrWrongThresholdFormat, operator)
+	}
+}
+
+// ValidateThresholds checks the thresholds metrics, operators and values
+func ValidateThresholds(thresholds model.Thresholds) error {
+	for _, threshold := range thresholds {
+		m, err := lookupThresholdMetric(threshold.Metric)
+		if err != nil {
+			return err
+		}
+
+		_, err = parseThresholdValue(m.kind, threshold.Value)
+		if err != nil {
+			return err
+		}
+
+		_, err = compareThreshold(threshold.Operator, 0, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckThresholds checks the summary against the thresholds, the results are in the thresholds order
+func CheckThresholds(thresholds model.Thresholds, summary *model.Summary) ([]*ThresholdResult, error) {
+	results := make([]*ThresholdResult, 0, len(thresholds))
+
+	for _, threshold := range thresholds {
+		m, err := lookupThresholdMetric(threshold.Metric)
+		if err != nil {
+			return nil, err
+		}
+
+		expected, err := parseThresholdValue(m.kind, threshold.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		actual := m.value(summary)
+		pass, err := compareThreshold(threshold.Operator, actual, expected)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, &ThresholdResult{
+			Threshold: threshold,
+			Actual:    formatThresholdValue(m.kind, actual),
+			Pass:      pass,
+		})
+	}
+
+	return results, nil
+}
+
+// ThresholdsPassed reports whether all the thresholds passed
+func ThresholdsPassed(results []*ThresholdResult) bool {
+	for _, result := range results {
+		if !result.Pass {
+			return false
+		}
+	}
+
+	return true
+}
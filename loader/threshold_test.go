@@ -0,0 +1,86 @@
+package loader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmwalaszek/hload/model"
+)
+
+func TestThresholdsSet(t *testing.T) {
+	var thresholds model.Thresholds
+	for _, value := range []string{"p99 < 200ms", "fail_rate<=0.5%", "http_code[5xx] == 0", "req_per_sec >1000", "errors != 1"} {
+		require.NoError(t, thresholds.Set(value), value)
+	}
+
+	require.Equal(t, &model.Threshold{Metric: "p99", Operator: "<", Value: "200ms"}, thresholds[0])
+	require.Equal(t, &model.Threshold{Metric: "fail_rate", Operator: "<=", Value: "0.5%"}, thresholds[1])
+	require.Equal(t, &model.Threshold{Metric: "http_code[5xx]", Operator: "==", Value: "0"}, thresholds[2])
+	require.Equal(t, &model.Threshold{Metric: "req_per_sec", Operator: ">", Value: "1000"}, thresholds[3])
+	require.Equal(t, &model.Threshold{Metric: "errors", Operator: "!=", Value: "1"}, thresholds[4])
+	require.Equal(t, "p99 < 200ms", thresholds[0].String())
+	require.NoError(t, ValidateThresholds(thresholds))
+
+	for _, value := range []string{"p99", "< 200ms", "p99 <", "p99 = 1"} {
+		require.ErrorIs(t, thresholds.Set(value), model.ErrWrongThresholdFormat, value)
+	}
+
+	for _, threshold := range []*model.Threshold{
+		{Metric: "p98", Operator: "<", Value: "1ms"},
+		{Metric: "p99", Operator: "<", Value: "200"},
+		{Metric: "fail_rate", Operator: "<", Value: "x%"},
+		{Metric: "http_code[5x]", Operator: "==", Value: "0"},
+		{Metric: "requests", Operator: "=<", Value: "1"},
+	} {
+		require.ErrorIs(t, ValidateThresholds(model.Thresholds{threshold}), model.ErrWrongThresholdFormat, threshold.String())
+	}
+}
+
+func TestCheckThresholds(t *testing.T) {
+	summary := &model.Summary{
+		ReqCount:   1000,
+		SuccessReq: 996,
+		FailReq:    4,
+		ReqPerSec:  1234.5,
+		P99ReqTime: 150 * time.Millisecond,
+		HTTPCodes:  map[int]int{200: 996, 500: 3, 503: 1},
+		Errors:     map[string]int{"timeout": 2},
+	}
+
+	tt := []struct {
+		threshold string
+		actual    string
+		pass      bool
+	}{
+		{"p99 < 200ms", "150ms", true},
+		{"p99 <= 100ms", "150ms", false},
+		{"fail_rate < 0.5%", "0.40%", true},
+		{"fail_rate < 0.001", "0.40%", false},
+		{"req_per_sec > 1000", "1234.50", true},
+		{"http_code[5xx] == 0", "4", false},
+		{"http_code[50x] >= 4", "4", true},
+		{"http_code[200] == 996", "996", true},
+		{"errors == 2", "2", true},
+		{"success != 996", "996", false},
+	}
+
+	var thresholds model.Thresholds
+	for _, tc := range tt {
+		require.NoError(t, thresholds.Set(tc.threshold))
+	}
+
+	results, err := CheckThresholds(thresholds, summary)
+	require.NoError(t, err)
+	require.Len(t, results, len(tt))
+
+	for i, tc := range tt {
+		require.Equal(t, tc.threshold, results[i].Threshold.String())
+		require.Equal(t, tc.actual, results[i].Actual, tc.threshold)
+		require.Equal(t, tc.pass, results[i].Pass, tc.threshold)
+	}
+
+	require.False(t, ThresholdsPassed(results))
+	require.True(t, ThresholdsPassed(results[:1]))
+	require.True(t, ThresholdsPassed(nil))
+}
@@ -0,0 +1,208 @@
+// Package metrics exposes the live benchmark metrics in the Prometheus format
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// maxErrorLabels limits the error label values, the errors above it are counted as other
+const maxErrorLabels = 100
+
+// Source is the running benchmark state which is not in the requests stats
+type Source interface {
+	InFlight() int
+	TargetRate() float64
+}
+
+// Exporter counts the requests stats it observes and serves them over HTTP
+type Exporter struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration prometheus.Histogram
+	bytes    prometheus.Counter
+
+	rate *rateMeter
+
+	mu          sync.Mutex
+	errorLabels map[string]struct{}
+
+	server *http.Server
+}
+
+// NewExporter creates the exporter of the source benchmark metrics
+func NewExporter(source Source) *Exporter {
+	e := &Exporter{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hload_requests_total",
+			Help: "Requests which received the response by the status code",
+		}, []string{"code"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hload_errors_total",
+			Help: "Failed requests by the error",
+		}, []string{"error"}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "hload_request_duration_seconds",
+			Help:    "Requests duration",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+		}),
+		bytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "hload_response_bytes_total",
+			Help: "Received response body bytes",
+		}),
+		rate:        &rateMeter{},
+		errorLabels: make(map[string]struct{}),
+	}
+
+	e.registry.MustRegister(
+		e.requests,
+		e.errors,
+		e.duration,
+		e.bytes,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "hload_requests_in_flight",
+			Help: "Requests sent and not finished yet",
+		}, func() float64 { return float64(source.InFlight()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "hload_target_requests_per_second",
+			Help: "Requests per second the benchmark is set to send, zero when not limited",
+		}, source.TargetRate),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "hload_achieved_requests_per_second",
+			Help: "Requests finished in the last full second",
+		}, func() float64 { return e.rate.rate(time.Now()) }),
+	)
+
+	return e
+}
+
+// Observe counts the request stat, the flow stats are skipped as their steps are observed separately
+func (e *Exporter) Observe(stat *model.RequestStat) {
+	if stat.Flow {
+		return
+	}
+
+	if loader.StatResponded(stat) {
+		e.requests.WithLabelValues(strconv.Itoa(stat.RetCode)).Inc()
+	}
+
+	if loader.StatFailed(stat) {
+		e.errors.WithLabelValues(e.errorLabel(loader.StatError(stat))).Inc()
+	}
+
+	e.duration.Observe(stat.Duration.Seconds())
+	e.bytes.Add(float64(stat.BodySize))
+	e.rate.add(stat.End)
+}
+
+func (e *Exporter) errorLabel(err string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.errorLabels[err]; ok {
+		return err
+	}
+
+	if len(e.errorLabels) >= maxErrorLabels {
+		return "other"
+	}
+
+	e.errorLabels[err] = struct{}{}
+	return err
+}
+
+// Handler returns the HTTP handler serving the metrics
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Listen starts serving the metrics on the /metrics path, it returns the address it listens on
+func (e *Exporter) Listen(addr string) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e.Handler())
+
+	e.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := e.server.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Metrics server error: %v", err)
+		}
+	}()
+
+	return ln.Addr(), nil
+}
+
+// Close stops serving the metrics
+func (e *Exporter) Close() error {
+	if e.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return e.server.Shutdown(ctx)
+}
+
+// rateMeter counts the requests finished in the current and the previous second
+type rateMeter struct {
+	mu       sync.Mutex
+	second   int64
+	current  int
+	previous int
+}
+
+func (r *rateMeter) add(end time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.roll(end.Unix())
+	r.current++
+}
+
+// rate returns the requests finished in the last full second
+func (r *rateMeter) rate(now time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.roll(now.Unix())
+	return float64(r.previous)
+}
+
+func (r *rateMeter) roll(second int64) {
+	switch {
+	case second <= r.second:
+		return
+	case second == r.second+1:
+		r.previous = r.current
+	default:
+		r.previous = 0
+	}
+
+	r.current = 0
+	r.second = second
+}
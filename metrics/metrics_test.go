@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testSource struct{}
+
+func (testSource) InFlight() int {
+	return 3
+}
+
+func (testSource) TargetRate() float64 {
+	return 100
+}
+
+func TestExporter(t *testing.T) {
+	e := NewExporter(testSource{})
+
+	end := time.Now().Add(-time.Second)
+	stats := []*model.RequestStat{
+		{End: end, Duration: 5 * time.Millisecond, BodySize: 10, RetCode: 200},
+		{End: end, Duration: 20 * time.Millisecond, BodySize: 10, RetCode: 200},
+		{End: end, Duration: 10 * time.Millisecond, BodySize: 5, RetCode: 500},
+		{End: end, Duration: 1 * time.Second, Error: "timeout"},
+		{End: end, Duration: 30 * time.Millisecond, RetCode: 200, Flow: true},
+	}
+
+	for _, stat := range stats {
+		e.Observe(stat)
+	}
+
+	addr, err := e.Listen("127.0.0.1:0")
+	require.Nil(t, err)
+	defer e.Close()
+
+	resp, err := http.Get("http://" + addr.String() + "/metrics")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+
+	expected := []string{
+		`hload_requests_total{code="200"} 2`,
+		`hload_requests_total{code="500"} 1`,
+		`hload_errors_total{error="Internal Server Error"} 1`,
+		`hload_errors_total{error="timeout"} 1`,
+		`hload_request_duration_seconds_count 4`,
+		`hload_response_bytes_total 25`,
+		`hload_requests_in_flight 3`,
+		`hload_target_requests_per_second 100`,
+	}
+
+	for _, line := range expected {
+		require.Contains(t, string(body), line)
+	}
+}
+
+func TestRateMeter(t *testing.T) {
+	r := &rateMeter{}
+	start := time.Unix(1000, 0)
+
+	for i := 0; i < 5; i++ {
+		r.add(start.Add(time.Duration(i) * 100 * time.Millisecond))
+	}
+	require.Equal(t, float64(0), r.rate(start.Add(500*time.Millisecond)))
+
+	r.add(start.Add(time.Second))
+	require.Equal(t, float64(5), r.rate(start.Add(time.Second+500*time.Millisecond)))
+	require.Equal(t, float64(1), r.rate(start.Add(2*time.Second)))
+	require.Equal(t, float64(0), r.rate(start.Add(5*time.Second)))
+}
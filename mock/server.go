@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -36,6 +37,9 @@ type LoaderHandler struct {
 	Body    [][]byte
 	Headers map[string]*headerCount
 
+	// sessions are the tokens and session cookies given by the login handler
+	sessions sync.Map
+
 	mx sync.Mutex
 }
 
@@ -122,6 +126,22 @@ func (h *LoaderHandler) HandleLongRequests(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *LoaderHandler) HandleSlowRequests(w http.ResponseWriter, r *http.Request) {
+	atomic.AddUint64(&h.Stats.RequestCount, 1)
+	time.Sleep(200 * time.Millisecond)
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleStallRequests every 10th request stalls for 1 second
+func (h *LoaderHandler) HandleStallRequests(w http.ResponseWriter, r *http.Request) {
+	count := atomic.AddUint64(&h.Stats.RequestCount, 1)
+	if count%10 == 0 {
+		time.Sleep(time.Second)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func (h *LoaderHandler) HandleAbortRequests(w http.ResponseWriter, r *http.Request) {
 	atomic.AddUint64(&h.Stats.RequestCount, 1)
 	w.WriteHeader(http.StatusNotFound)
@@ -141,6 +161,57 @@ func (h *LoaderHandler) HandleMixedRequests(w http.ResponseWriter, r *http.Reque
 	h.Stats.RequestCount++
 }
 
+// HandleLoginRequests returns a new token in the JSON body and a new session cookie
+func (h *LoaderHandler) HandleLoginRequests(w http.ResponseWriter, r *http.Request) {
+	count := atomic.AddUint64(&h.Stats.RequestCount, 1)
+
+	token := fmt.Sprintf("token-%d", count)
+	session := fmt.Sprintf("session-%d", count)
+	h.sessions.Store(token, session)
+
+	http.SetCookie(w, &http.Cookie{Name: "session_id", Value: session})
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"data":{"token":"%s","user":{"id":%d}}}`, token, count)
+}
+
+// HandleProfileRequests returns 401 unless the token and session cookie come from the same login
+func (h *LoaderHandler) HandleProfileRequests(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	cookie, err := r.Cookie("session_id")
+
+	session, ok := h.sessions.Load(token)
+	if !ok || err != nil || session != cookie.Value {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	fmt.Fprintf(w, "OK")
+}
+
+// HandleCloseRequests asks the client to close the connection after every response
+func (h *LoaderHandler) HandleCloseRequests(w http.ResponseWriter, r *http.Request) {
+	atomic.AddUint64(&h.Stats.RequestCount, 1)
+	w.Header().Set("Connection", "close")
+	fmt.Fprintf(w, "OK")
+}
+
+// HandleResetRequests resets the connection without the response
+func (h *LoaderHandler) HandleResetRequests(w http.ResponseWriter, r *http.Request) {
+	atomic.AddUint64(&h.Stats.RequestCount, 1)
+
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		// Closing with no linger sends RST instead of FIN
+		tcpConn.SetLinger(0)
+	}
+	conn.Close()
+}
+
 func NewServer(mixedFailedRequests int) (*LoaderHandler, *httptest.Server) {
 	mux := http.NewServeMux()
 	h := &LoaderHandler{
@@ -154,6 +225,12 @@ func NewServer(mixedFailedRequests int) (*LoaderHandler, *httptest.Server) {
 	mux.HandleFunc("/header", h.HandleHeaderRequests)
 	mux.HandleFunc("/abort", h.HandleAbortRequests)
 	mux.HandleFunc("/long", h.HandleLongRequests)
+	mux.HandleFunc("/slow", h.HandleSlowRequests)
+	mux.HandleFunc("/stall", h.HandleStallRequests)
+	mux.HandleFunc("/login", h.HandleLoginRequests)
+	mux.HandleFunc("/profile", h.HandleProfileRequests)
+	mux.HandleFunc("/close", h.HandleCloseRequests)
+	mux.HandleFunc("/reset", h.HandleResetRequests)
 
 	ts := httptest.NewServer(mux)
 
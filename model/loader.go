@@ -3,6 +3,8 @@ package model
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -29,6 +31,10 @@ type Loader struct {
 	GatherFullRequestsStats      bool `json:"gather_full_requests_stats,omitempty" db:"gather_full_requests_stats"`
 	GatherAggregateRequestsStats bool `json:"gather_aggregate_requests_stats,omitempty" db:"gather_aggregate_requests_stats"`
 
+	// CorrectCoordinatedOmission back-fills the latency samples of requests that should have been sent
+	// while the server stalled, based on the expected interval from RateLimit or RequestDelay
+	CorrectCoordinatedOmission bool `json:"correct_coordinated_omission,omitempty" db:"correct_coordinated_omission"`
+
 	AggregateWindow  time.Duration `db:"aggregate_window" json:"aggregate_window,omitempty"`
 	BenchmarkTimeout time.Duration `db:"benchmark_timeout" json:"benchmark_timeout,omitempty"`
 
@@ -37,6 +43,23 @@ type Loader struct {
 
 	Tags []*LoaderTag `json:"tags,omitempty"`
 
+	Stages Stages `json:"stages,omitempty"`
+
+	// Endpoints is the weighted request mix, URL, Method, Body, Headers and Parameters are used when it is empty
+	Endpoints Endpoints `json:"endpoints,omitempty"`
+
+	// Flow is the multi-step user flow every worker runs as a virtual user, it can't be used with Endpoints
+	Flow Flow `json:"flow,omitempty"`
+
+	// Feeder is the data file every request or flow run takes its row from
+	Feeder *Feeder `json:"feeder,omitempty"`
+
+	// Assertions are checked on every response, the request fails when one of them is not met
+	Assertions Assertions `json:"assertions,omitempty"`
+
+	// Thresholds are checked against the summary, the benchmark fails when one of them is not met
+	Thresholds Thresholds `json:"thresholds,omitempty"`
+
 	LoaderReqDetails
 }
 
@@ -45,7 +68,10 @@ type LoaderReqDetails struct {
 	ReqCount    int   `db:"request_count" json:"request_count,omitempty"`
 	AbortAfter  int   `db:"abort_after" json:"abort_after,omitempty"`
 	Connections int   `db:"connections" json:"connections,omitempty"`
-	RateLimit   int   `db:"rate_limit" json:"rate_limit,omitempty"` // How many requests per second is allowed
+	RateLimit   int   `db:"rate_limit" json:"rate_limit,omitempty"`     // How many requests per second is allowed
+	ArrivalRate int   `db:"arrival_rate" json:"arrival_rate,omitempty"` // Open model: how many requests per second are scheduled
+
+	StageTarget string `db:"stage_target" json:"stage_target,omitempty"` // What the Stages target is: connections or rate_limit
 
 	Duration     time.Duration `db:"duration" json:"duration,omitempty"`
 	KeepAlive    time.Duration `db:"keep_alive" json:"keep_alive,omitempty"`
@@ -96,7 +122,7 @@ func (p *Parameters) Set(value string) error {
 	parameters := strings.Split(value, "&")
 
 	for _, param := range parameters {
-		keyValue := strings.Split(param, "=")
+		keyValue := strings.SplitN(param, "=", 2)
 		if len(keyValue) != 2 {
 			return fmt.Errorf("error parse parameter %s", value)
 		}
@@ -108,6 +134,367 @@ func (p *Parameters) Set(value string) error {
 	return nil
 }
 
+const (
+	StageTargetConnections = "connections"
+	StageTargetRateLimit   = "rate_limit"
+
+	StageTransitionLinear = "linear"
+	StageTransitionStep   = "step"
+)
+
+var ErrWrongStageFormat = errors.New("wrong stage format, expected duration:target[:linear|step]")
+
+// Stage is one part of the load profile. Within the Duration the load goes from the previous
+// stage target (zero for the first stage) to Target, linearly or in one step.
+type Stage struct {
+	Duration   time.Duration `db:"duration" json:"duration" mapstructure:"duration"`
+	Target     int           `db:"target" json:"target" mapstructure:"target"`
+	Transition string        `db:"transition" json:"transition,omitempty" mapstructure:"transition"`
+}
+
+type Stages []*Stage
+
+// Set parses the stage in format "duration:target[:transition]", for example "30s:100:linear"
+func (s *Stages) Set(value string) error {
+	stageSplit := strings.Split(value, ":")
+	if len(stageSplit) < 2 || len(stageSplit) > 3 {
+		return ErrWrongStageFormat
+	}
+
+	duration, err := time.ParseDuration(stageSplit[0])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWrongStageFormat, err)
+	}
+
+	target, err := strconv.Atoi(stageSplit[1])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWrongStageFormat, err)
+	}
+
+	transition := StageTransitionLinear
+	if len(stageSplit) == 3 {
+		transition = stageSplit[2]
+	}
+
+	*s = append(*s, &Stage{
+		Duration:   duration,
+		Target:     target,
+		Transition: transition,
+	})
+
+	return nil
+}
+
+// SetDefaults sets the linear transition for the stages without one
+func (s Stages) SetDefaults() {
+	for _, stage := range s {
+		if stage.Transition == "" {
+			stage.Transition = StageTransitionLinear
+		}
+	}
+}
+
+// Duration returns the duration of all stages
+func (s Stages) Duration() time.Duration {
+	var d time.Duration
+	for _, stage := range s {
+		d += stage.Duration
+	}
+
+	return d
+}
+
+// MaxTarget returns the highest target of all stages
+func (s Stages) MaxTarget() int {
+	var m int
+	for _, stage := range s {
+		if stage.Target > m {
+			m = stage.Target
+		}
+	}
+
+	return m
+}
+
+// Level returns the load level at the elapsed time since the profile start
+func (s Stages) Level(elapsed time.Duration) float64 {
+	var prev float64
+	for _, stage := range s {
+		target := float64(stage.Target)
+		if elapsed < stage.Duration {
+			if stage.Transition == StageTransitionStep {
+				return target
+			}
+
+			return prev + (target-prev)*float64(elapsed)/float64(stage.Duration)
+		}
+
+		elapsed -= stage.Duration
+		prev = target
+	}
+
+	return prev
+}
+
+var ErrWrongEndpointFormat = errors.New("wrong endpoint format, expected weight:method:url")
+
+// Endpoint is one request definition of the weighted request mix.
+// Relative URLs are resolved against the loader URL and the loader headers are sent with every endpoint.
+type Endpoint struct {
+	Name   string `db:"name" json:"name,omitempty" mapstructure:"name"`
+	URL    string `db:"url" json:"url" mapstructure:"url"`
+	Method string `db:"method" json:"method,omitempty" mapstructure:"method"`
+	Weight int    `db:"weight" json:"weight,omitempty" mapstructure:"weight"`
+	Body   []byte `db:"body" json:"body,omitempty" mapstructure:"body"`
+
+	Headers    Headers    `db:"-" json:"headers,omitempty" mapstructure:"headers"`
+	Parameters Parameters `db:"-" json:"parameters,omitempty" mapstructure:"parameters"`
+}
+
+type Endpoints []*Endpoint
+
+// Set parses the endpoint in format "weight:method:url", for example "70:GET:/items"
+func (e *Endpoints) Set(value string) error {
+	endpointSplit := strings.SplitN(value, ":", 3)
+	if len(endpointSplit) != 3 {
+		return ErrWrongEndpointFormat
+	}
+
+	weight, err := strconv.Atoi(endpointSplit[0])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWrongEndpointFormat, err)
+	}
+
+	if endpointSplit[1] == "" || endpointSplit[2] == "" {
+		return ErrWrongEndpointFormat
+	}
+
+	*e = append(*e, &Endpoint{
+		URL:    endpointSplit[2],
+		Method: strings.ToUpper(endpointSplit[1]),
+		Weight: weight,
+	})
+
+	return nil
+}
+
+// SetDefaults sets the GET method, weight 1 and the "METHOD url" name for the endpoints without them
+func (e Endpoints) SetDefaults() {
+	for _, endpoint := range e {
+		if endpoint.Method == "" {
+			endpoint.Method = "GET"
+		}
+
+		if endpoint.Weight == 0 {
+			endpoint.Weight = 1
+		}
+
+		if endpoint.Name == "" {
+			endpoint.Name = endpoint.Method + " " + endpoint.URL
+		}
+	}
+}
+
+const (
+	ExtractSourceJSON   = "json"
+	ExtractSourceHeader = "header"
+	ExtractSourceCookie = "cookie"
+)
+
+// Extract saves a value from the flow step response into the virtual user variable
+type Extract struct {
+	Variable string `json:"variable" mapstructure:"variable"`
+	Source   string `json:"source" mapstructure:"source"`
+	// Path is the dot separated JSON path (data.items.0.id), the header name or the cookie name
+	Path string `json:"path" mapstructure:"path"`
+}
+
+// FlowStep is one request of the multi-step user flow.
+// Variables extracted by the previous steps are used in the URL, headers, body and parameters as ${name}.
+type FlowStep struct {
+	Endpoint `mapstructure:",squash"`
+
+	Extract []*Extract `json:"extract,omitempty" mapstructure:"extract"`
+}
+
+type Flow []*FlowStep
+
+// SetDefaults sets the GET method and the "METHOD url" name for the steps without them
+func (f Flow) SetDefaults() {
+	for _, step := range f {
+		if step.Method == "" {
+			step.Method = "GET"
+		}
+
+		if step.Name == "" {
+			step.Name = step.Method + " " + step.URL
+		}
+	}
+}
+
+const (
+	FeederModeSequential = "sequential"
+	FeederModeRandom     = "random"
+	FeederModeUnique     = "unique"
+
+	FeederFormatCSV   = "csv"
+	FeederFormatJSONL = "jsonl"
+)
+
+// Feeder is the CSV (with the header row) or JSONL data file. Every request, or every flow run, takes one row
+// and its columns are used in the URL, headers, body and parameters as {{feed column}}.
+// Rows are taken sequentially, randomly or uniquely, the unique mode stops the benchmark when the rows run out.
+type Feeder struct {
+	Path   string `db:"path" json:"path" mapstructure:"path"`
+	Format string `db:"format" json:"format,omitempty" mapstructure:"format"`
+	Mode   string `db:"mode" json:"mode,omitempty" mapstructure:"mode"`
+
+	// Data is the file content, it is saved with the loader so the benchmark does not depend on the file
+	Data []byte `db:"data" json:"data,omitempty" mapstructure:"-"`
+}
+
+// SetDefaults sets the sequential mode and the format from the file extension when they are not set
+func (f *Feeder) SetDefaults() {
+	if f.Mode == "" {
+		f.Mode = FeederModeSequential
+	}
+
+	if f.Format == "" {
+		switch strings.ToLower(filepath.Ext(f.Path)) {
+		case ".jsonl", ".ndjson":
+			f.Format = FeederFormatJSONL
+		default:
+			f.Format = FeederFormatCSV
+		}
+	}
+}
+
+const (
+	AssertionStatus      = "status"
+	AssertionBody        = "body"
+	AssertionBodyRegexp  = "body_regexp"
+	AssertionJSON        = "json"
+	AssertionHeader      = "header"
+	AssertionMaxBodySize = "max_body_size"
+)
+
+var ErrWrongAssertionFormat = errors.New("wrong assertion format, expected type:value")
+
+// Assertion is the check of the response. Value depends on the type:
+//   - status: the expected status codes and classes, for example "200,201,4xx", it replaces the 2xx check
+//   - body: the substring of the body
+//   - body_regexp: the regular expression the body matches
+//   - json: the expected value on the dot separated JSON Path, for example "$.status"
+//   - header: the expected value of the Path header, the header only has to be present when the value is empty
+//   - max_body_size: the maximum body size in bytes
+type Assertion struct {
+	Type  string `db:"type" json:"type" mapstructure:"type"`
+	Path  string `db:"path" json:"path,omitempty" mapstructure:"path"`
+	Value string `db:"value" json:"value,omitempty" mapstructure:"value"`
+}
+
+// String returns the assertion in the Set format
+func (a *Assertion) String() string {
+	switch a.Type {
+	case AssertionJSON, AssertionHeader:
+		if a.Value == "" {
+			return a.Type + ":" + a.Path
+		}
+
+		return a.Type + ":" + a.Path + "=" + a.Value
+	default:
+		return a.Type + ":" + a.Value
+	}
+}
+
+type Assertions []*Assertion
+
+// Set parses the assertion in format "type:value", the json and header assertions are "type:path=value",
+// for example "status:200,201", "body:ok", "json:$.status=ok", "header:Content-Type=application/json"
+func (a *Assertions) Set(value string) error {
+	assertionSplit := strings.SplitN(value, ":", 2)
+	if len(assertionSplit) != 2 || assertionSplit[1] == "" {
+		return ErrWrongAssertionFormat
+	}
+
+	assertion := &Assertion{
+		Type:  assertionSplit[0],
+		Value: assertionSplit[1],
+	}
+
+	switch assertion.Type {
+	case AssertionJSON, AssertionHeader:
+		pathValue := strings.SplitN(assertion.Value, "=", 2)
+		assertion.Path = pathValue[0]
+		assertion.Value = ""
+		if len(pathValue) == 2 {
+			assertion.Value = pathValue[1]
+		}
+	case AssertionStatus, AssertionBody, AssertionBodyRegexp, AssertionMaxBodySize:
+	default:
+		return fmt.Errorf("%w: unknown assertion type %s", ErrWrongAssertionFormat, assertion.Type)
+	}
+
+	*a = append(*a, assertion)
+
+	return nil
+}
+
+// Threshold operators
+const (
+	ThresholdLess         = "<"
+	ThresholdLessEqual    = "<="
+	ThresholdGreater      = ">"
+	ThresholdGreaterEqual = ">="
+	ThresholdEqual        = "=="
+	ThresholdNotEqual     = "!="
+)
+
+// thresholdOperators are in the parsing order, the two characters operators first
+var thresholdOperators = []string{ThresholdLessEqual, ThresholdGreaterEqual, ThresholdEqual, ThresholdNotEqual, ThresholdLess, ThresholdGreater}
+
+var ErrWrongThresholdFormat = errors.New("wrong threshold format, expected metric operator value")
+
+// Threshold is the rule the summary metric has to meet, for example "p99 < 200ms", "fail_rate < 0.5%",
+// "req_per_sec > 1000" or "http_code[5xx] == 0"
+type Threshold struct {
+	Metric   string `db:"metric" json:"metric" mapstructure:"metric"`
+	Operator string `db:"operator" json:"operator" mapstructure:"operator"`
+	Value    string `db:"value" json:"value" mapstructure:"value"`
+}
+
+// String returns the threshold in the Set format
+func (t *Threshold) String() string {
+	return t.Metric + " " + t.Operator + " " + t.Value
+}
+
+type Thresholds []*Threshold
+
+// Set parses the threshold in format "metric operator value", the spaces around the operator are optional
+func (t *Thresholds) Set(value string) error {
+	for _, operator := range thresholdOperators {
+		idx := strings.Index(value, operator)
+		if idx == -1 {
+			continue
+		}
+
+		threshold := &Threshold{
+			Metric:   strings.TrimSpace(value[:idx]),
+			Operator: operator,
+			Value:    strings.TrimSpace(value[idx+len(operator):]),
+		}
+
+		if threshold.Metric == "" || threshold.Value == "" {
+			return ErrWrongThresholdFormat
+		}
+
+		*t = append(*t, threshold)
+		return nil
+	}
+
+	return ErrWrongThresholdFormat
+}
+
 type LoaderTag struct {
 	Key        string    `db:"key" json:"key,omitempty"`
 	Value      string    `db:"value" json:"value,omitempty"`
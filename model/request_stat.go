@@ -12,6 +12,24 @@ type RequestStat struct {
 
 	RetCode int    `json:"ret_code" db:"ret_code"`
 	Error   string `json:"error" db:"error"`
+
+	// Phases are the request phases durations, measured when the HTTP engine traces the connection
+	Phases
+
+	// Endpoint is the name of the request mix endpoint, empty when the loader has no endpoints
+	Endpoint string `json:"endpoint,omitempty" db:"endpoint"`
+
+	// Flow is set for the stat of the whole user flow, the steps are reported as separate stats
+	Flow bool `json:"-" db:"-"`
+
+	// Late is set in the open model when the request waited for a free worker
+	Late bool `json:"-" db:"-"`
+
+	// StatusAsserted is set when the status assertion decides which status codes are successful instead of 2xx
+	StatusAsserted bool `json:"-" db:"-"`
+
+	// AssertionFailed is set when the response did not meet the assertion, the Error is the assertion name
+	AssertionFailed bool `json:"-" db:"-"`
 }
 
 // AggregatedStat provides a average request time within a timeframe from start to end
@@ -24,4 +42,26 @@ type AggregatedStat struct {
 	MaxRequestTime time.Duration `json:"max_request_time" db:"max_request_time"`
 	MinRequestTime time.Duration `json:"min_request_time" db:"min_request_time"`
 	RequestCount   int           `json:"request_count" db:"request_count"`
+
+	// Average request phases durations, the connection phases count as zero for the reused connections
+	AvgDNS      time.Duration `json:"avg_dns" db:"avg_dns"`
+	AvgConnect  time.Duration `json:"avg_connect" db:"avg_connect"`
+	AvgTLS      time.Duration `json:"avg_tls" db:"avg_tls"`
+	AvgTTFB     time.Duration `json:"avg_ttfb" db:"avg_ttfb"`
+	AvgTransfer time.Duration `json:"avg_transfer" db:"avg_transfer"`
+
+	// Connections lifecycle counters change within the window
+	ConnectionStats
+}
+
+// Phases are the durations of the HTTP request phases.
+// DNS, Connect and TLS are zero when the request was sent over the already open connection.
+type Phases struct {
+	DNS     time.Duration `json:"dns,omitempty" db:"dns"`
+	Connect time.Duration `json:"connect,omitempty" db:"connect"`
+	TLS     time.Duration `json:"tls,omitempty" db:"tls"`
+	// TTFB is the time from the request written to the first byte of the response
+	TTFB time.Duration `json:"ttfb,omitempty" db:"ttfb"`
+	// Transfer is the time from the first byte of the response to the whole body read
+	Transfer time.Duration `json:"transfer,omitempty" db:"transfer"`
 }
@@ -15,6 +15,8 @@ type Summary struct {
 	ReqCount        int `db:"requests_count" json:"requests_count"`
 	SuccessReq      int `db:"success_req" json:"success_req"` // Requests with return code 2x
 	FailReq         int `db:"fail_req" json:"fail_req"`       // Requests with return code != 2x
+	DroppedReq      int `db:"dropped_req" json:"dropped_req"` // Open model: requests not sent because all workers were busy
+	LateReq         int `db:"late_req" json:"late_req"`       // Open model: requests sent later than scheduled
 	DataTransferred int `db:"data_transferred" json:"data_transferred"`
 
 	ReqPerSec float64 `db:"req_per_sec" json:"req_per_sec"` // Request per second
@@ -28,13 +30,127 @@ type Summary struct {
 	P90ReqTime time.Duration `db:"p90_req_time" json:"p_90_req_time"` // 90th percentile
 	P99ReqTime time.Duration `db:"p99_req_time" json:"p_99_req_time"` // 99th percentile
 
+	// Percentiles corrected for the coordinated omission, zero when the correction was not enabled
+	CorrectedP50ReqTime time.Duration `db:"corrected_p50_req_time" json:"corrected_p_50_req_time"`
+	CorrectedP75ReqTime time.Duration `db:"corrected_p75_req_time" json:"corrected_p_75_req_time"`
+	CorrectedP90ReqTime time.Duration `db:"corrected_p90_req_time" json:"corrected_p_90_req_time"`
+	CorrectedP99ReqTime time.Duration `db:"corrected_p99_req_time" json:"corrected_p_99_req_time"`
+
 	StdDeviation float64 `db:"std_deviation" json:"std_deviation"` // Standard deviation
 
+	// Aborted is set when the benchmark stopped after the AbortAfter failed requests,
+	// TimedOut when it did not send the requests count within the BenchmarkTimeout
+	Aborted  bool `db:"-" json:"aborted,omitempty"`
+	TimedOut bool `db:"-" json:"timed_out,omitempty"`
+
+	ConnectionStats
+
+	// TDigest and CorrectedTDigest are the serialized request durations t-digests the summaries are merged with
+	// and any percentile is computed from later
+	TDigest          []byte `db:"tdigest" json:"tdigest,omitempty"`
+	CorrectedTDigest []byte `db:"corrected_tdigest" json:"corrected_tdigest,omitempty"`
+
+	// Percentiles are the requested percentiles computed from the TDigest, they are not saved
+	Percentiles []*Percentile `db:"-" json:"percentiles,omitempty"`
+
 	LoaderConf string `db:"loader_uuid" json:"-"`
 
+	// Sources are the UUIDs of the summaries the summary was merged from, empty for the benchmark summary
+	Sources []string `db:"-" json:"sources,omitempty"`
+
 	Errors    map[string]int `json:"errors,omitempty"`
 	HTTPCodes map[int]int    `json:"http_codes,omitempty"`
 
+	// Endpoints is the breakdown of the request mix, empty when the loader has no endpoints
+	Endpoints []*EndpointSummary `json:"endpoints,omitempty"`
+	// Flow is the whole user flow part of the summary, the flow steps are in Endpoints
+	Flow *EndpointSummary `json:"flow,omitempty"`
+
+	// Phases are the request phases percentiles, empty when no phase was measured
+	Phases []*PhaseSummary `json:"phases,omitempty"`
+
 	AggregatedStats []*AggregatedStat `json:"aggregated_stats,omitempty"`
 	RequestStats    []*RequestStat    `json:"request_stats,omitempty"`
 }
+
+// Percentile is the request duration percentile, e.g. 99.9 for p99.9
+type Percentile struct {
+	Percentile float64       `json:"percentile"`
+	Value      time.Duration `json:"value"`
+}
+
+// EndpointSummary is the part of the summary of one request mix endpoint, flow step or the whole flow
+type EndpointSummary struct {
+	Name   string `db:"name" json:"name"`
+	URL    string `db:"url" json:"url"`
+	Method string `db:"method" json:"method"`
+
+	ReqCount        int `db:"requests_count" json:"requests_count"`
+	SuccessReq      int `db:"success_req" json:"success_req"`
+	FailReq         int `db:"fail_req" json:"fail_req"`
+	DataTransferred int `db:"data_transferred" json:"data_transferred"`
+
+	ReqPerSec float64 `db:"req_per_sec" json:"req_per_sec"`
+
+	AvgReqTime time.Duration `db:"avg_req_time" json:"avg_req_time"`
+	MinReqTime time.Duration `db:"min_req_time" json:"min_req_time"`
+	MaxReqTime time.Duration `db:"max_req_time" json:"max_req_time"`
+
+	P50ReqTime time.Duration `db:"p50_req_time" json:"p_50_req_time"`
+	P75ReqTime time.Duration `db:"p75_req_time" json:"p_75_req_time"`
+	P90ReqTime time.Duration `db:"p90_req_time" json:"p_90_req_time"`
+	P99ReqTime time.Duration `db:"p99_req_time" json:"p_99_req_time"`
+
+	// TDigest is the serialized request durations t-digest
+	TDigest []byte `db:"-" json:"tdigest,omitempty"`
+
+	Errors    map[string]int `db:"-" json:"errors,omitempty"`
+	HTTPCodes map[int]int    `db:"-" json:"http_codes,omitempty"`
+}
+
+// ConnectionStats are the connections lifecycle counters of the HTTP engine.
+// Many reused connections mean the keep-alive traffic, many new ones a handshake per request.
+type ConnectionStats struct {
+	NewConnections    int `db:"new_connections" json:"new_connections"`       // TCP connections opened
+	ReusedConnections int `db:"reused_connections" json:"reused_connections"` // Requests sent over the already used connection
+	ClosedConnections int `db:"closed_connections" json:"closed_connections"` // Connections closed before the benchmark end
+	ResetConnections  int `db:"reset_connections" json:"reset_connections"`   // Connections reset by the peer
+}
+
+// Sub returns the counters change since the earlier counters
+func (c ConnectionStats) Sub(earlier ConnectionStats) ConnectionStats {
+	return ConnectionStats{
+		NewConnections:    c.NewConnections - earlier.NewConnections,
+		ReusedConnections: c.ReusedConnections - earlier.ReusedConnections,
+		ClosedConnections: c.ClosedConnections - earlier.ClosedConnections,
+		ResetConnections:  c.ResetConnections - earlier.ResetConnections,
+	}
+}
+
+// Request phases names
+const (
+	PhaseDNS      = "dns"
+	PhaseConnect  = "connect"
+	PhaseTLS      = "tls"
+	PhaseTTFB     = "ttfb"
+	PhaseTransfer = "transfer"
+)
+
+// PhaseSummary is the part of the summary of one request phase.
+// ReqCount is the number of requests the phase was measured in, the connection phases happen only for the new connections.
+type PhaseSummary struct {
+	Name     string `db:"name" json:"name"`
+	ReqCount int    `db:"requests_count" json:"requests_count"`
+
+	AvgTime time.Duration `db:"avg_time" json:"avg_time"`
+	MinTime time.Duration `db:"min_time" json:"min_time"`
+	MaxTime time.Duration `db:"max_time" json:"max_time"`
+
+	P50Time time.Duration `db:"p50_time" json:"p_50_time"`
+	P75Time time.Duration `db:"p75_time" json:"p_75_time"`
+	P90Time time.Duration `db:"p90_time" json:"p_90_time"`
+	P99Time time.Duration `db:"p99_time" json:"p_99_time"`
+
+	// TDigest is the serialized phase durations t-digest
+	TDigest []byte `db:"-" json:"tdigest,omitempty"`
+}
@@ -0,0 +1,78 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+)
+
+// Case is one loader run of the JUnit and TAP reports
+type Case struct {
+	Loader  *model.Loader
+	Summary *model.Summary
+	// Thresholds are the summary thresholds check results
+	Thresholds []*loader.ThresholdResult
+	// Err is the error the loader could not run with, the case has no summary then
+	Err error
+	// Skipped is set when the loader was not run, e.g. the batch was interrupted
+	Skipped bool
+}
+
+// Name returns the loader name, the UUID or the URL when the loader has no name
+func (c *Case) Name() string {
+	switch {
+	case c.Loader.Name != "":
+		return c.Loader.Name
+	case c.Loader.UUID != "":
+		return c.Loader.UUID
+	default:
+		return c.Loader.URL
+	}
+}
+
+// Failures returns the reasons the case failed: the failed thresholds, the abort and the benchmark timeout
+func (c *Case) Failures() []string {
+	if c.Summary == nil {
+		return nil
+	}
+
+	var failures []string
+	for _, result := range c.Thresholds {
+		if !result.Pass {
+			failures = append(failures, fmt.Sprintf("threshold %s failed, actual %s", result.Threshold, result.Actual))
+		}
+	}
+
+	if c.Summary.Aborted {
+		failures = append(failures, fmt.Sprintf("aborted after %d failed requests", c.Loader.AbortAfter))
+	}
+
+	if c.Summary.TimedOut {
+		failures = append(failures, fmt.Sprintf("benchmark timeout %v reached", c.Loader.BenchmarkTimeout))
+	}
+
+	return failures
+}
+
+// Failed reports whether the loader run but did not pass
+func (c *Case) Failed() bool {
+	return len(c.Failures()) > 0
+}
+
+// Metrics returns the summary metrics in one line
+func (c *Case) Metrics() string {
+	s := c.Summary
+	if s == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("requests=%d success=%d fail=%d req_per_sec=%.2f p50=%v p90=%v p99=%v",
+		s.ReqCount, s.SuccessReq, s.FailReq, s.ReqPerSec, s.P50ReqTime, s.P90ReqTime, s.P99ReqTime)
+}
+
+// message is the failure message with the summary metrics
+func (c *Case) message() string {
+	return strings.Join(c.Failures(), "; ") + " (" + c.Metrics() + ")"
+}
@@ -0,0 +1,248 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth  = 900
+	chartHeight = 280
+
+	marginLeft   = 70
+	marginRight  = 20
+	marginTop    = 20
+	marginBottom = 50
+
+	gridLines = 4
+)
+
+var palette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"}
+
+// series is one line of the line chart, the values are aligned with the chart times
+type series struct {
+	Name   string
+	Values []float64
+}
+
+// bucket is one bar of the histogram
+type bucket struct {
+	Label string
+	Count int
+}
+
+// niceMax rounds the value up to 1, 2 or 5 times the power of ten so the axis labels are round
+func niceMax(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+
+	exp := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*exp {
+			return m * exp
+		}
+	}
+
+	return 10 * exp
+}
+
+func formatValue(v float64) string {
+	switch {
+	case v == 0:
+		return "0"
+	case v >= 100:
+		return fmt.Sprintf("%.0f", v)
+	case v >= 10:
+		return fmt.Sprintf("%.1f", v)
+	default:
+		return fmt.Sprintf("%.2f", v)
+	}
+}
+
+// yAxis draws the horizontal grid lines with the labels and returns the value to the y coordinate mapping
+func yAxis(b *strings.Builder, maxValue float64, unit string) func(float64) float64 {
+	top := niceMax(maxValue)
+	plotHeight := float64(chartHeight - marginTop - marginBottom)
+
+	for i := 0; i <= gridLines; i++ {
+		v := top * float64(i) / gridLines
+		y := float64(marginTop) + plotHeight - plotHeight*float64(i)/gridLines
+		fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e0e0e0"/>`, marginLeft, y, chartWidth-marginRight, y)
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" text-anchor="end" font-size="11" fill="#555">%s</text>`, marginLeft-6, y+4, formatValue(v))
+	}
+
+	fmt.Fprintf(b, `<text x="14" y="%d" font-size="11" fill="#555" transform="rotate(-90 14 %d)" text-anchor="middle">%s</text>`,
+		marginTop+int(plotHeight)/2, marginTop+int(plotHeight)/2, template.HTMLEscapeString(unit))
+
+	return func(v float64) float64 {
+		return float64(marginTop) + plotHeight - plotHeight*v/top
+	}
+}
+
+// lineChart renders the series over the times as an inline SVG, x axis labels are the time since the first point
+func lineChart(times []time.Time, unit string, ss []series) template.HTML {
+	if len(times) == 0 {
+		return ""
+	}
+
+	var maxValue float64
+	for _, s := range ss {
+		for _, v := range s.Values {
+			maxValue = math.Max(maxValue, v)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="100%%" xmlns="http://www.w3.org/2000/svg">`, chartWidth, chartHeight)
+	y := yAxis(&b, maxValue, unit)
+
+	first := times[0]
+	span := times[len(times)-1].Sub(first)
+	plotWidth := float64(chartWidth - marginLeft - marginRight)
+	x := func(t time.Time) float64 {
+		if span <= 0 {
+			return float64(marginLeft) + plotWidth/2
+		}
+
+		return float64(marginLeft) + plotWidth*float64(t.Sub(first))/float64(span)
+	}
+
+	labels := gridLines + 1
+	if len(times) < labels {
+		labels = len(times)
+	}
+	for i := 0; i < labels; i++ {
+		idx := 0
+		if labels > 1 {
+			idx = i * (len(times) - 1) / (labels - 1)
+		}
+
+		t := times[idx]
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" font-size="11" fill="#555">%s</text>`,
+			x(t), chartHeight-marginBottom+16, t.Sub(first).Round(time.Second))
+	}
+
+	for i, s := range ss {
+		color := palette[i%len(palette)]
+		points := make([]string, 0, len(s.Values))
+		for j, v := range s.Values {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(times[j]), y(v)))
+		}
+
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, color, strings.Join(points, " "))
+		for _, p := range points {
+			xy := strings.Split(p, ",")
+			fmt.Fprintf(&b, `<circle cx="%s" cy="%s" r="2.5" fill="%s"/>`, xy[0], xy[1], color)
+		}
+
+		legendX := marginLeft + i*110
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`, legendX, chartHeight-18, color)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="12" fill="#333">%s</text>`, legendX+16, chartHeight-8, template.HTMLEscapeString(s.Name))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// barChart renders the buckets as vertical bars in an inline SVG
+func barChart(buckets []bucket, unit string) template.HTML {
+	if len(buckets) == 0 {
+		return ""
+	}
+
+	var maxCount float64
+	for _, bu := range buckets {
+		maxCount = math.Max(maxCount, float64(bu.Count))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="100%%" xmlns="http://www.w3.org/2000/svg">`, chartWidth, chartHeight)
+	y := yAxis(&b, maxCount, "requests")
+
+	plotWidth := float64(chartWidth - marginLeft - marginRight)
+	barWidth := plotWidth / float64(len(buckets))
+	labelEvery := int(math.Ceil(float64(len(buckets)) / 10))
+
+	for i, bu := range buckets {
+		x := float64(marginLeft) + barWidth*float64(i)
+		top := y(float64(bu.Count))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %d</title></rect>`,
+			x+1, top, math.Max(barWidth-2, 1), float64(chartHeight-marginBottom)-top, palette[0], template.HTMLEscapeString(bu.Label), bu.Count)
+
+		if i%labelEvery == 0 || i == len(buckets)-1 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" font-size="11" fill="#555">%s</text>`,
+				x+barWidth/2, chartHeight-marginBottom+16, template.HTMLEscapeString(bu.Label))
+		}
+	}
+
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-size="11" fill="#555">%s</text>`,
+		marginLeft+int(plotWidth)/2, chartHeight-8, template.HTMLEscapeString(unit))
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// histogram splits the durations into linear buckets between the min and the 99th percentile,
+// the slower requests land in the last bucket so a few outliers do not squash the chart
+func histogram(durations []time.Duration, bins int) []bucket {
+	if len(durations) == 0 || bins < 1 {
+		return nil
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	low := sorted[0]
+	high := percentile(sorted, 0.99)
+	width := (high - low) / time.Duration(bins)
+	if width <= 0 {
+		return []bucket{{Label: fmt.Sprintf("%.2f", toMs(low)), Count: len(sorted)}}
+	}
+
+	buckets := make([]bucket, bins+1)
+	for i := 0; i < bins; i++ {
+		buckets[i].Label = fmt.Sprintf("%.2f", toMs(low+width*time.Duration(i)))
+	}
+	buckets[bins].Label = fmt.Sprintf(">%.2f", toMs(high))
+
+	for _, d := range sorted {
+		idx := int((d - low) / width)
+		if d > high {
+			idx = bins
+		} else if idx >= bins {
+			idx = bins - 1
+		}
+
+		buckets[idx].Count++
+	}
+
+	if buckets[bins].Count == 0 {
+		buckets = buckets[:bins]
+	}
+
+	return buckets
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+
+	return sorted[idx]
+}
+
+func toMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
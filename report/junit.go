@@ -0,0 +1,113 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JUnitSuiteName is the name of the JUnit test suite with the loaders
+const JUnitSuiteName = "hload"
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit writes the loaders runs as the JUnit XML report, every loader is the testcase
+func JUnit(w io.Writer, cases []*Case) error {
+	suite := junitSuite{
+		Name:  JUnitSuiteName,
+		Tests: len(cases),
+	}
+
+	var total float64
+	for _, c := range cases {
+		tc := junitCase{
+			Name:      c.Name(),
+			ClassName: JUnitSuiteName,
+			Time:      "0",
+		}
+
+		if c.Summary != nil {
+			total += c.Summary.TotalTime.Seconds()
+			tc.Time = fmt.Sprintf("%.3f", c.Summary.TotalTime.Seconds())
+			tc.SystemOut = c.Metrics()
+		}
+
+		switch {
+		case c.Skipped:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: "not run"}
+		case c.Err != nil:
+			suite.Errors++
+			tc.Error = &junitMessage{Message: c.Err.Error(), Type: "error"}
+		case c.Failed():
+			suite.Failures++
+			tc.Failure = &junitMessage{
+				Message: c.message(),
+				Type:    "failure",
+				Text:    strings.Join(c.Failures(), "\n") + "\n" + c.Metrics(),
+			}
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	suite.Time = fmt.Sprintf("%.3f", total)
+	suites := junitSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(suites)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,101 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmwalaszek/hload/loader"
+	"github.com/tmwalaszek/hload/model"
+)
+
+func testCases(t *testing.T) []*Case {
+	summary := &model.Summary{
+		TotalTime:  2 * time.Second,
+		ReqCount:   100,
+		SuccessReq: 90,
+		FailReq:    10,
+		ReqPerSec:  50,
+		P50ReqTime: 10 * time.Millisecond,
+		P90ReqTime: 20 * time.Millisecond,
+		P99ReqTime: 30 * time.Millisecond,
+	}
+
+	var thresholds model.Thresholds
+	require.NoError(t, thresholds.Set("p99 < 20ms"))
+	require.NoError(t, thresholds.Set("requests == 100"))
+
+	results, err := loader.CheckThresholds(thresholds, summary)
+	require.NoError(t, err)
+
+	aborted := *summary
+	aborted.Aborted = true
+
+	timedOut := *summary
+	timedOut.TimedOut = true
+
+	return []*Case{
+		{Loader: &model.Loader{Name: "passed"}, Summary: summary, Thresholds: results[1:]},
+		{Loader: &model.Loader{Name: "thresholds"}, Summary: summary, Thresholds: results},
+		{Loader: &model.Loader{Name: "aborted", LoaderReqDetails: model.LoaderReqDetails{AbortAfter: 5}}, Summary: &aborted},
+		{Loader: &model.Loader{UUID: "timed-out", BenchmarkTimeout: time.Second}, Summary: &timedOut},
+		{Loader: &model.Loader{Name: "error"}, Err: errors.New("connection refused")},
+		{Loader: &model.Loader{Name: "skipped"}, Skipped: true},
+	}
+}
+
+func TestCaseFailures(t *testing.T) {
+	cases := testCases(t)
+
+	require.False(t, cases[0].Failed())
+	require.Equal(t, []string{"threshold p99 < 20ms failed, actual 30ms"}, cases[1].Failures())
+	require.Equal(t, []string{"aborted after 5 failed requests"}, cases[2].Failures())
+	require.Equal(t, []string{"benchmark timeout 1s reached"}, cases[3].Failures())
+	require.Equal(t, "timed-out", cases[3].Name())
+	require.False(t, cases[4].Failed())
+	require.Equal(t, "requests=100 success=90 fail=10 req_per_sec=50.00 p50=10ms p90=20ms p99=30ms", cases[0].Metrics())
+}
+
+func TestJUnit(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, JUnit(&b, testCases(t)))
+
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(b.Bytes(), &suites))
+	require.Equal(t, 6, suites.Tests)
+	require.Equal(t, 3, suites.Failures)
+	require.Equal(t, 1, suites.Errors)
+	require.Equal(t, 1, suites.Skipped)
+	require.Len(t, suites.Suites, 1)
+
+	cases := suites.Suites[0].Cases
+	require.Len(t, cases, 6)
+	require.Nil(t, cases[0].Failure)
+	require.Equal(t, "2.000", cases[0].Time)
+	require.NotNil(t, cases[1].Failure)
+	require.True(t, strings.HasPrefix(cases[1].Failure.Message, "threshold p99 < 20ms failed"))
+	require.Contains(t, cases[1].Failure.Message, "requests=100")
+	require.NotNil(t, cases[4].Error)
+	require.Equal(t, "connection refused", cases[4].Error.Message)
+	require.NotNil(t, cases[5].Skipped)
+}
+
+func TestTAP(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, TAP(&b, testCases(t)))
+
+	out := b.String()
+	require.True(t, strings.HasPrefix(out, "TAP version 13\n1..6\n"))
+	require.Contains(t, out, "ok 1 - passed\n")
+	require.Contains(t, out, "not ok 2 - thresholds\n")
+	require.Contains(t, out, "not ok 3 - aborted\n")
+	require.Contains(t, out, "not ok 4 - timed-out\n")
+	require.Contains(t, out, "not ok 5 - error\n")
+	require.Contains(t, out, "ok 6 - skipped # SKIP not run\n")
+	require.Contains(t, out, "    - \"aborted after 5 failed requests\"\n")
+	require.Contains(t, out, "    p99: 30ms\n")
+}
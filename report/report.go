@@ -0,0 +1,297 @@
+// Package report renders a summary as a single self-contained HTML page.
+// The charts are inline SVG so the file can be attached anywhere and opened offline.
+// It also writes the loaders runs as the JUnit XML and TAP reports for the CI servers.
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmwalaszek/hload/model"
+)
+
+//go:embed report.html
+var reportTemplate string
+
+// HistogramBins is the number of the latency histogram buckets up to the 99th percentile
+const HistogramBins = 40
+
+// field is one row of the loader configuration table
+type field struct {
+	Name  string
+	Value string
+}
+
+// share is one row of the status codes or errors breakdown
+type share struct {
+	Name    string
+	Count   int
+	Percent float64
+}
+
+type page struct {
+	Loader    *model.Loader
+	Summary   *model.Summary
+	Generated time.Time
+
+	Config []field
+	Codes  []share
+	Errors []share
+
+	Latency    template.HTML
+	Throughput template.HTML
+	Histogram  template.HTML
+}
+
+// HTML writes the summary report of the loader configuration run to w
+func HTML(w io.Writer, loaderConf *model.Loader, summary *model.Summary) error {
+	p := page{
+		Loader:    loaderConf,
+		Summary:   summary,
+		Generated: time.Now(),
+		Config:    loaderFields(loaderConf),
+		Codes:     httpCodesShares(summary.HTTPCodes),
+		Errors:    errorsShares(summary.Errors),
+	}
+
+	p.Latency, p.Throughput = timeCharts(summary.AggregatedStats, summary.RequestStats)
+
+	if len(summary.RequestStats) > 0 {
+		durations := make([]time.Duration, 0, len(summary.RequestStats))
+		for _, stat := range summary.RequestStats {
+			durations = append(durations, stat.Duration)
+		}
+
+		p.Histogram = barChart(histogram(durations, HistogramBins), "request time (ms)")
+	}
+
+	funcs := template.FuncMap{
+		"ms": func(d time.Duration) string {
+			return fmt.Sprintf("%.2f ms", toMs(d))
+		},
+		"pct": func(v float64) string {
+			return fmt.Sprintf("%.2f%%", v)
+		},
+		"time": func(t time.Time) string {
+			return t.Format("2006-01-02 15:04:05 MST")
+		},
+	}
+
+	t, err := template.New("report").Funcs(funcs).Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+
+	return t.Execute(w, p)
+}
+
+// timeCharts returns the latency and the throughput over the aggregation windows.
+// The windows percentiles are calculated only when the requests stats were saved as well.
+func timeCharts(aggregated []*model.AggregatedStat, requests []*model.RequestStat) (template.HTML, template.HTML) {
+	if len(aggregated) == 0 {
+		return "", ""
+	}
+
+	windows := make([]*model.AggregatedStat, len(aggregated))
+	copy(windows, aggregated)
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+
+	times := make([]time.Time, len(windows))
+	minS := series{Name: "min"}
+	avgS := series{Name: "avg"}
+	maxS := series{Name: "max"}
+	rps := series{Name: "req/s"}
+
+	for i, window := range windows {
+		times[i] = window.Start
+		minS.Values = append(minS.Values, toMs(window.MinRequestTime))
+		avgS.Values = append(avgS.Values, toMs(window.AvgRequestTime))
+		maxS.Values = append(maxS.Values, toMs(window.MaxRequestTime))
+
+		// The last window can be cut short by the benchmark end
+		duration := window.Duration
+		if duration <= 0 {
+			duration = window.End.Sub(window.Start)
+		}
+
+		var perSec float64
+		if duration > 0 {
+			perSec = float64(window.RequestCount) / duration.Seconds()
+		}
+		rps.Values = append(rps.Values, perSec)
+	}
+
+	latency := []series{minS, avgS}
+	if len(requests) > 0 {
+		latency = append(latency, windowsPercentiles(windows, requests)...)
+	}
+	latency = append(latency, maxS)
+
+	return lineChart(times, "request time (ms)", latency), lineChart(times, "requests per second", []series{rps})
+}
+
+// windowsPercentiles returns the p50, p90 and p99 of the requests started within every window
+func windowsPercentiles(windows []*model.AggregatedStat, requests []*model.RequestStat) []series {
+	durations := make([][]time.Duration, len(windows))
+	for _, stat := range requests {
+		idx := sort.Search(len(windows), func(i int) bool { return windows[i].Start.After(stat.Start) }) - 1
+		if idx < 0 {
+			continue
+		}
+
+		durations[idx] = append(durations[idx], stat.Duration)
+	}
+
+	p50 := series{Name: "p50"}
+	p90 := series{Name: "p90"}
+	p99 := series{Name: "p99"}
+	for _, d := range durations {
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+		p50.Values = append(p50.Values, toMs(percentile(d, 0.50)))
+		p90.Values = append(p90.Values, toMs(percentile(d, 0.90)))
+		p99.Values = append(p99.Values, toMs(percentile(d, 0.99)))
+	}
+
+	return []series{p50, p90, p99}
+}
+
+func httpCodesShares(codes map[int]int) []share {
+	var total int
+	for _, count := range codes {
+		total += count
+	}
+
+	shares := make([]share, 0, len(codes))
+	for code, count := range codes {
+		shares = append(shares, share{Name: fmt.Sprintf("%d", code), Count: count, Percent: 100 * float64(count) / float64(total)})
+	}
+
+	sort.Slice(shares, func(i, j int) bool { return shares[i].Name < shares[j].Name })
+	return shares
+}
+
+func errorsShares(errors map[string]int) []share {
+	var total int
+	for _, count := range errors {
+		total += count
+	}
+
+	shares := make([]share, 0, len(errors))
+	for name, count := range errors {
+		shares = append(shares, share{Name: name, Count: count, Percent: 100 * float64(count) / float64(total)})
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Count == shares[j].Count {
+			return shares[i].Name < shares[j].Name
+		}
+
+		return shares[i].Count > shares[j].Count
+	})
+	return shares
+}
+
+// loaderFields returns the set loader configuration options
+func loaderFields(l *model.Loader) []field {
+	if l == nil {
+		return nil
+	}
+
+	var fields []field
+	add := func(name string, value any) {
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				return
+			}
+		case int:
+			if v == 0 {
+				return
+			}
+		case time.Duration:
+			if v == 0 {
+				return
+			}
+		case bool:
+			if !v {
+				return
+			}
+		}
+
+		fields = append(fields, field{Name: name, Value: fmt.Sprint(value)})
+	}
+
+	add("UUID", l.UUID)
+	add("Name", l.Name)
+	add("Description", l.Description)
+	add("URL", l.URL)
+	add("Method", l.Method)
+	add("HTTP engine", l.HTTPEngine)
+	add("Connections", l.Connections)
+	add("Requests", l.ReqCount)
+	add("Duration", l.Duration)
+	add("Rate limit", l.RateLimit)
+	add("Arrival rate", l.ArrivalRate)
+	add("Request delay", l.RequestDelay)
+	add("Abort after", l.AbortAfter)
+	add("Keep alive", l.KeepAlive)
+	add("Read timeout", l.ReadTimeout)
+	add("Write timeout", l.WriteTimeout)
+	add("Timeout", l.Timeout)
+	add("Benchmark timeout", l.BenchmarkTimeout)
+	add("Aggregate window", l.AggregateWindow)
+	add("Skip verify", l.SkipVerify)
+	add("Coordinated omission correction", l.CorrectCoordinatedOmission)
+	add("Stage target", l.StageTarget)
+
+	headers := make([]string, 0, len(l.Headers))
+	for key := range l.Headers {
+		headers = append(headers, key)
+	}
+	sort.Strings(headers)
+	for _, key := range headers {
+		add("Header", fmt.Sprintf("%s: %s", key, strings.Join(l.Headers[key], ", ")))
+	}
+
+	for _, params := range l.Parameters {
+		keys := make([]string, 0, len(params))
+		for key := range params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			add("Parameter", fmt.Sprintf("%s=%s", key, params[key]))
+		}
+	}
+
+	for _, s := range l.Stages {
+		stage := fmt.Sprintf("%s:%d", s.Duration, s.Target)
+		if s.Transition != "" {
+			stage += ":" + s.Transition
+		}
+		add("Stage", stage)
+	}
+
+	for _, e := range l.Endpoints {
+		add("Endpoint", fmt.Sprintf("%d %s %s", e.Weight, e.Method, e.URL))
+	}
+
+	for _, step := range l.Flow {
+		add("Flow step", fmt.Sprintf("%s %s %s", step.Name, step.Method, step.URL))
+	}
+
+	if len(l.Tags) > 0 {
+		tags := make([]string, 0, len(l.Tags))
+		for _, tag := range l.Tags {
+			tags = append(tags, fmt.Sprintf("%s=%s", tag.Key, tag.Value))
+		}
+		add("Tags", strings.Join(tags, ", "))
+	}
+
+	return fields
+}
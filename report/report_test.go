@@ -0,0 +1,135 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmwalaszek/hload/model"
+)
+
+func TestHistogram(t *testing.T) {
+	var durations []time.Duration
+	for i := 1; i <= 100; i++ {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	durations = append(durations, time.Second)
+
+	buckets := histogram(durations, 10)
+	require.Len(t, buckets, 11)
+	require.True(t, strings.HasPrefix(buckets[10].Label, ">"))
+	require.Equal(t, 1, buckets[10].Count)
+
+	var total int
+	for _, b := range buckets {
+		total += b.Count
+	}
+	require.Equal(t, len(durations), total)
+
+	buckets = histogram([]time.Duration{time.Millisecond, time.Millisecond}, 10)
+	require.Len(t, buckets, 1)
+	require.Equal(t, 2, buckets[0].Count)
+
+	require.Nil(t, histogram(nil, 10))
+}
+
+func TestNiceMax(t *testing.T) {
+	tt := []struct {
+		value    float64
+		expected float64
+	}{
+		{0, 1},
+		{0.3, 0.5},
+		{7, 10},
+		{13, 20},
+		{200, 200},
+		{420, 500},
+	}
+
+	for _, tc := range tt {
+		require.InDelta(t, tc.expected, niceMax(tc.value), 1e-9, "value %v", tc.value)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	summary := &model.Summary{
+		UUID:       "summary-uuid",
+		URL:        "http://localhost/",
+		Start:      start,
+		End:        start.Add(20 * time.Second),
+		TotalTime:  20 * time.Second,
+		ReqCount:   4,
+		SuccessReq: 3,
+		FailReq:    1,
+		P99ReqTime: 30 * time.Millisecond,
+		HTTPCodes:  map[int]int{200: 3, 500: 1},
+		Errors:     map[string]int{"<script>timeout</script>": 1},
+		AggregatedStats: []*model.AggregatedStat{
+			{Start: start.Add(10 * time.Second), Duration: 10 * time.Second, RequestCount: 2, AvgRequestTime: 20 * time.Millisecond, MaxRequestTime: 30 * time.Millisecond},
+			{Start: start, Duration: 10 * time.Second, RequestCount: 2, AvgRequestTime: 10 * time.Millisecond, MaxRequestTime: 15 * time.Millisecond},
+		},
+		RequestStats: []*model.RequestStat{
+			{Start: start, Duration: 5 * time.Millisecond},
+			{Start: start.Add(time.Second), Duration: 15 * time.Millisecond},
+			{Start: start.Add(11 * time.Second), Duration: 10 * time.Millisecond},
+			{Start: start.Add(12 * time.Second), Duration: 30 * time.Millisecond},
+		},
+	}
+
+	loaderConf := &model.Loader{
+		UUID: "loader-uuid",
+		Name: "report",
+		URL:  "http://localhost/",
+		LoaderReqDetails: model.LoaderReqDetails{
+			Connections: 2,
+			Duration:    20 * time.Second,
+		},
+		Headers: model.Headers{"X-Test": []string{"1"}},
+	}
+
+	var buf bytes.Buffer
+	err := HTML(&buf, loaderConf, summary)
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Equal(t, 3, strings.Count(out, "<svg"))
+	require.Contains(t, out, "summary-uuid")
+	require.Contains(t, out, ">p99<")
+	require.Contains(t, out, "<th>Connections</th><td>2</td>")
+	require.Contains(t, out, "X-Test: 1")
+	require.Contains(t, out, "&lt;script&gt;timeout&lt;/script&gt;")
+	require.NotContains(t, out, "<script>")
+
+	// No charts without the aggregated and the requests stats
+	summary.AggregatedStats = nil
+	summary.RequestStats = nil
+	buf.Reset()
+	err = HTML(&buf, nil, summary)
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), "<svg")
+	require.Contains(t, buf.String(), "--save-aggregate-requests-stats")
+}
+
+func TestWindowsPercentiles(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	windows := []*model.AggregatedStat{
+		{Start: start},
+		{Start: start.Add(10 * time.Second)},
+	}
+
+	requests := []*model.RequestStat{
+		{Start: start.Add(-time.Second), Duration: time.Second},
+		{Start: start, Duration: 10 * time.Millisecond},
+		{Start: start.Add(9 * time.Second), Duration: 20 * time.Millisecond},
+		{Start: start.Add(10 * time.Second), Duration: 40 * time.Millisecond},
+	}
+
+	ss := windowsPercentiles(windows, requests)
+	require.Len(t, ss, 3)
+	require.Equal(t, []float64{10, 40}, ss[0].Values)
+	require.Equal(t, []float64{20, 40}, ss[2].Values)
+}
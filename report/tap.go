@@ -0,0 +1,64 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TAP writes the loaders runs as the TAP version 13 report, every loader is the test point
+// with the failures and the summary metrics in the YAML diagnostics block
+func TAP(w io.Writer, cases []*Case) error {
+	var b strings.Builder
+
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(cases))
+
+	for i, c := range cases {
+		name := strings.ReplaceAll(c.Name(), "#", "\\#")
+
+		switch {
+		case c.Skipped:
+			fmt.Fprintf(&b, "ok %d - %s # SKIP not run\n", i+1, name)
+			continue
+		case c.Err != nil:
+			fmt.Fprintf(&b, "not ok %d - %s\n", i+1, name)
+			b.WriteString("  ---\n")
+			fmt.Fprintf(&b, "  message: %s\n", strconv.Quote(c.Err.Error()))
+			b.WriteString("  severity: error\n")
+			b.WriteString("  ...\n")
+			continue
+		case c.Failed():
+			fmt.Fprintf(&b, "not ok %d - %s\n", i+1, name)
+			b.WriteString("  ---\n")
+			fmt.Fprintf(&b, "  message: %s\n", strconv.Quote(c.message()))
+			b.WriteString("  severity: fail\n")
+			b.WriteString("  failures:\n")
+			for _, failure := range c.Failures() {
+				fmt.Fprintf(&b, "    - %s\n", strconv.Quote(failure))
+			}
+		case c.Summary == nil:
+			fmt.Fprintf(&b, "ok %d - %s\n", i+1, name)
+			continue
+		default:
+			fmt.Fprintf(&b, "ok %d - %s\n", i+1, name)
+			b.WriteString("  ---\n")
+		}
+
+		s := c.Summary
+		b.WriteString("  metrics:\n")
+		fmt.Fprintf(&b, "    requests: %d\n", s.ReqCount)
+		fmt.Fprintf(&b, "    success: %d\n", s.SuccessReq)
+		fmt.Fprintf(&b, "    fail: %d\n", s.FailReq)
+		fmt.Fprintf(&b, "    req_per_sec: %.2f\n", s.ReqPerSec)
+		fmt.Fprintf(&b, "    p50: %s\n", s.P50ReqTime)
+		fmt.Fprintf(&b, "    p90: %s\n", s.P90ReqTime)
+		fmt.Fprintf(&b, "    p99: %s\n", s.P99ReqTime)
+		fmt.Fprintf(&b, "    duration: %s\n", s.TotalTime)
+		b.WriteString("  ...\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -3,10 +3,12 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	u "github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/mattn/go-sqlite3"
 	"github.com/tmwalaszek/hload/model"
 )
@@ -68,12 +70,133 @@ func (s *Storage) InsertLoaderConfiguration(loaderConfiguration *model.Loader) (
 		}
 	}()
 
+	uuid, err = s.insertLoaderConfiguration(tx, loaderConfiguration)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit()
+
+	return uuid, err
+}
+
+// loaderConfigurationTables are the tables of the loader configuration details replaced with the configuration
+var loaderConfigurationTables = []string{
+	"loader_requests_details",
+	"header",
+	"parameter",
+	"loader_stage",
+	"loader_endpoint",
+	"loader_assertion",
+	"loader_threshold",
+	"loader_feeder",
+}
+
+// ImportLoaderConfigurations saves the loader configurations with their tags in one transaction,
+// none of them is saved when one of them fails.
+// When replaceUUIDs[i] is set the loader configuration i replaces the existing one keeping its UUID, tags and summaries.
+func (s *Storage) ImportLoaderConfigurations(loaderConfigurations []*model.Loader, replaceUUIDs []string) (uuids []string, err error) {
+	tx := s.db.MustBegin()
+	defer func() {
+		if err != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				err = StorageError{
+					Err:           err,
+					RollbackError: rollbackErr,
+				}
+			}
+			return
+		}
+	}()
+
+	uuids = make([]string, len(loaderConfigurations))
+	for i, loaderConfiguration := range loaderConfigurations {
+		if i < len(replaceUUIDs) && replaceUUIDs[i] != "" {
+			uuids[i] = replaceUUIDs[i]
+			err = s.replaceLoaderConfiguration(tx, uuids[i], loaderConfiguration)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		uuids[i], err = s.insertLoaderConfiguration(tx, loaderConfiguration)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.insertLoaderTags(tx, uuids[i], loaderConfiguration.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return uuids, nil
+}
+
+// replaceLoaderConfiguration updates the loader configuration and replaces its details within the transaction.
+// The tags are added or updated.
+func (s *Storage) replaceLoaderConfiguration(tx *sqlx.Tx, uuid string, loaderConfiguration *model.Loader) error {
+	loaderConfiguration.UUID = uuid
+
+	res, err := tx.NamedExec(updateLoader, loaderConfiguration)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.Code, sqlite3.ErrConstraint) {
+			return fmt.Errorf("loader configuration name %s for URL %s already exists", loaderConfiguration.Name, loaderConfiguration.URL)
+		}
+		return fmt.Errorf("error update: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("loader configuration %s %w", uuid, ErrNotFound)
+	}
+
+	for _, table := range loaderConfigurationTables {
+		_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE loader_uuid = $1", table), uuid)
+		if err != nil {
+			return fmt.Errorf("delete error %s: %w", table, err)
+		}
+	}
+
+	err = s.insertLoaderDetails(tx, uuid, loaderConfiguration)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range loaderConfiguration.Tags {
+		err = s.insertTable(tx, upsertLoaderTag, loaderTagTable{
+			LoaderConfigurationUUID: uuid,
+			LoaderTag:               *t,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertLoaderConfiguration saves the loader configuration within the transaction
+func (s *Storage) insertLoaderConfiguration(tx *sqlx.Tx, loaderConfiguration *model.Loader) (string, error) {
 	if loaderConfiguration.UUID == "" {
 		id := u.New()
 		loaderConfiguration.UUID = id.String()
 	}
 
-	uuid, err = s.insertTablePrimaryUUID(tx, optsInsert, loaderConfiguration)
+	uuid, err := s.insertTablePrimaryUUID(tx, optsInsert, loaderConfiguration)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) {
@@ -84,12 +207,23 @@ func (s *Storage) InsertLoaderConfiguration(loaderConfiguration *model.Loader) (
 		return "", err
 	}
 
-	loaderConfiguration.LoaderReqDetails.LoaderConfigurationUUID = uuid
-	err = s.insertTable(tx, optsLoadInsert, loaderConfiguration.LoaderReqDetails)
+	err = s.insertLoaderDetails(tx, uuid, loaderConfiguration)
 	if err != nil {
 		return "", err
 	}
 
+	return uuid, nil
+}
+
+// insertLoaderDetails saves the requests details, headers, parameters, stages, endpoints, flow, assertions,
+// thresholds and the feeder of the loader configuration
+func (s *Storage) insertLoaderDetails(tx *sqlx.Tx, uuid string, loaderConfiguration *model.Loader) error {
+	loaderConfiguration.LoaderReqDetails.LoaderConfigurationUUID = uuid
+	err := s.insertTable(tx, optsLoadInsert, loaderConfiguration.LoaderReqDetails)
+	if err != nil {
+		return err
+	}
+
 	if len(loaderConfiguration.Headers) > 0 {
 		headers := make([]string, 0)
 		for k, values := range loaderConfiguration.Headers {
@@ -107,7 +241,7 @@ func (s *Storage) InsertLoaderConfiguration(loaderConfiguration *model.Loader) (
 
 			err = s.insertTable(tx, headerInsert, headerModel)
 			if err != nil {
-				return "", err
+				return err
 			}
 		}
 	}
@@ -115,12 +249,18 @@ func (s *Storage) InsertLoaderConfiguration(loaderConfiguration *model.Loader) (
 	if len(loaderConfiguration.Parameters) > 0 {
 		parameters := make([]string, len(loaderConfiguration.Parameters))
 		for i, parameterMap := range loaderConfiguration.Parameters {
-			var parameter string
-			for k, v := range parameterMap {
-				parameter += strings.Join([]string{k, v}, "=")
+			keys := make([]string, 0, len(parameterMap))
+			for k := range parameterMap {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			pairs := make([]string, len(keys))
+			for j, k := range keys {
+				pairs[j] = strings.Join([]string{k, parameterMap[k]}, "=")
 			}
 
-			parameters[i] = parameter
+			parameters[i] = strings.Join(pairs, "&")
 		}
 
 		for _, parameter := range parameters {
@@ -131,14 +271,111 @@ func (s *Storage) InsertLoaderConfiguration(loaderConfiguration *model.Loader) (
 
 			err = s.insertTable(tx, parameterInsert, parameterModel)
 			if err != nil {
-				return "", err
+				return err
 			}
 		}
 	}
 
-	err = tx.Commit()
+	for i, stage := range loaderConfiguration.Stages {
+		stageModel := &loaderStageTable{
+			Position:                i,
+			LoaderConfigurationUUID: uuid,
+			Stage:                   *stage,
+		}
 
-	return uuid, err
+		err = s.insertTable(tx, loaderStageInsert, stageModel)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, endpoint := range loaderConfiguration.Endpoints {
+		endpointModel := &loaderEndpointTable{
+			Position:                i,
+			LoaderConfigurationUUID: uuid,
+			Endpoint:                *endpoint,
+		}
+
+		err = s.insertLoaderEndpoint(tx, endpointModel)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, step := range loaderConfiguration.Flow {
+		endpointModel := &loaderEndpointTable{
+			Position:                i,
+			Flow:                    true,
+			LoaderConfigurationUUID: uuid,
+			Endpoint:                step.Endpoint,
+		}
+
+		endpointModel.ExtractJSON, err = marshalJSONColumn(step.Extract)
+		if err != nil {
+			return err
+		}
+
+		err = s.insertLoaderEndpoint(tx, endpointModel)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, assertion := range loaderConfiguration.Assertions {
+		assertionModel := &loaderAssertionTable{
+			Position:                i,
+			LoaderConfigurationUUID: uuid,
+			Assertion:               *assertion,
+		}
+
+		err = s.insertTable(tx, loaderAssertionInsert, assertionModel)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, threshold := range loaderConfiguration.Thresholds {
+		thresholdModel := &loaderThresholdTable{
+			Position:                i,
+			LoaderConfigurationUUID: uuid,
+			Threshold:               *threshold,
+		}
+
+		err = s.insertTable(tx, loaderThresholdInsert, thresholdModel)
+		if err != nil {
+			return err
+		}
+	}
+
+	if loaderConfiguration.Feeder != nil {
+		feederModel := &loaderFeederTable{
+			LoaderConfigurationUUID: uuid,
+			Feeder:                  *loaderConfiguration.Feeder,
+		}
+
+		err = s.insertTable(tx, loaderFeederInsert, feederModel)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) insertLoaderEndpoint(tx *sqlx.Tx, endpointModel *loaderEndpointTable) error {
+	var err error
+
+	endpointModel.HeadersJSON, err = marshalJSONColumn(endpointModel.Headers)
+	if err != nil {
+		return err
+	}
+
+	endpointModel.ParametersJSON, err = marshalJSONColumn(endpointModel.Parameters)
+	if err != nil {
+		return err
+	}
+
+	return s.insertTable(tx, loaderEndpointInsert, endpointModel)
 }
 
 func (s *Storage) InsertSummary(optsUUID string, summary *model.Summary, saveRequests, saveAggRequests bool) (uuid string, err error) {
@@ -192,6 +429,61 @@ func (s *Storage) InsertSummary(optsUUID string, summary *model.Summary, saveReq
 		}
 	}
 
+	endpoints := summary.Endpoints
+	if summary.Flow != nil {
+		endpoints = append(endpoints, summary.Flow)
+	}
+
+	for i, endpoint := range endpoints {
+		endpointModel := &endpointSummaryTable{
+			Position:        i,
+			Flow:            endpoint == summary.Flow,
+			SummaryUUID:     uuid,
+			EndpointSummary: *endpoint,
+		}
+
+		endpointModel.ErrorsJSON, err = marshalJSONColumn(endpoint.Errors)
+		if err != nil {
+			return "", err
+		}
+
+		endpointModel.HTTPCodesJSON, err = marshalJSONColumn(endpoint.HTTPCodes)
+		if err != nil {
+			return "", err
+		}
+
+		err = s.insertTable(tx, endpointSummaryInsert, endpointModel)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for i, phase := range summary.Phases {
+		phaseModel := &summaryPhaseTable{
+			Position:     i,
+			SummaryUUID:  uuid,
+			PhaseSummary: *phase,
+		}
+
+		err = s.insertTable(tx, summaryPhaseInsert, phaseModel)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for i, source := range summary.Sources {
+		sourceModel := &summarySourceTable{
+			Position:    i,
+			SourceUUID:  source,
+			SummaryUUID: uuid,
+		}
+
+		err = s.insertTable(tx, summarySourceInsert, sourceModel)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	if saveRequests {
 		for _, reqStat := range summary.RequestStats {
 			reqStatDB := requestStatTable{
@@ -200,9 +492,11 @@ func (s *Storage) InsertSummary(optsUUID string, summary *model.Summary, saveReq
 					Start:    reqStat.Start,
 					End:      reqStat.End,
 					Duration: reqStat.Duration,
+					Phases:   reqStat.Phases,
 					Error:    reqStat.Error,
 					BodySize: reqStat.BodySize,
 					RetCode:  reqStat.RetCode,
+					Endpoint: reqStat.Endpoint,
 				},
 			}
 
@@ -218,13 +512,19 @@ func (s *Storage) InsertSummary(optsUUID string, summary *model.Summary, saveReq
 			aggStatDB := aggregatedStatTable{
 				SummaryUUID: uuid,
 				AggregatedStat: model.AggregatedStat{
-					Start:          aggStat.Start,
-					End:            aggStat.End,
-					Duration:       aggStat.Duration,
-					AvgRequestTime: aggStat.AvgRequestTime,
-					MaxRequestTime: aggStat.MaxRequestTime,
-					MinRequestTime: aggStat.MinRequestTime,
-					RequestCount:   aggStat.RequestCount,
+					Start:           aggStat.Start,
+					End:             aggStat.End,
+					Duration:        aggStat.Duration,
+					AvgRequestTime:  aggStat.AvgRequestTime,
+					MaxRequestTime:  aggStat.MaxRequestTime,
+					MinRequestTime:  aggStat.MinRequestTime,
+					RequestCount:    aggStat.RequestCount,
+					AvgDNS:          aggStat.AvgDNS,
+					AvgConnect:      aggStat.AvgConnect,
+					AvgTLS:          aggStat.AvgTLS,
+					AvgTTFB:         aggStat.AvgTTFB,
+					AvgTransfer:     aggStat.AvgTransfer,
+					ConnectionStats: aggStat.ConnectionStats,
 				},
 			}
 			err = s.insertTable(tx, insertAggregateStat, aggStatDB)
@@ -260,6 +560,68 @@ func (s *Storage) getSummaryWithFullRequests(summaryUUID string) ([]*model.Reque
 	return requestsStats, nil
 }
 
+// getSummaryEndpoints returns the endpoints or flow steps summaries and the whole flow summary
+func (s *Storage) getSummaryEndpoints(summaryUUID string) ([]*model.EndpointSummary, *model.EndpointSummary, error) {
+	var endpointsTable []*endpointSummaryTable
+
+	err := s.db.Select(&endpointsTable, selectEndpointSummaries, summaryUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var endpoints []*model.EndpointSummary
+	var flow *model.EndpointSummary
+	for _, endpointTable := range endpointsTable {
+		err = unmarshalJSONColumn(endpointTable.ErrorsJSON, &endpointTable.EndpointSummary.Errors)
+		if err != nil {
+			return nil, nil, fmt.Errorf("endpoint %s errors: %w", endpointTable.Name, err)
+		}
+
+		err = unmarshalJSONColumn(endpointTable.HTTPCodesJSON, &endpointTable.EndpointSummary.HTTPCodes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("endpoint %s http codes: %w", endpointTable.Name, err)
+		}
+
+		if endpointTable.Flow {
+			flow = &endpointTable.EndpointSummary
+			continue
+		}
+
+		endpoints = append(endpoints, &endpointTable.EndpointSummary)
+	}
+
+	return endpoints, flow, nil
+}
+
+// getSummaryPhases returns the request phases summaries
+func (s *Storage) getSummaryPhases(summaryUUID string) ([]*model.PhaseSummary, error) {
+	var phasesTable []*summaryPhaseTable
+
+	err := s.db.Select(&phasesTable, selectSummaryPhases, summaryUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var phases []*model.PhaseSummary
+	for _, phaseTable := range phasesTable {
+		phases = append(phases, &phaseTable.PhaseSummary)
+	}
+
+	return phases, nil
+}
+
+// getSummarySources returns the UUIDs of the summaries the summary was merged from
+func (s *Storage) getSummarySources(summaryUUID string) ([]string, error) {
+	var sources []string
+
+	err := s.db.Select(&sources, selectSummarySources, summaryUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
 func (s *Storage) mapSummaries(summariesModelsAgg []*summaryAggregated) ([]*model.Summary, error) {
 	summaries := make([]*model.Summary, 0)
 
@@ -312,6 +674,24 @@ func (s *Storage) mapSummaries(summariesModelsAgg []*summaryAggregated) ([]*mode
 			summariesModel.HTTPCodes = httpCodes
 		}
 
+		endpoints, flow, err := s.getSummaryEndpoints(summariesModel.UUID)
+		if err != nil {
+			return nil, err
+		}
+
+		summariesModel.Endpoints = endpoints
+		summariesModel.Flow = flow
+
+		summariesModel.Phases, err = s.getSummaryPhases(summariesModel.UUID)
+		if err != nil {
+			return nil, err
+		}
+
+		summariesModel.Sources, err = s.getSummarySources(summariesModel.UUID)
+		if err != nil {
+			return nil, err
+		}
+
 		summaries = append(summaries, &summariesModel.Summary)
 	}
 
@@ -350,7 +730,7 @@ func (s *Storage) GetLoaderByDescription(description string) ([]*model.Loader, e
 		return nil, err
 	}
 
-	opts, err := mapLoader(loaderConfAgg)
+	opts, err := s.mapLoader(loaderConfAgg)
 	if err != nil {
 		return nil, err
 	}
@@ -371,7 +751,7 @@ func (s *Storage) GetLoaderByName(name string) ([]*model.Loader, error) {
 		return nil, err
 	}
 
-	opts, err := mapLoader(loaderConfAgg)
+	opts, err := s.mapLoader(loaderConfAgg)
 	if err != nil {
 		return nil, err
 	}
@@ -392,13 +772,13 @@ func (s *Storage) GetLoaderByID(loaderUUID string) (*model.Loader, error) {
 		return nil, err
 	}
 
-	confs, err := mapLoader(loaderConfAgg)
+	confs, err := s.mapLoader(loaderConfAgg)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(confs) == 0 {
-		return nil, fmt.Errorf("loader configuration %s not found", loaderUUID)
+		return nil, fmt.Errorf("loader configuration %s %w", loaderUUID, ErrNotFound)
 	}
 
 	return confs[0], nil
@@ -416,7 +796,7 @@ func (s *Storage) GetLoaders(limit int) ([]*model.Loader, error) {
 		return nil, err
 	}
 
-	confUUIDs, err := mapLoader(loaderConfAgg)
+	confUUIDs, err := s.mapLoader(loaderConfAgg)
 	if err != nil {
 		return nil, err
 	}
@@ -436,7 +816,7 @@ func (s *Storage) GetLoadersByRange(from, to int64, limit int) ([]*model.Loader,
 		return nil, err
 	}
 
-	loaders, err := mapLoader(loaderConfAgg)
+	loaders, err := s.mapLoader(loaderConfAgg)
 	if err != nil {
 		return nil, err
 	}
@@ -488,6 +868,38 @@ func (s *Storage) GetSummaries(loaderConfUUID string, opts ...Option) ([]*model.
 	return summaries, nil
 }
 
+// GetSummaryByID returns the summary, with the aggregated and the requests stats when withRequests is set
+func (s *Storage) GetSummaryByID(summaryUUID string, withRequests bool) (*model.Summary, error) {
+	sqlQuery, err := generateSQLFromTemplate(summaryTemplate, "uuid", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var summariesModelsAgg []*summaryAggregated
+	err = s.db.Select(&summariesModelsAgg, sqlQuery, summaryUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.mapSummaries(summariesModelsAgg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(summaries) == 0 {
+		return nil, fmt.Errorf("summary %s %w", summaryUUID, ErrNotFound)
+	}
+
+	if withRequests {
+		err = s.getSummariesRequests(summaries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return summaries[0], nil
+}
+
 func (s *Storage) GetLoaderByTags(tags []*model.LoaderTag) ([]*model.Loader, error) {
 	type TagsKeysValues struct {
 		Keys   []string
@@ -519,6 +931,6 @@ func (s *Storage) GetLoaderByTags(tags []*model.LoaderTag) ([]*model.Loader, err
 		return nil, fmt.Errorf("db error: %w", err)
 	}
 
-	optIDs, err := mapLoader(loaderConfAgg)
+	optIDs, err := s.mapLoader(loaderConfAgg)
 	return optIDs, err
 }
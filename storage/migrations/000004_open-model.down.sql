@@ -0,0 +1,3 @@
+ALTER TABLE loader_requests_details DROP COLUMN arrival_rate;
+ALTER TABLE summary DROP COLUMN dropped_req;
+ALTER TABLE summary DROP COLUMN late_req
\ No newline at end of file
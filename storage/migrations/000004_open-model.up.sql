@@ -0,0 +1,3 @@
+ALTER TABLE loader_requests_details ADD COLUMN arrival_rate INTEGER DEFAULT 0;
+ALTER TABLE summary ADD COLUMN dropped_req INTEGER DEFAULT 0;
+ALTER TABLE summary ADD COLUMN late_req INTEGER DEFAULT 0
\ No newline at end of file
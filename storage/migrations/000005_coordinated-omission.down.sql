@@ -0,0 +1,5 @@
+ALTER TABLE loader DROP COLUMN correct_coordinated_omission;
+ALTER TABLE summary DROP COLUMN corrected_p50_req_time;
+ALTER TABLE summary DROP COLUMN corrected_p75_req_time;
+ALTER TABLE summary DROP COLUMN corrected_p90_req_time;
+ALTER TABLE summary DROP COLUMN corrected_p99_req_time
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE loader ADD COLUMN correct_coordinated_omission INTEGER DEFAULT 0;
+ALTER TABLE summary ADD COLUMN corrected_p50_req_time INTEGER DEFAULT 0;
+ALTER TABLE summary ADD COLUMN corrected_p75_req_time INTEGER DEFAULT 0;
+ALTER TABLE summary ADD COLUMN corrected_p90_req_time INTEGER DEFAULT 0;
+ALTER TABLE summary ADD COLUMN corrected_p99_req_time INTEGER DEFAULT 0
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS loader_stage;
+ALTER TABLE loader_requests_details DROP COLUMN stage_target
\ No newline at end of file
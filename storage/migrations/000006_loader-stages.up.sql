@@ -0,0 +1,12 @@
+ALTER TABLE loader_requests_details ADD COLUMN stage_target TEXT DEFAULT "" NOT NULL;
+
+CREATE TABLE IF NOT EXISTS loader_stage (
+    id INTEGER PRIMARY KEY,
+    position INTEGER,
+    duration INTEGER,
+    target INTEGER,
+    transition TEXT,
+    loader_uuid TEXT,
+
+    FOREIGN KEY (loader_uuid) REFERENCES loader (uuid) ON DELETE CASCADE
+)
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS endpoint_summary;
+DROP TABLE IF EXISTS loader_endpoint;
+ALTER TABLE requests_stats DROP COLUMN endpoint
\ No newline at end of file
@@ -0,0 +1,41 @@
+ALTER TABLE requests_stats ADD COLUMN endpoint TEXT DEFAULT "" NOT NULL;
+
+CREATE TABLE IF NOT EXISTS loader_endpoint (
+    id INTEGER PRIMARY KEY,
+    position INTEGER,
+    name TEXT,
+    url TEXT,
+    method TEXT,
+    weight INTEGER,
+    body TEXT,
+    headers TEXT,
+    parameters TEXT,
+    loader_uuid TEXT,
+
+    FOREIGN KEY (loader_uuid) REFERENCES loader (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS endpoint_summary (
+    id INTEGER PRIMARY KEY,
+    position INTEGER,
+    name TEXT,
+    url TEXT,
+    method TEXT,
+    requests_count INTEGER,
+    success_req INTEGER,
+    fail_req INTEGER,
+    data_transferred INTEGER,
+    req_per_sec REAL,
+    avg_req_time INTEGER,
+    min_req_time INTEGER,
+    max_req_time INTEGER,
+    p50_req_time INTEGER,
+    p75_req_time INTEGER,
+    p90_req_time INTEGER,
+    p99_req_time INTEGER,
+    errors TEXT,
+    http_codes TEXT,
+    summary_uuid TEXT,
+
+    FOREIGN KEY (summary_uuid) REFERENCES summary (uuid) ON DELETE CASCADE
+)
@@ -0,0 +1,3 @@
+ALTER TABLE loader_endpoint DROP COLUMN flow;
+ALTER TABLE loader_endpoint DROP COLUMN extract;
+ALTER TABLE endpoint_summary DROP COLUMN flow
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE loader_endpoint ADD COLUMN flow INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE loader_endpoint ADD COLUMN extract TEXT DEFAULT "" NOT NULL;
+ALTER TABLE endpoint_summary ADD COLUMN flow INTEGER DEFAULT 0 NOT NULL
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS loader_feeder
\ No newline at end of file
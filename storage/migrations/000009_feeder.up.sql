@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS loader_feeder (
+    id INTEGER PRIMARY KEY,
+    path TEXT,
+    format TEXT,
+    mode TEXT,
+    data BLOB,
+    loader_uuid TEXT UNIQUE,
+
+    FOREIGN KEY (loader_uuid) REFERENCES loader (uuid) ON DELETE CASCADE
+)
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS loader_assertion
\ No newline at end of file
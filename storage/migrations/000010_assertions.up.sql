@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS loader_assertion (
+    id INTEGER PRIMARY KEY,
+    position INTEGER,
+    type TEXT,
+    path TEXT,
+    value TEXT,
+    loader_uuid TEXT,
+
+    FOREIGN KEY (loader_uuid) REFERENCES loader (uuid) ON DELETE CASCADE
+)
\ No newline at end of file
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS summary_phase;
+ALTER TABLE aggregated_stats DROP COLUMN avg_transfer;
+ALTER TABLE aggregated_stats DROP COLUMN avg_ttfb;
+ALTER TABLE aggregated_stats DROP COLUMN avg_tls;
+ALTER TABLE aggregated_stats DROP COLUMN avg_connect;
+ALTER TABLE aggregated_stats DROP COLUMN avg_dns;
+ALTER TABLE requests_stats DROP COLUMN transfer;
+ALTER TABLE requests_stats DROP COLUMN ttfb;
+ALTER TABLE requests_stats DROP COLUMN tls;
+ALTER TABLE requests_stats DROP COLUMN connect;
+ALTER TABLE requests_stats DROP COLUMN dns
\ No newline at end of file
@@ -0,0 +1,28 @@
+ALTER TABLE requests_stats ADD COLUMN dns INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE requests_stats ADD COLUMN connect INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE requests_stats ADD COLUMN tls INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE requests_stats ADD COLUMN ttfb INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE requests_stats ADD COLUMN transfer INTEGER DEFAULT 0 NOT NULL;
+
+ALTER TABLE aggregated_stats ADD COLUMN avg_dns INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE aggregated_stats ADD COLUMN avg_connect INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE aggregated_stats ADD COLUMN avg_tls INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE aggregated_stats ADD COLUMN avg_ttfb INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE aggregated_stats ADD COLUMN avg_transfer INTEGER DEFAULT 0 NOT NULL;
+
+CREATE TABLE IF NOT EXISTS summary_phase (
+    id INTEGER PRIMARY KEY,
+    position INTEGER,
+    name TEXT,
+    requests_count INTEGER,
+    avg_time INTEGER,
+    min_time INTEGER,
+    max_time INTEGER,
+    p50_time INTEGER,
+    p75_time INTEGER,
+    p90_time INTEGER,
+    p99_time INTEGER,
+    summary_uuid TEXT,
+
+    FOREIGN KEY (summary_uuid) REFERENCES summary (uuid) ON DELETE CASCADE
+)
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE aggregated_stats DROP COLUMN reset_connections;
+ALTER TABLE aggregated_stats DROP COLUMN closed_connections;
+ALTER TABLE aggregated_stats DROP COLUMN reused_connections;
+ALTER TABLE aggregated_stats DROP COLUMN new_connections;
+ALTER TABLE summary DROP COLUMN reset_connections;
+ALTER TABLE summary DROP COLUMN closed_connections;
+ALTER TABLE summary DROP COLUMN reused_connections;
+ALTER TABLE summary DROP COLUMN new_connections
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE summary ADD COLUMN new_connections INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE summary ADD COLUMN reused_connections INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE summary ADD COLUMN closed_connections INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE summary ADD COLUMN reset_connections INTEGER DEFAULT 0 NOT NULL;
+
+ALTER TABLE aggregated_stats ADD COLUMN new_connections INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE aggregated_stats ADD COLUMN reused_connections INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE aggregated_stats ADD COLUMN closed_connections INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE aggregated_stats ADD COLUMN reset_connections INTEGER DEFAULT 0 NOT NULL
\ No newline at end of file
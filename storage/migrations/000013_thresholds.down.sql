@@ -0,0 +1 @@
+DROP TABLE IF EXISTS loader_threshold
\ No newline at end of file
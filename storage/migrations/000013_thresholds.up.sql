@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS loader_threshold (
+    id INTEGER PRIMARY KEY,
+    position INTEGER,
+    metric TEXT,
+    operator TEXT,
+    value TEXT,
+    loader_uuid TEXT,
+
+    FOREIGN KEY (loader_uuid) REFERENCES loader (uuid) ON DELETE CASCADE
+)
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE summary DROP COLUMN corrected_tdigest;
+ALTER TABLE summary DROP COLUMN tdigest
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE summary ADD COLUMN tdigest BLOB;
+ALTER TABLE summary ADD COLUMN corrected_tdigest BLOB
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS summary_source
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS summary_source (
+    id INTEGER PRIMARY KEY,
+    position INTEGER,
+    source_uuid TEXT,
+    summary_uuid TEXT,
+
+    FOREIGN KEY (summary_uuid) REFERENCES summary (uuid) ON DELETE CASCADE
+)
\ No newline at end of file
@@ -29,6 +29,10 @@ var (
 	loaderConfigurationTagInsert string
 	//go:embed sql/delete_loader.sql
 	deleteLoader string
+	//go:embed sql/update_loader.sql
+	updateLoader string
+	//go:embed sql/upsert_loader_tag.sql
+	upsertLoaderTag string
 	//go:embed sql/delete_loader_tag.sql
 	deleteLoaderTag string
 	//go:embed sql/insert_aggregate_stats.sql
@@ -57,6 +61,42 @@ var (
 	updateTemplate string
 	//go:embed sql/update_loader_tag.sql
 	updateLoaderTag string
+	//go:embed sql/select_loader_headers.sql
+	selectLoaderHeaders string
+	//go:embed sql/select_loader_parameters.sql
+	selectLoaderParameters string
+	//go:embed sql/insert_loader_stage.sql
+	loaderStageInsert string
+	//go:embed sql/select_loader_stages.sql
+	selectLoaderStages string
+	//go:embed sql/insert_loader_endpoint.sql
+	loaderEndpointInsert string
+	//go:embed sql/select_loader_endpoints.sql
+	selectLoaderEndpoints string
+	//go:embed sql/insert_loader_feeder.sql
+	loaderFeederInsert string
+	//go:embed sql/select_loader_feeder.sql
+	selectLoaderFeeder string
+	//go:embed sql/insert_loader_assertion.sql
+	loaderAssertionInsert string
+	//go:embed sql/select_loader_assertions.sql
+	selectLoaderAssertions string
+	//go:embed sql/insert_loader_threshold.sql
+	loaderThresholdInsert string
+	//go:embed sql/select_loader_thresholds.sql
+	selectLoaderThresholds string
+	//go:embed sql/insert_endpoint_summary.sql
+	endpointSummaryInsert string
+	//go:embed sql/select_endpoint_summaries.sql
+	selectEndpointSummaries string
+	//go:embed sql/insert_summary_phase.sql
+	summaryPhaseInsert string
+	//go:embed sql/select_summary_phases.sql
+	selectSummaryPhases string
+	//go:embed sql/insert_summary_source.sql
+	summarySourceInsert string
+	//go:embed sql/select_summary_sources.sql
+	selectSummarySources string
 )
 
 // data is optional depending on the template
@@ -1 +1 @@
-DELETE FROM loader WHERE id = $1
\ No newline at end of file
+DELETE FROM loader WHERE uuid = $1
\ No newline at end of file
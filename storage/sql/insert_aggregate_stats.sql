@@ -1,2 +1,4 @@
-INSERT INTO aggregated_stats(start, end, duration, avg_request_time, max_request_time, min_request_time, request_count, summary_uuid)
-VALUES(:start, :end, :duration, :avg_request_time, :max_request_time, :min_request_time, :request_count, :summary_uuid)
\ No newline at end of file
+INSERT INTO aggregated_stats(start, end, duration, avg_request_time, max_request_time, min_request_time, request_count,
+    avg_dns, avg_connect, avg_tls, avg_ttfb, avg_transfer, new_connections, reused_connections, closed_connections, reset_connections, summary_uuid)
+VALUES(:start, :end, :duration, :avg_request_time, :max_request_time, :min_request_time, :request_count,
+    :avg_dns, :avg_connect, :avg_tls, :avg_ttfb, :avg_transfer, :new_connections, :reused_connections, :closed_connections, :reset_connections, :summary_uuid)
\ No newline at end of file
@@ -0,0 +1,4 @@
+INSERT INTO endpoint_summary (position, name, url, method, requests_count, success_req, fail_req, data_transferred, req_per_sec,
+    avg_req_time, min_req_time, max_req_time, p50_req_time, p75_req_time, p90_req_time, p99_req_time, errors, http_codes, flow, summary_uuid)
+VALUES (:position, :name, :url, :method, :requests_count, :success_req, :fail_req, :data_transferred, :req_per_sec,
+    :avg_req_time, :min_req_time, :max_req_time, :p50_req_time, :p75_req_time, :p90_req_time, :p99_req_time, :errors, :http_codes, :flow, :summary_uuid)
\ No newline at end of file
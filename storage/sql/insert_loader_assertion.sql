@@ -0,0 +1 @@
+INSERT INTO loader_assertion (position, type, path, value, loader_uuid) VALUES (:position, :type, :path, :value, :loader_uuid)
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT INTO loader_endpoint (position, name, url, method, weight, body, headers, parameters, flow, extract, loader_uuid)
+VALUES (:position, :name, :url, :method, :weight, :body, :headers, :parameters, :flow, :extract, :loader_uuid)
\ No newline at end of file
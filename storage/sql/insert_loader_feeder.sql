@@ -0,0 +1 @@
+INSERT INTO loader_feeder (path, format, mode, data, loader_uuid) VALUES (:path, :format, :mode, :data, :loader_uuid)
\ No newline at end of file
@@ -1,3 +1,3 @@
 INSERT INTO loader_requests_details
-(request_count, abort_after, connections, rate_limit, duration, keep_alive, request_delay, read_timeout, write_timeout, timeout, loader_uuid)
-VALUES (:request_count, :abort_after, :connections, :rate_limit, :duration, :keep_alive, :request_delay, :read_timeout, :write_timeout, :timeout, :loader_uuid)
\ No newline at end of file
+(request_count, abort_after, connections, rate_limit, arrival_rate, stage_target, duration, keep_alive, request_delay, read_timeout, write_timeout, timeout, loader_uuid)
+VALUES (:request_count, :abort_after, :connections, :rate_limit, :arrival_rate, :stage_target, :duration, :keep_alive, :request_delay, :read_timeout, :write_timeout, :timeout, :loader_uuid)
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO loader_stage (position, duration, target, transition, loader_uuid) VALUES (:position, :duration, :target, :transition, :loader_uuid)
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO loader_threshold (position, metric, operator, value, loader_uuid) VALUES (:position, :metric, :operator, :value, :loader_uuid)
\ No newline at end of file
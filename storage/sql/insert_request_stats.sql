@@ -1,2 +1,2 @@
-INSERT INTO requests_stats(start, end, duration, error, body_size, ret_code, summary_uuid)
-VALUES(:start, :end, :duration, :error, :body_size, :ret_code, :summary_uuid)
\ No newline at end of file
+INSERT INTO requests_stats(start, end, duration, error, body_size, ret_code, endpoint, dns, connect, tls, ttfb, transfer, summary_uuid)
+VALUES(:start, :end, :duration, :error, :body_size, :ret_code, :endpoint, :dns, :connect, :tls, :ttfb, :transfer, :summary_uuid)
\ No newline at end of file
@@ -1,4 +1,4 @@
 INSERT INTO summary
-(uuid, url, description, start, end, total_time, requests_count, success_req, fail_req, data_transferred, req_per_sec, avg_req_time, min_req_time, max_req_time, p50_req_time, p75_req_time, p90_req_time, p99_req_time, std_deviation, loader_uuid)
-VALUES(:uuid, :url, :description, :start, :end, :total_time, :requests_count, :success_req, :fail_req, :data_transferred, :req_per_sec, :avg_req_time, :min_req_time, :max_req_time, :p50_req_time, :p75_req_time, :p90_req_time, :p99_req_time, :std_deviation, :loader_uuid)
+(uuid, url, description, start, end, total_time, requests_count, success_req, fail_req, dropped_req, late_req, data_transferred, req_per_sec, avg_req_time, min_req_time, max_req_time, p50_req_time, p75_req_time, p90_req_time, p99_req_time, corrected_p50_req_time, corrected_p75_req_time, corrected_p90_req_time, corrected_p99_req_time, std_deviation, new_connections, reused_connections, closed_connections, reset_connections, tdigest, corrected_tdigest, loader_uuid)
+VALUES(:uuid, :url, :description, :start, :end, :total_time, :requests_count, :success_req, :fail_req, :dropped_req, :late_req, :data_transferred, :req_per_sec, :avg_req_time, :min_req_time, :max_req_time, :p50_req_time, :p75_req_time, :p90_req_time, :p99_req_time, :corrected_p50_req_time, :corrected_p75_req_time, :corrected_p90_req_time, :corrected_p99_req_time, :std_deviation, :new_connections, :reused_connections, :closed_connections, :reset_connections, :tdigest, :corrected_tdigest, :loader_uuid)
 RETURNING uuid;
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT INTO summary_phase (position, name, requests_count, avg_time, min_time, max_time, p50_time, p75_time, p90_time, p99_time, summary_uuid)
+VALUES (:position, :name, :requests_count, :avg_time, :min_time, :max_time, :p50_time, :p75_time, :p90_time, :p99_time, :summary_uuid)
\ No newline at end of file
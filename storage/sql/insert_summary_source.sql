@@ -0,0 +1,2 @@
+INSERT INTO summary_source (position, source_uuid, summary_uuid)
+VALUES (:position, :source_uuid, :summary_uuid)
\ No newline at end of file
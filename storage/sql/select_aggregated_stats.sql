@@ -1 +1,2 @@
-SELECT start,end,duration,avg_request_time,max_request_time,min_request_time,request_count FROM aggregated_stats WHERE summary_uuid=$1;
\ No newline at end of file
+SELECT start,end,duration,avg_request_time,max_request_time,min_request_time,request_count,avg_dns,avg_connect,avg_tls,avg_ttfb,avg_transfer,
+    new_connections,reused_connections,closed_connections,reset_connections FROM aggregated_stats WHERE summary_uuid=$1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+SELECT name,url,method,requests_count,success_req,fail_req,data_transferred,req_per_sec,avg_req_time,min_req_time,max_req_time,
+    p50_req_time,p75_req_time,p90_req_time,p99_req_time,errors,http_codes,flow FROM endpoint_summary WHERE summary_uuid=$1 ORDER BY position
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT loader_uuid,type,path,value FROM loader_assertion WHERE loader_uuid IN (?) ORDER BY position
\ No newline at end of file
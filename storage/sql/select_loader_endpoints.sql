@@ -0,0 +1 @@
+SELECT loader_uuid,name,url,method,weight,body,headers,parameters,flow,extract FROM loader_endpoint WHERE loader_uuid IN (?) ORDER BY position
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT loader_uuid,path,format,mode,data FROM loader_feeder WHERE loader_uuid IN (?)
\ No newline at end of file
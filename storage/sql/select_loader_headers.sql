@@ -0,0 +1 @@
+SELECT loader_uuid,header FROM header WHERE loader_uuid IN (?) ORDER BY id
\ No newline at end of file
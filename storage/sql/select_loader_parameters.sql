@@ -0,0 +1 @@
+SELECT loader_uuid,parameters FROM parameter WHERE loader_uuid IN (?) ORDER BY id
\ No newline at end of file
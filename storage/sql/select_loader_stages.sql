@@ -0,0 +1 @@
+SELECT loader_uuid,duration,target,transition FROM loader_stage WHERE loader_uuid IN (?) ORDER BY position
\ No newline at end of file
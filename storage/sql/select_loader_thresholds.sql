@@ -0,0 +1 @@
+SELECT loader_uuid,metric,operator,value FROM loader_threshold WHERE loader_uuid IN (?) ORDER BY position
\ No newline at end of file
@@ -1 +1 @@
-SELECT start,end,duration,body_size,ret_code,error FROM requests_stats WHERE summary_uuid=$1;
\ No newline at end of file
+SELECT start,end,duration,body_size,ret_code,error,endpoint,dns,connect,tls,ttfb,transfer FROM requests_stats WHERE summary_uuid=$1;
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT name,requests_count,avg_time,min_time,max_time,p50_time,p75_time,p90_time,p99_time FROM summary_phase WHERE summary_uuid=$1 ORDER BY position
\ No newline at end of file
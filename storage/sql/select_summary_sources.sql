@@ -0,0 +1 @@
+SELECT source_uuid FROM summary_source WHERE summary_uuid=$1 ORDER BY position
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE loader SET
+url = :url, name = :name, description = :description, aggregate_window = :aggregate_window, gather_full_requests_stats = :gather_full_requests_stats, gather_aggregate_requests_stats = :gather_aggregate_requests_stats, correct_coordinated_omission = :correct_coordinated_omission, method = :method, http_engine = :http_engine, skip_verify = :skip_verify, ca = :ca, cert = :cert, key = :key, benchmark_timeout = :benchmark_timeout, body = :body
+WHERE uuid = :uuid
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT INTO loader_tag (key, value, loader_uuid) VALUES (:key, :value, :loader_uuid)
+ON CONFLICT (key, loader_uuid) DO UPDATE SET value = excluded.value, update_date = datetime('now')
\ No newline at end of file
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"embed"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -20,6 +21,9 @@ var (
 	fs embed.FS
 )
 
+// ErrNotFound is returned when the loader configuration or the summary does not exist
+var ErrNotFound = errors.New("not found")
+
 type Storage struct {
 	db *sqlx.DB
 }
@@ -46,10 +50,8 @@ func (s StorageError) Error() string {
 }
 
 // loaderAggregated is a struct that will be used to store the result of the query in select_loader.tmpl
-// from this struct we will build the model.Loader with the tags, headers and parameters in their respective models
+// from this struct we will build the model.Loader with the tags in their respective models
 type loaderAggregated struct {
-	Header     string `db:"headers_agg"`
-	Parameter  string `db:"parameters_agg"`
 	TagsKeys   string `db:"tags_keys_agg"`
 	TagsValues string `db:"tags_values_agg"`
 
@@ -113,6 +115,77 @@ type httpCodesTable struct {
 	Summary string `db:"summary"`
 }
 
+type loaderStageTable struct {
+	ID                      int64  `db:"id"`
+	Position                int    `db:"position"`
+	LoaderConfigurationUUID string `db:"loader_uuid"`
+
+	model.Stage
+}
+
+type loaderAssertionTable struct {
+	ID                      int64  `db:"id"`
+	Position                int    `db:"position"`
+	LoaderConfigurationUUID string `db:"loader_uuid"`
+
+	model.Assertion
+}
+
+type loaderThresholdTable struct {
+	ID                      int64  `db:"id"`
+	Position                int    `db:"position"`
+	LoaderConfigurationUUID string `db:"loader_uuid"`
+
+	model.Threshold
+}
+
+type loaderFeederTable struct {
+	ID                      int64  `db:"id"`
+	LoaderConfigurationUUID string `db:"loader_uuid"`
+
+	model.Feeder
+}
+
+// loaderEndpointTable keeps the request mix endpoint or the flow step, headers, parameters and extracts are kept as JSON
+type loaderEndpointTable struct {
+	ID                      int64  `db:"id"`
+	Position                int    `db:"position"`
+	Flow                    bool   `db:"flow"`
+	HeadersJSON             string `db:"headers"`
+	ParametersJSON          string `db:"parameters"`
+	ExtractJSON             string `db:"extract"`
+	LoaderConfigurationUUID string `db:"loader_uuid"`
+
+	model.Endpoint
+}
+
+// endpointSummaryTable keeps the endpoint errors and http codes as JSON
+type endpointSummaryTable struct {
+	ID            int64  `db:"id"`
+	Position      int    `db:"position"`
+	Flow          bool   `db:"flow"`
+	ErrorsJSON    string `db:"errors"`
+	HTTPCodesJSON string `db:"http_codes"`
+	SummaryUUID   string `db:"summary_uuid"`
+
+	model.EndpointSummary
+}
+
+type summaryPhaseTable struct {
+	ID          int64  `db:"id"`
+	Position    int    `db:"position"`
+	SummaryUUID string `db:"summary_uuid"`
+
+	model.PhaseSummary
+}
+
+type summarySourceTable struct {
+	ID          int64  `db:"id"`
+	Position    int    `db:"position"`
+	SourceUUID  string `db:"source_uuid"`
+	SummaryUUID string `db:"summary_uuid"`
+}
+
 type loaderTagTable struct {
 	ID                      int64  `db:"id"`
 	LoaderConfigurationUUID string `db:"loader_uuid"`
@@ -159,39 +232,171 @@ func NewStorage(file string) (*Storage, error) {
 	}, nil
 }
 
-// mapLoader function maps aggregated loaderConfiguration from database query to model.Loader
-func mapLoader(loaderAgg []*loaderAggregated) ([]*model.Loader, error) {
-	confs := make([]*model.Loader, 0)
-	var err error
-	for _, confAgg := range loaderAgg {
-		if confAgg.Header != "" {
-			header := make(model.Headers)
-			headersSplit := strings.Split(confAgg.Header, ",")
-
-			for _, h := range headersSplit {
-				err = header.Set(h)
-				if err != nil {
-					return nil, err
-				}
-			}
+// selectLoadersIn selects the rows of all the loaders at once, query has the IN (?) placeholder for the loaders UUIDs
+func (s *Storage) selectLoadersIn(dest any, query string, loaderUUIDs []string) error {
+	query, args, err := sqlx.In(query, loaderUUIDs)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Select(dest, s.db.Rebind(query), args...)
+}
+
+// mapLoaderEndpoints sets the request mix endpoints and the flow steps of the loaders
+func (s *Storage) mapLoaderEndpoints(loaders map[string]*model.Loader, loaderUUIDs []string) error {
+	var endpointsTable []*loaderEndpointTable
+
+	err := s.selectLoadersIn(&endpointsTable, selectLoaderEndpoints, loaderUUIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, endpointTable := range endpointsTable {
+		l := loaders[endpointTable.LoaderConfigurationUUID]
 
-			confAgg.Loader.Headers = header
+		err = unmarshalJSONColumn(endpointTable.HeadersJSON, &endpointTable.Endpoint.Headers)
+		if err != nil {
+			return fmt.Errorf("endpoint %s headers: %w", endpointTable.Name, err)
 		}
 
-		if confAgg.Parameter != "" {
-			parameter := make(model.Parameters, 0)
-			parametersSplit := strings.Split(confAgg.Parameter, ",")
+		err = unmarshalJSONColumn(endpointTable.ParametersJSON, &endpointTable.Endpoint.Parameters)
+		if err != nil {
+			return fmt.Errorf("endpoint %s parameters: %w", endpointTable.Name, err)
+		}
 
-			for _, p := range parametersSplit {
-				err = parameter.Set(p)
-				if err != nil {
-					return nil, err
-				}
-			}
+		if !endpointTable.Flow {
+			l.Endpoints = append(l.Endpoints, &endpointTable.Endpoint)
+			continue
+		}
+
+		step := &model.FlowStep{
+			Endpoint: endpointTable.Endpoint,
+		}
 
-			confAgg.Loader.Parameters = parameter
+		err = unmarshalJSONColumn(endpointTable.ExtractJSON, &step.Extract)
+		if err != nil {
+			return fmt.Errorf("flow step %s extract: %w", endpointTable.Name, err)
 		}
 
+		l.Flow = append(l.Flow, step)
+	}
+
+	return nil
+}
+
+// unmarshalJSONColumn decodes the JSON column, empty column leaves v untouched
+func unmarshalJSONColumn(column string, v any) error {
+	if column == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(column), v)
+}
+
+// marshalJSONColumn encodes v into the JSON column
+func marshalJSONColumn(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// mapLoaderHeadersParameters sets the headers and parameters sets of the loaders.
+// They are fetched separately as their values can contain commas.
+func (s *Storage) mapLoaderHeadersParameters(loaders map[string]*model.Loader, loaderUUIDs []string) error {
+	var headersRows []*headerTable
+	var parametersRows []*parameterTable
+
+	err := s.selectLoadersIn(&headersRows, selectLoaderHeaders, loaderUUIDs)
+	if err != nil {
+		return err
+	}
+
+	err = s.selectLoadersIn(&parametersRows, selectLoaderParameters, loaderUUIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range headersRows {
+		l := loaders[h.ConfigurationUUID]
+		if l.Headers == nil {
+			l.Headers = make(model.Headers)
+		}
+
+		err = l.Headers.Set(h.Header)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, p := range parametersRows {
+		l := loaders[p.ConfigurationUUID]
+		if l.Parameters == nil {
+			l.Parameters = make(model.Parameters, 0)
+		}
+
+		err = l.Parameters.Set(p.Parameters)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapLoaderDetails sets the stages, the feeder, the assertions and the thresholds of the loaders
+func (s *Storage) mapLoaderDetails(loaders map[string]*model.Loader, loaderUUIDs []string) error {
+	var stages []*loaderStageTable
+	err := s.selectLoadersIn(&stages, selectLoaderStages, loaderUUIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, stage := range stages {
+		l := loaders[stage.LoaderConfigurationUUID]
+		l.Stages = append(l.Stages, &stage.Stage)
+	}
+
+	var feeders []*loaderFeederTable
+	err = s.selectLoadersIn(&feeders, selectLoaderFeeder, loaderUUIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, feeder := range feeders {
+		loaders[feeder.LoaderConfigurationUUID].Feeder = &feeder.Feeder
+	}
+
+	var assertions []*loaderAssertionTable
+	err = s.selectLoadersIn(&assertions, selectLoaderAssertions, loaderUUIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, assertion := range assertions {
+		l := loaders[assertion.LoaderConfigurationUUID]
+		l.Assertions = append(l.Assertions, &assertion.Assertion)
+	}
+
+	var thresholds []*loaderThresholdTable
+	err = s.selectLoadersIn(&thresholds, selectLoaderThresholds, loaderUUIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, threshold := range thresholds {
+		l := loaders[threshold.LoaderConfigurationUUID]
+		l.Thresholds = append(l.Thresholds, &threshold.Threshold)
+	}
+
+	return nil
+}
+
+// mapLoader function maps aggregated loaderConfiguration from database query to model.Loader
+// The headers, parameters, stages, endpoints, feeders, assertions and thresholds of all loaders are fetched in bulk
+func (s *Storage) mapLoader(loaderAgg []*loaderAggregated) ([]*model.Loader, error) {
+	confs := make([]*model.Loader, 0)
+	loaders := make(map[string]*model.Loader, len(loaderAgg))
+	loaderUUIDs := make([]string, 0, len(loaderAgg))
+	for _, confAgg := range loaderAgg {
 		if confAgg.TagsKeys != "" {
 			tagsKeys := strings.Split(confAgg.TagsKeys, ",")
 			tagsValues := strings.Split(confAgg.TagsValues, ",")
@@ -211,6 +416,27 @@ func mapLoader(loaderAgg []*loaderAggregated) ([]*model.Loader, error) {
 		}
 
 		confs = append(confs, &confAgg.Loader)
+		loaders[confAgg.Loader.UUID] = &confAgg.Loader
+		loaderUUIDs = append(loaderUUIDs, confAgg.Loader.UUID)
+	}
+
+	if len(confs) == 0 {
+		return confs, nil
+	}
+
+	err := s.mapLoaderHeadersParameters(loaders, loaderUUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mapLoaderEndpoints(loaders, loaderUUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mapLoaderDetails(loaders, loaderUUIDs)
+	if err != nil {
+		return nil, err
 	}
 
 	return confs, nil
@@ -135,6 +135,78 @@ func TestStorageTags(t *testing.T) {
 	require.Equal(t, loaderOpts, loaders[0])
 }
 
+func TestStorageImport(t *testing.T) {
+	store, err := NewStorage("test_file.db")
+	defer os.Remove("test_file.db")
+
+	require.Nil(t, err)
+
+	newLoader := func(name string) *model.Loader {
+		return &model.Loader{
+			Name:   name,
+			URL:    "http://localhost/" + name,
+			Method: "GET",
+			Tags:   []*model.LoaderTag{{Key: "source", Value: "import"}},
+		}
+	}
+
+	// The duplicated name fails the whole import
+	_, err = store.ImportLoaderConfigurations([]*model.Loader{newLoader("items"), newLoader("cart"), newLoader("items")}, nil)
+	require.NotNil(t, err)
+
+	loaders, err := store.GetLoaders(10)
+	require.Nil(t, err)
+	require.Empty(t, loaders)
+
+	ids, err := store.ImportLoaderConfigurations([]*model.Loader{newLoader("items"), newLoader("cart")}, nil)
+	require.Nil(t, err)
+	require.Len(t, ids, 2)
+
+	loaders, err = store.GetLoaderByTags([]*model.LoaderTag{{Key: "source", Value: "import"}})
+	require.Nil(t, err)
+	require.Len(t, loaders, 2)
+
+	_, err = store.InsertSummary(ids[0], &model.Summary{URL: "http://localhost/items"}, false, false)
+	require.Nil(t, err)
+
+	// The replaced loader keeps its UUID, summaries and other tags
+	replaced := newLoader("items")
+	replaced.Method = "POST"
+	replaced.Headers = model.Headers{"Accept": {"application/json"}}
+	replaced.Tags = []*model.LoaderTag{{Key: "source", Value: "reimport"}}
+
+	err = store.InsertLoaderConfigurationTags(ids[0], []*model.LoaderTag{{Key: "team", Value: "shop"}})
+	require.Nil(t, err)
+
+	replacedIDs, err := store.ImportLoaderConfigurations([]*model.Loader{replaced, newLoader("orders")}, []string{ids[0], ""})
+	require.Nil(t, err)
+	require.Equal(t, ids[0], replacedIDs[0])
+
+	l, err := store.GetLoaderByID(ids[0])
+	require.Nil(t, err)
+	require.Equal(t, "POST", l.Method)
+	require.Equal(t, model.Headers{"Accept": {"application/json"}}, l.Headers)
+	require.ElementsMatch(t, []*model.LoaderTag{{Key: "source", Value: "reimport"}, {Key: "team", Value: "shop"}}, l.Tags)
+
+	summaries, err := store.GetSummaries(ids[0], WithLimit(10))
+	require.Nil(t, err)
+	require.Len(t, summaries, 1)
+
+	// The details fetched in bulk go to their own loaders
+	loaders, err = store.GetLoaders(10)
+	require.Nil(t, err)
+	require.Len(t, loaders, 3)
+
+	for _, l := range loaders {
+		if l.UUID == ids[0] {
+			require.Equal(t, model.Headers{"Accept": {"application/json"}}, l.Headers)
+			continue
+		}
+
+		require.Nil(t, l.Headers)
+	}
+}
+
 // One LoaderConf and zero, one or more summaries
 func TestStorageOneLoaderOpts(t *testing.T) {
 	var tt = []struct {
@@ -149,6 +221,78 @@ func TestStorageOneLoaderOpts(t *testing.T) {
 			LoaderFile:  "loader.json",
 			SummaryFile: "summaries.json",
 		},
+		{
+			Name:        "Test 2 - directory stages",
+			Directory:   "stages",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 3 - directory endpoints",
+			Directory:   "endpoints",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 4 - directory flow",
+			Directory:   "flow",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 5 - directory feeder",
+			Directory:   "feeder",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 6 - directory har",
+			Directory:   "har",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 7 - directory assertions",
+			Directory:   "assertions",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 8 - directory phases",
+			Directory:   "phases",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 9 - directory connections",
+			Directory:   "connections",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 10 - directory thresholds",
+			Directory:   "thresholds",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 11 - directory tdigest",
+			Directory:   "tdigest",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 12 - directory merged",
+			Directory:   "merged",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
+		{
+			Name:        "Test 13 - directory open_model",
+			Directory:   "open_model",
+			LoaderFile:  "loader.json",
+			SummaryFile: "summaries.json",
+		},
 	}
 
 	for _, tc := range tt {
@@ -184,28 +328,41 @@ func TestStorageOneLoaderOpts(t *testing.T) {
 				require.Nil(t, err)
 
 				summary := &model.Summary{
-					URL:             tempSummary.URL,
-					Description:     tempSummary.Description,
-					Start:           start,
-					End:             end,
-					TotalTime:       tempSummary.TotalTime,
-					ReqCount:        tempSummary.ReqCount,
-					SuccessReq:      tempSummary.SuccessReq,
-					FailReq:         tempSummary.FailReq,
-					DataTransferred: tempSummary.DataTransferred,
-					ReqPerSec:       tempSummary.ReqPerSec,
-					AvgReqTime:      tempSummary.AvgReqTime,
-					MinReqTime:      tempSummary.MinReqTime,
-					MaxReqTime:      tempSummary.MaxReqTime,
-					P50ReqTime:      tempSummary.P50ReqTime,
-					P75ReqTime:      tempSummary.P75ReqTime,
-					P90ReqTime:      tempSummary.P90ReqTime,
-					P99ReqTime:      tempSummary.P99ReqTime,
-					StdDeviation:    tempSummary.StdDeviation,
-					Errors:          tempSummary.Errors,
-					HTTPCodes:       tempSummary.HTTPCodes,
-					AggregatedStats: tempSummary.AggregatedStats,
-					RequestStats:    tempSummary.RequestStats,
+					URL:                 tempSummary.URL,
+					Description:         tempSummary.Description,
+					Start:               start,
+					End:                 end,
+					TotalTime:           tempSummary.TotalTime,
+					ReqCount:            tempSummary.ReqCount,
+					SuccessReq:          tempSummary.SuccessReq,
+					FailReq:             tempSummary.FailReq,
+					DroppedReq:          tempSummary.DroppedReq,
+					LateReq:             tempSummary.LateReq,
+					DataTransferred:     tempSummary.DataTransferred,
+					ReqPerSec:           tempSummary.ReqPerSec,
+					AvgReqTime:          tempSummary.AvgReqTime,
+					MinReqTime:          tempSummary.MinReqTime,
+					MaxReqTime:          tempSummary.MaxReqTime,
+					P50ReqTime:          tempSummary.P50ReqTime,
+					P75ReqTime:          tempSummary.P75ReqTime,
+					P90ReqTime:          tempSummary.P90ReqTime,
+					P99ReqTime:          tempSummary.P99ReqTime,
+					CorrectedP50ReqTime: tempSummary.CorrectedP50ReqTime,
+					CorrectedP75ReqTime: tempSummary.CorrectedP75ReqTime,
+					CorrectedP90ReqTime: tempSummary.CorrectedP90ReqTime,
+					CorrectedP99ReqTime: tempSummary.CorrectedP99ReqTime,
+					StdDeviation:        tempSummary.StdDeviation,
+					ConnectionStats:     tempSummary.ConnectionStats,
+					TDigest:             tempSummary.TDigest,
+					CorrectedTDigest:    tempSummary.CorrectedTDigest,
+					Sources:             tempSummary.Sources,
+					Errors:              tempSummary.Errors,
+					HTTPCodes:           tempSummary.HTTPCodes,
+					Endpoints:           tempSummary.Endpoints,
+					Flow:                tempSummary.Flow,
+					Phases:              tempSummary.Phases,
+					AggregatedStats:     tempSummary.AggregatedStats,
+					RequestStats:        tempSummary.RequestStats,
 				}
 				summaries = append(summaries, summary)
 			}
@@ -266,6 +423,15 @@ func TestStorageOneLoaderOpts(t *testing.T) {
 			require.Nil(t, err)
 			require.NotNil(t, loaderWithSummaries)
 			require.EqualValues(t, summaries, loaderWithSummaries)
+
+			for _, summary := range summaries {
+				summaryByID, err := store.GetSummaryByID(summary.UUID, true)
+				require.Nil(t, err)
+				require.EqualValues(t, summary, summaryByID)
+			}
+
+			_, err = store.GetSummaryByID(uuid.NewString(), false)
+			require.ErrorIs(t, err, ErrNotFound)
 		})
 	}
 }
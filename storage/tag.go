@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/mattn/go-sqlite3"
 	"github.com/tmwalaszek/hload/model"
 )
@@ -107,13 +108,25 @@ func (s *Storage) InsertLoaderConfigurationTags(loaderConfUUID string, loaderCon
 		}
 	}()
 
+	err = s.insertLoaderTags(tx, loaderConfUUID, loaderConfigurationTags)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	return
+}
+
+// insertLoaderTags saves the loader tags within the transaction
+func (s *Storage) insertLoaderTags(tx *sqlx.Tx, loaderConfUUID string, loaderConfigurationTags []*model.LoaderTag) error {
 	for _, t := range loaderConfigurationTags {
 		tag := loaderTagTable{
 			LoaderConfigurationUUID: loaderConfUUID,
 			LoaderTag:               *t,
 		}
 
-		err = s.insertTable(tx, loaderConfigurationTagInsert, tag)
+		err := s.insertTable(tx, loaderConfigurationTagInsert, tag)
 		if err != nil {
 			var sqliteErr sqlite3.Error
 			if errors.As(err, &sqliteErr) {
@@ -130,7 +143,5 @@ func (s *Storage) InsertLoaderConfigurationTags(loaderConfUUID string, loaderCon
 		}
 	}
 
-	err = tx.Commit()
-
-	return
+	return nil
 }
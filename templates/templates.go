@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/tmwalaszek/hload/loader"
 	"github.com/tmwalaszek/hload/model"
 	"github.com/tmwalaszek/hload/storage"
 )
@@ -75,6 +76,15 @@ func (r *RenderTemplate) render(templateName string, data any) ([]byte, error) {
 
 			return x.In(loc)
 		},
+		// percentile returns the summary request duration percentile, n/a for the summaries without the t-digest
+		"percentile": func(s *model.Summary, p float64) string {
+			d, err := loader.Quantile(s.TDigest, p)
+			if err != nil {
+				return "n/a"
+			}
+
+			return d.String()
+		},
 	}
 
 	t := template.Must(template.New("new").Funcs(funcsAdd).Parse(r.content))
@@ -2,8 +2,11 @@ package templates
 
 import (
 	"testing"
+	"time"
 
+	"github.com/caio/go-tdigest/v4"
 	"github.com/stretchr/testify/require"
+	"github.com/tmwalaszek/hload/loader"
 	"github.com/tmwalaszek/hload/model"
 )
 
@@ -23,3 +26,42 @@ func TestRenderOutput(t *testing.T) {
 	_, err = r.RenderOutput(loaders)
 	require.NoError(t, err)
 }
+
+func TestRenderPercentiles(t *testing.T) {
+	digest, err := tdigest.New()
+	require.NoError(t, err)
+
+	for i := 1; i <= 1000; i++ {
+		require.NoError(t, digest.Add(float64(time.Duration(i)*time.Millisecond)))
+	}
+
+	summary := &model.Summary{TDigest: digest.ToBytes(nil)}
+	require.NoError(t, loader.SetPercentiles(summary, []float64{99.9}))
+
+	r, err := NewRenderTemplate("default", "")
+	require.NoError(t, err)
+
+	b, err := r.RenderSummary(summary, false, false)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "P99.9 time:")
+	require.Contains(t, string(b), summary.Percentiles[0].Value.String())
+
+	r = &RenderTemplate{content: `{{ define "summary" }}{{ percentile .Summary 50 }} {{ percentile .Empty 50 }}{{ end }}`}
+	b, err = r.render("summary", map[string]*model.Summary{"Summary": summary, "Empty": {}})
+	require.NoError(t, err)
+	require.Equal(t, "500.5ms n/a", string(b))
+}
+
+func TestRenderSources(t *testing.T) {
+	r, err := NewRenderTemplate("default", "")
+	require.NoError(t, err)
+
+	b, err := r.RenderSummary(&model.Summary{Sources: []string{"a", "b"}}, false, false)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "Merged from:")
+	require.Contains(t, string(b), "a, b\n")
+
+	b, err = r.RenderSummary(&model.Summary{}, false, false)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "Merged from:")
+}